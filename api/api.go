@@ -0,0 +1,32 @@
+package api
+
+//go:generate protoc -I proto --go_out=.. --go_opt=module=Backend-trainee-assignment --go-grpc_out=.. --go-grpc_opt=module=Backend-trainee-assignment review/v1/review.proto
+
+import (
+	_ "embed"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Спецификация OpenAPI 3 для всех эндпоинтов сервиса
+//
+//go:embed openapi.json
+var Spec []byte
+
+// Страница Swagger UI, загружающая спецификацию с /openapi.json
+//
+//go:embed swagger.html
+var SwaggerUI []byte
+
+// Функция разбирает и проверяет встроенную спецификацию
+func Load() (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(Spec)
+	if err != nil {
+		return nil, err
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
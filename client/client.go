@@ -0,0 +1,184 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Заголовок ключа идемпотентности, сервер повторяет сохраненный ответ для того же ключа
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Заголовок, которым сервер помечает повторенный ответ
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// Клиент HTTP API сервиса назначения ревьюеров
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+}
+
+type Option func(*Client)
+
+// Опция задает HTTP-клиент, по умолчанию используется клиент с таймаутом 30s
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// Опция задает число повторов и начальную паузу между ними (удваивается с каждой попыткой)
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		maxRetries: 2,
+		backoff:    200 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Функция выполняет GET-запрос и декодирует ответ в out
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return c.do(ctx, http.MethodGet, path, nil, out)
+}
+
+// Функция выполняет POST-запрос с телом in и декодирует ответ в out
+func (c *Client) post(ctx context.Context, path string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("ошибка при кодировании запроса: %w", err)
+	}
+	return c.do(ctx, http.MethodPost, path, body, out)
+}
+
+// Функция отправляет запрос с повторами. POST-запросы получают один ключ идемпотентности
+// на все попытки, поэтому повтор после потерянного ответа не выполняет операцию дважды
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	idempotencyKey := ""
+	if method == http.MethodPost {
+		idempotencyKey = newIdempotencyKey()
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := c.backoff << (attempt - 1)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		lastErr = c.send(ctx, method, path, body, idempotencyKey, out)
+		if lastErr == nil || !retryable(ctx, lastErr) {
+			return lastErr
+		}
+	}
+	return lastErr
+}
+
+// Функция выполняет одну попытку запроса
+func (c *Client) send(ctx context.Context, method, path string, body []byte, idempotencyKey string, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set(IdempotencyKeyHeader, idempotencyKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return &transportError{err: err}
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &transportError{err: err}
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		err := decodeError(resp.StatusCode, data)
+		err.Replayed = resp.Header.Get(IdempotentReplayedHeader) == "true"
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("ошибка при разборе ответа %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// Функция решает, стоит ли повторять запрос: сетевые ошибки, 5xx и запрос в обработке.
+// Повторенный сервером ответ окончателен: операция с этим ключом уже выполнена
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	switch e := err.(type) {
+	case *transportError:
+		return true
+	case *Error:
+		if e.Replayed {
+			return false
+		}
+		return e.StatusCode >= http.StatusInternalServerError || e.Code == CodeRequestInProgress
+	}
+	return false
+}
+
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// Ошибка соединения или чтения ответа
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string {
+	return e.err.Error()
+}
+
+func (e *transportError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,214 @@
+package client
+
+import (
+	"Backend-trainee-assignment/handler"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Ответ тестового сервера на очередную попытку
+type scriptedResponse struct {
+	status   int
+	body     string
+	replayed bool
+	// Соединение закрывается без ответа
+	drop bool
+}
+
+// Тестовый сервер, отвечающий по сценарию и запоминающий ключи идемпотентности попыток
+type scriptedServer struct {
+	*httptest.Server
+	mu   sync.Mutex
+	keys []string
+}
+
+func newScriptedServer(t *testing.T, script ...scriptedResponse) *scriptedServer {
+	t.Helper()
+	s := &scriptedServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		attempt := len(s.keys)
+		s.keys = append(s.keys, r.Header.Get(IdempotencyKeyHeader))
+		s.mu.Unlock()
+		if attempt >= len(script) {
+			t.Errorf("unexpected attempt %d", attempt+1)
+			w.WriteHeader(http.StatusTeapot)
+			return
+		}
+		resp := script[attempt]
+		if resp.drop {
+			dropConnection(t, w)
+			return
+		}
+		if resp.replayed {
+			w.Header().Set(IdempotentReplayedHeader, "true")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.status)
+		w.Write([]byte(resp.body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *scriptedServer) attempts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.keys...)
+}
+
+func dropConnection(t *testing.T, w http.ResponseWriter) {
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		t.Errorf("hijack: %v", err)
+		return
+	}
+	conn.Close()
+}
+
+const (
+	mergedBody   = `{"pr":{"pull_request_id":"pr-1","status":"MERGED"}}`
+	internalBody = `{"error":{"code":"INTERNAL_ERROR","message":"db down"}}`
+)
+
+func TestClientRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       []scriptedResponse
+		wantAttempts int
+		wantCode     string
+	}{
+		{
+			name:         "5xx then success",
+			script:       []scriptedResponse{{status: 503, body: internalBody}, {status: 502, body: internalBody}, {status: 200, body: mergedBody}},
+			wantAttempts: 3,
+		},
+		{
+			name:         "lost response then success",
+			script:       []scriptedResponse{{drop: true}, {status: 200, body: mergedBody}},
+			wantAttempts: 2,
+		},
+		{
+			name:         "in progress then success",
+			script:       []scriptedResponse{{status: 409, body: `{"error":{"code":"REQUEST_IN_PROGRESS","message":"busy"}}`}, {status: 200, body: mergedBody}},
+			wantAttempts: 2,
+		},
+		{
+			name:         "retries exhausted",
+			script:       []scriptedResponse{{status: 500, body: internalBody}, {status: 500, body: internalBody}, {status: 500, body: internalBody}},
+			wantAttempts: 3,
+			wantCode:     CodeInternal,
+		},
+		{
+			name:         "4xx is not retried",
+			script:       []scriptedResponse{{status: 409, body: `{"error":{"code":"PR_MERGED","message":"merged"}}`}},
+			wantAttempts: 1,
+			wantCode:     CodePRMerged,
+		},
+		{
+			name:         "replayed 5xx is final",
+			script:       []scriptedResponse{{drop: true}, {status: 500, body: internalBody, replayed: true}},
+			wantAttempts: 2,
+			wantCode:     CodeInternal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newScriptedServer(t, tt.script...)
+			c := New(server.URL, WithRetries(2, time.Millisecond))
+
+			pr, err := c.MergePR(context.Background(), "pr-1")
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("MergePR: %v", err)
+				}
+				if pr.Status != "MERGED" {
+					t.Fatalf("status %q", pr.Status)
+				}
+			} else if !IsCode(err, tt.wantCode) {
+				t.Fatalf("error %v, want %s", err, tt.wantCode)
+			}
+
+			keys := server.attempts()
+			if len(keys) != tt.wantAttempts {
+				t.Fatalf("attempts %d, want %d", len(keys), tt.wantAttempts)
+			}
+			for _, key := range keys {
+				if key == "" || key != keys[0] {
+					t.Fatalf("idempotency keys %v, want one key for all attempts", keys)
+				}
+			}
+		})
+	}
+}
+
+func TestClientGetHasNoIdempotencyKey(t *testing.T) {
+	server := newScriptedServer(t, scriptedResponse{status: 503, body: internalBody}, scriptedResponse{status: 200, body: mergedBody})
+	c := New(server.URL, WithRetries(2, time.Millisecond))
+	if _, err := c.GetPR(context.Background(), "pr-1"); err != nil {
+		t.Fatalf("GetPR: %v", err)
+	}
+	if keys := server.attempts(); len(keys) != 2 || keys[0] != "" || keys[1] != "" {
+		t.Fatalf("attempt keys %q, want two attempts without key", keys)
+	}
+}
+
+func TestClientStopsRetryingWhenContextDone(t *testing.T) {
+	server := newScriptedServer(t, scriptedResponse{status: 503, body: internalBody})
+	c := New(server.URL, WithRetries(5, time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := c.MergePR(ctx, "pr-1")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error %v, want deadline exceeded", err)
+	}
+	if n := len(server.attempts()); n != 1 {
+		t.Fatalf("attempts %d, want 1", n)
+	}
+}
+
+// Клиент и хранилище идемпотентности сервера вместе: ответ на первую попытку теряется,
+// повтор получает сохраненный ответ, и операция выполняется один раз
+func TestClientRetryReplaysServerResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var merges atomic.Int32
+	router := gin.New()
+	router.Use(handler.ErrorMiddleware(), handler.NewIdempotencyStore(time.Hour).Middleware())
+	router.POST("/pullRequest/merge", func(c *gin.Context) {
+		merges.Add(1)
+		c.JSON(http.StatusOK, gin.H{"pr": gin.H{"pull_request_id": "pr-1", "status": "MERGED"}})
+	})
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			// Сервер выполняет операцию, но ответ до клиента не доходит
+			router.ServeHTTP(httptest.NewRecorder(), r)
+			dropConnection(t, w)
+			return
+		}
+		router.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithRetries(2, time.Millisecond))
+	pr, err := c.MergePR(context.Background(), "pr-1")
+	if err != nil {
+		t.Fatalf("MergePR: %v", err)
+	}
+	if pr.Status != "MERGED" {
+		t.Fatalf("status %q", pr.Status)
+	}
+	if merges.Load() != 1 || requests.Load() != 2 {
+		t.Fatalf("merges %d, requests %d, want 1 merge over 2 requests", merges.Load(), requests.Load())
+	}
+}
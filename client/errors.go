@@ -0,0 +1,76 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Коды ошибок API
+const (
+	CodeValidation        = "VALIDATION_ERROR"
+	CodeNotFound          = "NOT_FOUND"
+	CodeTeamExists        = "TEAM_EXISTS"
+	CodePRExists          = "PR_EXISTS"
+	CodeRepositoryExists  = "REPOSITORY_EXISTS"
+	CodePRMerged          = "PR_MERGED"
+	CodeNotAssigned       = "NOT_ASSIGNED"
+	CodeNoCandidate       = "NO_CANDIDATE"
+	CodeAlreadyMember     = "ALREADY_MEMBER"
+	CodeNotMember         = "NOT_MEMBER"
+	CodeMaxReviewers      = "MAX_REVIEWERS"
+	CodeUserInactive      = "USER_INACTIVE"
+	CodeAuthorSelfReview  = "AUTHOR_SELF_REVIEW"
+	CodeAlreadyAssigned   = "ALREADY_ASSIGNED"
+	CodeNoTargetTeam      = "NO_TARGET_TEAM"
+	CodeRequestInProgress = "REQUEST_IN_PROGRESS"
+	CodeKeyReused         = "IDEMPOTENCY_KEY_REUSED"
+	CodeTimeout           = "TIMEOUT"
+	CodeInternal          = "INTERNAL_ERROR"
+)
+
+// Ошибка API, разобранная из ответа {"error": {"code", "message"}}
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	// Ответ повторен сервером по ключу идемпотентности, запрос с этим ключом уже выполнен
+	Replayed bool
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// Функция проверяет, что ошибка является ошибкой API с указанным кодом
+func IsCode(err error, code string) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// Функция проверяет, что ресурс не найден
+func IsNotFound(err error) bool {
+	return IsCode(err, CodeNotFound)
+}
+
+func decodeError(status int, body []byte) *Error {
+	var envelope struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope.Error.Code == "" {
+		code := CodeInternal
+		if status < http.StatusInternalServerError {
+			code = http.StatusText(status)
+		}
+		return &Error{StatusCode: status, Code: code, Message: string(body)}
+	}
+	return &Error{
+		StatusCode: status,
+		Code:       envelope.Error.Code,
+		Message:    envelope.Error.Message,
+	}
+}
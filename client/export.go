@@ -0,0 +1,126 @@
+package client
+
+import (
+	"Backend-trainee-assignment/models"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Выгрузки сервиса
+const (
+	ExportPullRequests = "pull-requests"
+	ExportAssignments  = "assignments"
+	ExportUserStats    = "user-stats"
+)
+
+// Первое поле последней строки CSV, если выгрузку на сервере оборвала ошибка
+const exportErrorMarker = "#error"
+
+// Функция копирует выгрузку kind в формате csv или ndjson в w и возвращает число байт.
+// Выгрузка не повторяется и ограничена только ctx: таймаут HTTP-клиента оборвал бы большой поток.
+// Если сервер завершил выгрузку строкой с ошибкой, строка не копируется и возвращается *Error
+func (c *Client) Export(ctx context.Context, kind, format string, f models.ExportFilter, w io.Writer) (int64, error) {
+	params := url.Values{"format": {format}}
+	if f.TeamName != "" {
+		params.Set("team_name", f.TeamName)
+	}
+	if f.UserID != "" {
+		params.Set("user_id", f.UserID)
+	}
+	if f.Status != "" {
+		params.Set("status", f.Status)
+	}
+	if f.From != nil {
+		params.Set("from", f.From.Format(time.RFC3339))
+	}
+	if f.To != nil {
+		params.Set("to", f.To.Format(time.RFC3339))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/export/"+kind+"?"+params.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+	httpClient := *c.httpClient
+	httpClient.Timeout = 0
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, &transportError{err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return 0, &transportError{err: err}
+		}
+		return 0, decodeError(resp.StatusCode, data)
+	}
+	tail := &exportTail{w: w}
+	if _, err := io.Copy(tail, resp.Body); err != nil {
+		return tail.written, fmt.Errorf("ошибка при чтении выгрузки %s: %w", kind, err)
+	}
+	if apiErr := exportError(resp.StatusCode, tail.pending); apiErr != nil {
+		return tail.written, apiErr
+	}
+	if _, err := tail.w.Write(tail.pending); err != nil {
+		return tail.written, err
+	}
+	return tail.written + int64(len(tail.pending)), nil
+}
+
+// Writer, задерживающий последнюю строку потока, чтобы проверить, не ошибка ли это
+type exportTail struct {
+	w       io.Writer
+	pending []byte
+	written int64
+}
+
+func (t *exportTail) Write(p []byte) (int, error) {
+	t.pending = append(t.pending, p...)
+	// Последняя строка (завершенная или нет) остается в pending, все до нее записывается
+	end := len(t.pending)
+	if end > 0 && t.pending[end-1] == '\n' {
+		end--
+	}
+	cut := bytes.LastIndexByte(t.pending[:end], '\n') + 1
+	if cut == 0 {
+		return len(p), nil
+	}
+	n, err := t.w.Write(t.pending[:cut])
+	t.written += int64(n)
+	if err != nil {
+		return 0, err
+	}
+	t.pending = append(t.pending[:0], t.pending[cut:]...)
+	return len(p), nil
+}
+
+// Функция разбирает последнюю строку выгрузки: {"error": {...}} в NDJSON или #error,код,сообщение в CSV
+func exportError(status int, line []byte) *Error {
+	if bytes.HasPrefix(line, []byte(`{"error":`)) {
+		var record struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(line, &record); err == nil && record.Error.Code != "" {
+			return &Error{StatusCode: status, Code: record.Error.Code, Message: record.Error.Message}
+		}
+	}
+	if bytes.HasPrefix(line, []byte(exportErrorMarker+",")) {
+		record, err := csv.NewReader(bytes.NewReader(line)).Read()
+		if err == nil && len(record) == 3 {
+			return &Error{StatusCode: status, Code: record[1], Message: record[2]}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"Backend-trainee-assignment/models"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExportDetectsTerminalError(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		want     string
+		wantCode string
+	}{
+		{name: "csv complete", body: "id\nr1\nr2\n", want: "id\nr1\nr2\n"},
+		{name: "no trailing newline", body: "{\"id\":\"r1\"}\n{\"id\":\"r2\"}", want: "{\"id\":\"r1\"}\n{\"id\":\"r2\"}"},
+		{name: "csv error", body: "id\nr1\n#error,TIMEOUT,request deadline exceeded\n", want: "id\nr1\n", wantCode: CodeTimeout},
+		{
+			name:     "ndjson error",
+			body:     "{\"id\":\"r1\"}\n{\"error\":{\"code\":\"INTERNAL_ERROR\",\"message\":\"connection reset\"}}\n",
+			want:     "{\"id\":\"r1\"}\n",
+			wantCode: CodeInternal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// Поток отдается частями, граница не совпадает с концом строки
+				for i := 0; i < len(tt.body); i += 5 {
+					w.Write([]byte(tt.body[i:min(i+5, len(tt.body))]))
+					w.(http.Flusher).Flush()
+				}
+			}))
+			defer server.Close()
+
+			var out bytes.Buffer
+			n, err := New(server.URL).Export(context.Background(), ExportPullRequests, "csv", models.ExportFilter{}, &out)
+			if tt.wantCode == "" && err != nil {
+				t.Fatalf("Export: %v", err)
+			}
+			if tt.wantCode != "" && !IsCode(err, tt.wantCode) {
+				t.Fatalf("error %v, want %s", err, tt.wantCode)
+			}
+			if out.String() != tt.want || n != int64(len(tt.want)) {
+				t.Fatalf("output %q (%d bytes), want %q", out.String(), n, tt.want)
+			}
+		})
+	}
+}
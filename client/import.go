@@ -0,0 +1,20 @@
+package client
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+)
+
+// Функция импортирует оргструктуру в формате csv или yaml; при dryRun возвращает план без изменений
+func (c *Client) ImportOrgChart(ctx context.Context, format string, content []byte, dryRun bool) (*models.ImportPlan, error) {
+	req := map[string]interface{}{
+		"format":  format,
+		"content": string(content),
+		"dry_run": dryRun,
+	}
+	var plan models.ImportPlan
+	if err := c.post(ctx, "/team/import", req, &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
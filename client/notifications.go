@@ -0,0 +1,25 @@
+package client
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"net/url"
+)
+
+// Функция возвращает адрес и отказы от писем пользователя
+func (c *Client) GetNotificationPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+	var prefs models.NotificationPreferences
+	if err := c.get(ctx, "/users/notifications", url.Values{"user_id": {userID}}, &prefs); err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+// Функция заменяет настройки писем пользователя; пустой Email отключает письма
+func (c *Client) SetNotificationPreferences(ctx context.Context, prefs models.NotificationPreferences) (*models.NotificationPreferences, error) {
+	var resp models.NotificationPreferences
+	if err := c.post(ctx, "/users/notifications/set", prefs, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
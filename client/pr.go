@@ -0,0 +1,121 @@
+package client
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"net/url"
+)
+
+// Запрос на создание PR. TeamName и RepositoryID необязательны
+type CreatePRRequest struct {
+	PullRequestID   string   `json:"pull_request_id"`
+	PullRequestName string   `json:"pull_request_name"`
+	AuthorID        string   `json:"author_id"`
+	TeamName        string   `json:"team_name,omitempty"`
+	RepositoryID    string   `json:"repository_id,omitempty"`
+	Labels          []string `json:"labels,omitempty"`
+}
+
+// Результат создания PR, Warning заполнен, если ревьюеров назначить не удалось
+type CreatePRResult struct {
+	PR      models.PullRequest `json:"pr"`
+	Warning string             `json:"warning,omitempty"`
+}
+
+// Функция создает PR и назначает ревьюеров
+func (c *Client) CreatePR(ctx context.Context, req CreatePRRequest) (*CreatePRResult, error) {
+	var result CreatePRResult
+	if err := c.post(ctx, "/pullRequest/create", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Функция возвращает PR с назначенными ревьюерами
+func (c *Client) GetPR(ctx context.Context, prID string) (*models.PullRequest, error) {
+	var resp prResponse
+	if err := c.get(ctx, "/pullRequest/get", url.Values{"pull_request_id": {prID}}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.PR, nil
+}
+
+// Функция мержит PR
+func (c *Client) MergePR(ctx context.Context, prID string) (*models.PullRequest, error) {
+	var resp prResponse
+	if err := c.post(ctx, "/pullRequest/merge", map[string]string{"pull_request_id": prID}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.PR, nil
+}
+
+// Функция переназначает ревьюера, возвращает PR и ID нового ревьюера
+func (c *Client) ReassignReviewer(ctx context.Context, prID, oldUserID string) (*models.PullRequest, string, error) {
+	req := map[string]string{
+		"pull_request_id": prID,
+		"old_user_id":     oldUserID,
+	}
+	var resp struct {
+		PR         models.PullRequest `json:"pr"`
+		ReplacedBy string             `json:"replaced_by"`
+	}
+	if err := c.post(ctx, "/pullRequest/reassign", req, &resp); err != nil {
+		return nil, "", err
+	}
+	return &resp.PR, resp.ReplacedBy, nil
+}
+
+// Функция добавляет ревьюера к PR
+func (c *Client) AddReviewer(ctx context.Context, prID, reviewerID string) (*models.PullRequest, error) {
+	var resp prResponse
+	path := "/api/pull-requests/" + url.PathEscape(prID) + "/reviewers"
+	if err := c.post(ctx, path, map[string]string{"reviewer_id": reviewerID}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.PR, nil
+}
+
+// Функция возвращает метки PR
+func (c *Client) GetLabels(ctx context.Context, prID string) ([]string, error) {
+	var resp labelsResponse
+	if err := c.get(ctx, "/pullRequest/labels", url.Values{"pull_request_id": {prID}}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Labels, nil
+}
+
+// Функция добавляет метки PR
+func (c *Client) AddLabels(ctx context.Context, prID string, labels []string) ([]string, error) {
+	return c.updateLabels(ctx, "/pullRequest/labels/add", prID, labels)
+}
+
+// Функция удаляет метки PR
+func (c *Client) RemoveLabels(ctx context.Context, prID string, labels []string) ([]string, error) {
+	return c.updateLabels(ctx, "/pullRequest/labels/remove", prID, labels)
+}
+
+// Функция заменяет метки PR
+func (c *Client) SetLabels(ctx context.Context, prID string, labels []string) ([]string, error) {
+	return c.updateLabels(ctx, "/pullRequest/labels/set", prID, labels)
+}
+
+type prResponse struct {
+	PR models.PullRequest `json:"pr"`
+}
+
+type labelsResponse struct {
+	PullRequestID string   `json:"pull_request_id"`
+	Labels        []string `json:"labels"`
+}
+
+func (c *Client) updateLabels(ctx context.Context, path, prID string, labels []string) ([]string, error) {
+	req := map[string]interface{}{
+		"pull_request_id": prID,
+		"labels":          nonNil(labels),
+	}
+	var resp labelsResponse
+	if err := c.post(ctx, path, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Labels, nil
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"net/url"
+)
+
+// Виды напоминаний для RunReminders
+const (
+	ReminderDigest     = "digest"
+	ReminderEscalation = "escalation"
+)
+
+// Функция возвращает расписания напоминаний команды
+func (c *Client) GetReminders(ctx context.Context, teamName string) (*models.TeamReminders, error) {
+	var reminders models.TeamReminders
+	if err := c.get(ctx, "/team/reminders", url.Values{"team_name": {teamName}}, &reminders); err != nil {
+		return nil, err
+	}
+	return &reminders, nil
+}
+
+// Функция задает расписания напоминаний команды
+func (c *Client) SetReminders(ctx context.Context, reminders models.TeamReminders) (*models.TeamReminders, error) {
+	var resp models.TeamReminders
+	if err := c.post(ctx, "/team/reminders/set", reminders, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Функция отправляет напоминание команде вне расписания, возвращает количество отправленного
+func (c *Client) RunReminders(ctx context.Context, teamName, kind string) (int, error) {
+	req := map[string]string{"team_name": teamName, "kind": kind}
+	var resp struct {
+		Sent int `json:"sent"`
+	}
+	if err := c.post(ctx, "/team/reminders/run", req, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Sent, nil
+}
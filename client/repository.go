@@ -0,0 +1,36 @@
+package client
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"net/url"
+)
+
+// Функция создает репозиторий с пулом ревьюеров
+func (c *Client) AddRepository(ctx context.Context, repo models.Repository) (*models.Repository, error) {
+	return c.saveRepository(ctx, "/repository/add", repo)
+}
+
+// Функция обновляет репозиторий и его пул ревьюеров
+func (c *Client) UpdateRepository(ctx context.Context, repo models.Repository) (*models.Repository, error) {
+	return c.saveRepository(ctx, "/repository/update", repo)
+}
+
+// Функция возвращает репозиторий
+func (c *Client) GetRepository(ctx context.Context, repositoryID string) (*models.Repository, error) {
+	var repo models.Repository
+	if err := c.get(ctx, "/repository/get", url.Values{"repository_id": {repositoryID}}, &repo); err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+func (c *Client) saveRepository(ctx context.Context, path string, repo models.Repository) (*models.Repository, error) {
+	var resp struct {
+		Repository models.Repository `json:"repository"`
+	}
+	if err := c.post(ctx, path, repo, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Repository, nil
+}
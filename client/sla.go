@@ -0,0 +1,68 @@
+package client
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"net/url"
+)
+
+// Решения ревьюера по PR
+const (
+	DecisionApproved         = "APPROVED"
+	DecisionChangesRequested = "CHANGES_REQUESTED"
+	DecisionCommented        = "COMMENTED"
+)
+
+// Функция фиксирует решение ревьюера по PR и возвращает его первое решение
+func (c *Client) SubmitReview(ctx context.Context, prID, reviewerID, decision string) (*models.ReviewDecision, error) {
+	req := map[string]string{
+		"pull_request_id": prID,
+		"reviewer_id":     reviewerID,
+		"decision":        decision,
+	}
+	var resp struct {
+		Review models.ReviewDecision `json:"review"`
+	}
+	if err := c.post(ctx, "/pullRequest/review", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Review, nil
+}
+
+// Функция возвращает назначения, превысившие SLA; пустой teamName - по всем командам
+func (c *Client) GetOverdue(ctx context.Context, teamName string) ([]models.OverdueAssignment, error) {
+	query := url.Values{}
+	if teamName != "" {
+		query.Set("team_name", teamName)
+	}
+	var resp struct {
+		Overdue []models.OverdueAssignment `json:"overdue"`
+	}
+	if err := c.get(ctx, "/sla/overdue", query, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Overdue, nil
+}
+
+// Функция возвращает порог SLA команды
+func (c *Client) GetTeamSLA(ctx context.Context, teamName string) (*models.TeamSLA, error) {
+	var sla models.TeamSLA
+	if err := c.get(ctx, "/team/sla", url.Values{"team_name": {teamName}}, &sla); err != nil {
+		return nil, err
+	}
+	return &sla, nil
+}
+
+// Функция задает порог SLA команды
+func (c *Client) SetTeamSLA(ctx context.Context, teamName string, thresholdHours int, autoReassign bool) (*models.TeamSLA, error) {
+	req := struct {
+		TeamName       string `json:"team_name"`
+		ThresholdHours int    `json:"threshold_hours"`
+		AutoReassign   bool   `json:"auto_reassign"`
+	}{teamName, thresholdHours, autoReassign}
+	var sla models.TeamSLA
+	if err := c.post(ctx, "/team/sla/set", req, &sla); err != nil {
+		return nil, err
+	}
+	return &sla, nil
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Функция возвращает статистику за период; пустые поля запроса не ограничивают выборку
+func (c *Client) Stats(ctx context.Context, q models.StatsQuery) (*models.StatsReport, error) {
+	params := url.Values{}
+	if q.From != nil {
+		params.Set("from", q.From.Format(time.RFC3339))
+	}
+	if q.To != nil {
+		params.Set("to", q.To.Format(time.RFC3339))
+	}
+	if q.TeamName != "" {
+		params.Set("team", q.TeamName)
+	}
+	if q.GroupBy != "" {
+		params.Set("group_by", q.GroupBy)
+	}
+	if q.Top > 0 {
+		params.Set("top", strconv.Itoa(q.Top))
+	}
+	var stats models.StatsReport
+	if err := c.get(ctx, "/api/stats", params, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// Функция возвращает отчет о равномерности нагрузки ревьюеров; нулевые From и To - последние 30 дней
+func (c *Client) Fairness(ctx context.Context, q models.FairnessQuery) (*models.FairnessReport, error) {
+	params := url.Values{}
+	if !q.From.IsZero() {
+		params.Set("from", q.From.Format(time.RFC3339))
+	}
+	if !q.To.IsZero() {
+		params.Set("to", q.To.Format(time.RFC3339))
+	}
+	if q.TeamName != "" {
+		params.Set("team", q.TeamName)
+	}
+	if q.GroupBy != "" {
+		params.Set("group_by", q.GroupBy)
+	}
+	if q.Tolerance > 0 {
+		params.Set("tolerance", strconv.FormatFloat(q.Tolerance, 'f', -1, 64))
+	}
+	var report models.FairnessReport
+	if err := c.get(ctx, "/api/stats/fairness", params, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
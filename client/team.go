@@ -0,0 +1,101 @@
+package client
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"net/url"
+)
+
+// Запрос на добавление участника, IsActive == nil сохраняет текущий статус пользователя
+type AddMemberRequest struct {
+	TeamName string `json:"team_name"`
+	UserID   string `json:"user_id"`
+	Username string `json:"username,omitempty"`
+	IsActive *bool  `json:"is_active,omitempty"`
+}
+
+// Результат массовой деактивации
+type MassDeactivateResult struct {
+	models.DeactivationReport
+	ProcessingTimeMs int64  `json:"processing_time_ms"`
+	Message          string `json:"message"`
+}
+
+// Функция создает команду с участниками
+func (c *Client) AddTeam(ctx context.Context, team models.Team) (*models.Team, error) {
+	var resp struct {
+		Team models.Team `json:"team"`
+	}
+	if err := c.post(ctx, "/team/add", team, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Team, nil
+}
+
+// Функция возвращает команду с участниками
+func (c *Client) GetTeam(ctx context.Context, teamName string) (*models.Team, error) {
+	var team models.Team
+	if err := c.get(ctx, "/team/get", url.Values{"team_name": {teamName}}, &team); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// Функция добавляет участника в команду
+func (c *Client) AddMember(ctx context.Context, req AddMemberRequest) (*models.MembershipReport, error) {
+	return c.membership(ctx, "/team/addMember", req)
+}
+
+// Функция убирает участника из команды
+func (c *Client) RemoveMember(ctx context.Context, teamName, userID string) (*models.MembershipReport, error) {
+	return c.membership(ctx, "/team/removeMember", map[string]string{
+		"team_name": teamName,
+		"user_id":   userID,
+	})
+}
+
+// Функция переводит участника в другую команду
+func (c *Client) MoveMember(ctx context.Context, userID, fromTeamName, toTeamName string) (*models.MembershipReport, error) {
+	return c.membership(ctx, "/team/moveMember", map[string]string{
+		"user_id":        userID,
+		"from_team_name": fromTeamName,
+		"to_team_name":   toTeamName,
+	})
+}
+
+// Функция переименовывает команду
+func (c *Client) RenameTeam(ctx context.Context, teamName, newTeamName string) (*models.MembershipReport, error) {
+	return c.membership(ctx, "/team/rename", map[string]string{
+		"team_name":     teamName,
+		"new_team_name": newTeamName,
+	})
+}
+
+// Функция удаляет команду
+func (c *Client) DeleteTeam(ctx context.Context, teamName string) (*models.MembershipReport, error) {
+	return c.membership(ctx, "/team/delete", map[string]string{"team_name": teamName})
+}
+
+// Функция массово деактивирует пользователей команды, с dryRun только возвращает отчет
+func (c *Client) MassDeactivate(ctx context.Context, teamName string, userIDs []string, dryRun bool) (*MassDeactivateResult, error) {
+	req := map[string]interface{}{
+		"team_name": teamName,
+		"user_ids":  userIDs,
+		"dry_run":   dryRun,
+	}
+	var result MassDeactivateResult
+	if err := c.post(ctx, "/team/massDeactivate", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) membership(ctx context.Context, path string, req interface{}) (*models.MembershipReport, error) {
+	var resp struct {
+		Report models.MembershipReport `json:"report"`
+	}
+	if err := c.post(ctx, path, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Report, nil
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"net/url"
+)
+
+// Функция устанавливает флаг активности пользователя
+func (c *Client) SetIsActive(ctx context.Context, userID string, isActive bool) (*models.User, error) {
+	req := map[string]interface{}{
+		"user_id":   userID,
+		"is_active": isActive,
+	}
+	var resp struct {
+		User models.User `json:"user"`
+	}
+	if err := c.post(ctx, "/users/setIsActive", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.User, nil
+}
+
+// Функция возвращает PR, где пользователь назначен ревьюером
+func (c *Client) GetReview(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
+	var resp struct {
+		PullRequests []models.PullRequestShort `json:"pull_requests"`
+	}
+	if err := c.get(ctx, "/users/getReview", url.Values{"user_id": {userID}}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.PullRequests, nil
+}
+
+// Функция возвращает навыки пользователя
+func (c *Client) GetSkills(ctx context.Context, userID string) ([]string, error) {
+	var resp skillsResponse
+	if err := c.get(ctx, "/users/skills", url.Values{"user_id": {userID}}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Skills, nil
+}
+
+// Функция добавляет навыки пользователю
+func (c *Client) AddSkills(ctx context.Context, userID string, skills []string) ([]string, error) {
+	return c.updateSkills(ctx, "/users/skills/add", userID, skills)
+}
+
+// Функция удаляет навыки пользователя
+func (c *Client) RemoveSkills(ctx context.Context, userID string, skills []string) ([]string, error) {
+	return c.updateSkills(ctx, "/users/skills/remove", userID, skills)
+}
+
+// Функция заменяет навыки пользователя
+func (c *Client) SetSkills(ctx context.Context, userID string, skills []string) ([]string, error) {
+	return c.updateSkills(ctx, "/users/skills/set", userID, skills)
+}
+
+type skillsResponse struct {
+	UserID string   `json:"user_id"`
+	Skills []string `json:"skills"`
+}
+
+func (c *Client) updateSkills(ctx context.Context, path, userID string, skills []string) ([]string, error) {
+	req := map[string]interface{}{
+		"user_id": userID,
+		"skills":  nonNil(skills),
+	}
+	var resp skillsResponse
+	if err := c.post(ctx, path, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Skills, nil
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
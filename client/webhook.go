@@ -0,0 +1,30 @@
+package client
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"net/url"
+)
+
+// Функция возвращает вебхук команды, путь адреса в ответе скрыт
+func (c *Client) GetWebhook(ctx context.Context, teamName string) (*models.TeamWebhook, error) {
+	var webhook models.TeamWebhook
+	if err := c.get(ctx, "/team/webhook", url.Values{"team_name": {teamName}}, &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// Функция задает вебхук Slack/Mattermost команды; Enabled передается как есть, false выключает отправку
+func (c *Client) SetWebhook(ctx context.Context, webhook models.TeamWebhook) (*models.TeamWebhook, error) {
+	var resp models.TeamWebhook
+	if err := c.post(ctx, "/team/webhook/set", webhook, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Функция удаляет вебхук команды
+func (c *Client) DeleteWebhook(ctx context.Context, teamName string) error {
+	return c.post(ctx, "/team/webhook/delete", map[string]string{"team_name": teamName}, nil)
+}
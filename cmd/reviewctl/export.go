@@ -0,0 +1,44 @@
+package main
+
+import (
+	"Backend-trainee-assignment/client"
+	"Backend-trainee-assignment/models"
+	"context"
+	"flag"
+)
+
+func exportPullRequests(ctx context.Context, a *app, args []string) error {
+	return export(ctx, a, "export pull-requests", client.ExportPullRequests, args)
+}
+
+func exportAssignments(ctx context.Context, a *app, args []string) error {
+	return export(ctx, a, "export assignments", client.ExportAssignments, args)
+}
+
+func exportUserStats(ctx context.Context, a *app, args []string) error {
+	return export(ctx, a, "export user-stats", client.ExportUserStats, args)
+}
+
+// Функция пишет выгрузку в stdout как есть, --output на нее не влияет
+func export(ctx context.Context, a *app, name, kind string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	format := fs.String("format", "csv", "csv or ndjson")
+	team := fs.String("team", "", "team name")
+	user := fs.String("user", "", "user ID")
+	status := fs.String("status", "", "PR status, OPEN or MERGED")
+	from := fs.String("from", "", "window start, YYYY-MM-DD or RFC 3339")
+	to := fs.String("to", "", "window end (exclusive), YYYY-MM-DD or RFC 3339")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	f := models.ExportFilter{TeamName: *team, UserID: *user, Status: *status}
+	var err error
+	if f.From, err = parseTimeFlag("from", *from); err != nil {
+		return err
+	}
+	if f.To, err = parseTimeFlag("to", *to); err != nil {
+		return err
+	}
+	_, err = a.client.Export(ctx, kind, *format, f, a.out.w)
+	return err
+}
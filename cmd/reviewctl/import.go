@@ -0,0 +1,81 @@
+package main
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func importOrgChart(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	file := fs.String("f", "", "CSV or YAML org chart")
+	format := fs.String("format", "", "csv or yaml, by default from file extension")
+	dryRun := fs.Bool("dry-run", false, "show plan without applying it")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("import: -f is required")
+	}
+	if *format == "" {
+		switch strings.ToLower(filepath.Ext(*file)) {
+		case ".csv":
+			*format = "csv"
+		case ".yaml", ".yml":
+			*format = "yaml"
+		default:
+			return errors.New("import: cannot detect format from file extension, use --format")
+		}
+	}
+	content, err := os.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	plan, err := a.client.ImportOrgChart(ctx, *format, content, *dryRun)
+	if err != nil {
+		return err
+	}
+	return a.out.print(plan, []string{"ACTION", "SUBJECT", "DETAILS"}, planRows(plan))
+}
+
+func planRows(plan *models.ImportPlan) [][]string {
+	var rows [][]string
+	for _, team := range plan.TeamsToCreate {
+		rows = append(rows, []string{"create team", team, "-"})
+	}
+	for _, user := range plan.UsersToAdd {
+		details := "teams=" + joinOrDash(user.Teams)
+		if !user.IsActive {
+			details += " inactive"
+		}
+		rows = append(rows, []string{"add user", user.UserID, details})
+	}
+	for _, move := range plan.UsersToMove {
+		rows = append(rows, []string{"move user", move.UserID, "+" + joinOrDash(move.AddTeams) + " -" + joinOrDash(move.RemoveTeams)})
+	}
+	for _, rename := range plan.UsersToRename {
+		rows = append(rows, []string{"rename user", rename.UserID, rename.OldUsername + " -> " + rename.Username})
+	}
+	for _, userID := range plan.UsersToActivate {
+		rows = append(rows, []string{"activate user", userID, "-"})
+	}
+	for _, userID := range plan.UsersToDeactivate {
+		rows = append(rows, []string{"deactivate user", userID, "-"})
+	}
+	for _, r := range plan.Reassignments {
+		newReviewer := r.NewReviewerID
+		if newReviewer == "" {
+			newReviewer = "(removed)"
+		}
+		rows = append(rows, []string{"reassign review", r.PullRequestID, r.OldReviewerID + " -> " + newReviewer})
+	}
+	status := "applied"
+	if plan.DryRun {
+		status = "dry run, nothing changed"
+	}
+	return append(rows, []string{"result", "-", status})
+}
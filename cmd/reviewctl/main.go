@@ -0,0 +1,159 @@
+// reviewctl - утилита администрирования сервиса назначения ревьюеров через HTTP API
+package main
+
+import (
+	"Backend-trainee-assignment/client"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const usage = `Usage: reviewctl [--server URL] [--output table|json] <command> [args]
+
+Commands:
+  team add -f FILE.yaml           create team from YAML definition
+  team get NAME                   show team members
+  user activate USER_ID           set user active
+  user deactivate USER_ID         set user inactive
+  pr create --id ID --name NAME --author USER_ID [--team T] [--repo R] [--labels a,b]
+  pr show PR_ID                   show PR with reviewers
+  pr merge PR_ID                  merge PR
+  pr reassign PR_ID OLD_USER_ID   replace reviewer
+  import -f ORG.csv|ORG.yaml [--format csv|yaml] [--dry-run]
+                                  sync teams and users with org chart
+  stats [--from D] [--to D] [--team T] [--group-by day|week|month] [--top N]
+                                  show assignment statistics for a window
+  export pull-requests|assignments|user-stats [--format csv|ndjson] [--team T] [--user U]
+         [--status S] [--from D] [--to D]
+                                  stream export to stdout (raise --timeout for large exports)
+  mass-deactivate --team T [--dry-run] USER_ID...
+                                  deactivate users and reassign their reviews
+
+Environment:
+  REVIEWCTL_SERVER                default for --server (http://localhost:8080)
+`
+
+// Контекст выполнения команды
+type app struct {
+	client *client.Client
+	out    *printer
+	stderr io.Writer
+}
+
+type command func(ctx context.Context, a *app, args []string) error
+
+var commands = map[string]map[string]command{
+	"team": {
+		"add": teamAdd,
+		"get": teamGet,
+	},
+	"user": {
+		"activate":   userActivate,
+		"deactivate": userDeactivate,
+	},
+	"pr": {
+		"create":   prCreate,
+		"show":     prShow,
+		"merge":    prMerge,
+		"reassign": prReassign,
+	},
+	"export": {
+		"pull-requests": exportPullRequests,
+		"assignments":   exportAssignments,
+		"user-stats":    exportUserStats,
+	},
+}
+
+var topLevel = map[string]command{
+	"stats":           stats,
+	"import":          importOrgChart,
+	"mass-deactivate": massDeactivate,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	global := flag.NewFlagSet("reviewctl", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	global.Usage = func() { fmt.Fprint(stderr, usage) }
+	server := global.String("server", envOr("REVIEWCTL_SERVER", "http://localhost:8080"), "service base URL")
+	output := global.String("output", "table", "output format: table or json")
+	timeout := global.Duration("timeout", 30*time.Second, "request timeout")
+	if err := global.Parse(args); err != nil {
+		return 2
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(stderr, "unknown output format %q\n", *output)
+		return 2
+	}
+
+	cmd, cmdArgs, ok := resolve(global.Args())
+	if !ok {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	a := &app{
+		client: client.New(*server),
+		out:    &printer{w: stdout, json: *output == "json"},
+		stderr: stderr,
+	}
+	if err := cmd(ctx, a, cmdArgs); err != nil {
+		var apiErr *client.Error
+		if errors.As(err, &apiErr) {
+			fmt.Fprintf(stderr, "error: %s: %s\n", apiErr.Code, apiErr.Message)
+		} else {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+		}
+		return 1
+	}
+	return 0
+}
+
+// Функция находит команду по первым аргументам
+func resolve(args []string) (command, []string, bool) {
+	if len(args) == 0 {
+		return nil, nil, false
+	}
+	if cmd, ok := topLevel[args[0]]; ok {
+		return cmd, args[1:], true
+	}
+	group, ok := commands[args[0]]
+	if !ok || len(args) < 2 {
+		return nil, nil, false
+	}
+	cmd, ok := group[args[1]]
+	return cmd, args[2:], ok
+}
+
+// Функция разбирает флаги подкоманды и проверяет число позиционных аргументов
+func parseArgs(fs *flag.FlagSet, args []string, positional int) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if positional >= 0 && fs.NArg() != positional {
+		return nil, fmt.Errorf("%s: expected %d argument(s), got %d", fs.Name(), positional, fs.NArg())
+	}
+	return fs.Args(), nil
+}
+
+func envOr(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
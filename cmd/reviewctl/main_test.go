@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Фейковый API: отвечает на /pullRequest/get и /team/add, запоминает последний запрос
+type fakeAPI struct {
+	*httptest.Server
+	lastPath string
+	lastBody []byte
+}
+
+func newFakeAPI(t *testing.T) *fakeAPI {
+	t.Helper()
+	api := &fakeAPI{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /pullRequest/get", func(w http.ResponseWriter, r *http.Request) {
+		api.lastPath = r.URL.RequestURI()
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("pull_request_id") != "p1" {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"error": {"code": "NOT_FOUND", "message": "PR not found"}}`)
+			return
+		}
+		io.WriteString(w, `{"pr": {"pull_request_id": "p1", "pull_request_name": "Fix", "author_id": "a",
+			"team_name": "backend", "status": "OPEN", "assigned_reviewers": ["r1", "r2"]}}`)
+	})
+	mux.HandleFunc("POST /team/add", func(w http.ResponseWriter, r *http.Request) {
+		api.lastPath = r.URL.RequestURI()
+		api.lastBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"team": `+string(api.lastBody)+`}`)
+	})
+	api.Server = httptest.NewServer(mux)
+	t.Cleanup(api.Close)
+	return api
+}
+
+// Функция запускает утилиту и возвращает код выхода, stdout и stderr
+func runCLI(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestPRShowOutputFormats(t *testing.T) {
+	api := newFakeAPI(t)
+
+	code, stdout, stderr := runCLI("--server", api.URL, "pr", "show", "p1")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if api.lastPath != "/pullRequest/get?pull_request_id=p1" {
+		t.Fatalf("requested %s", api.lastPath)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("table %q, want header and one row", stdout)
+	}
+	wantRows := [][]string{
+		{"PR_ID", "NAME", "AUTHOR", "TEAM", "STATUS", "REVIEWERS", "LABELS"},
+		{"p1", "Fix", "a", "backend", "OPEN", "r1,r2", "-"},
+	}
+	for i, line := range lines {
+		if got := strings.Fields(line); strings.Join(got, " ") != strings.Join(wantRows[i], " ") {
+			t.Fatalf("table line %d %q, want %v", i, line, wantRows[i])
+		}
+	}
+
+	code, stdout, stderr = runCLI("--server", api.URL, "--output", "json", "pr", "show", "p1")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	var pr struct {
+		PullRequestID     string   `json:"pull_request_id"`
+		AssignedReviewers []string `json:"assigned_reviewers"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &pr); err != nil {
+		t.Fatalf("json output %q: %v", stdout, err)
+	}
+	if pr.PullRequestID != "p1" || strings.Join(pr.AssignedReviewers, ",") != "r1,r2" {
+		t.Fatalf("json output %+v", pr)
+	}
+}
+
+func TestTeamAddFromYAML(t *testing.T) {
+	api := newFakeAPI(t)
+	file := filepath.Join(t.TempDir(), "team.yaml")
+	yamlTeam := "team_name: backend\nmembers:\n  - user_id: u1\n    username: Alice\n  - user_id: u2\n    username: Bob\n    is_active: false\n"
+	if err := os.WriteFile(file, []byte(yamlTeam), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := runCLI("--server", api.URL, "team", "add", "-f", file)
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	// is_active по умолчанию true
+	var sent struct {
+		TeamName string `json:"team_name"`
+		Members  []struct {
+			UserID   string `json:"user_id"`
+			IsActive bool   `json:"is_active"`
+		} `json:"members"`
+	}
+	if err := json.Unmarshal(api.lastBody, &sent); err != nil {
+		t.Fatal(err)
+	}
+	if sent.TeamName != "backend" || len(sent.Members) != 2 || !sent.Members[0].IsActive || sent.Members[1].IsActive {
+		t.Fatalf("sent %s", api.lastBody)
+	}
+	if !strings.Contains(stdout, "u2") || !strings.Contains(stdout, "false") {
+		t.Fatalf("table %q", stdout)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	api := newFakeAPI(t)
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStderr string
+	}{
+		{name: "no command", args: []string{}, wantCode: 2, wantStderr: "Usage: reviewctl"},
+		{name: "unknown command", args: []string{"pr", "close", "p1"}, wantCode: 2, wantStderr: "Usage: reviewctl"},
+		{name: "unknown output", args: []string{"--output", "yaml", "pr", "show", "p1"}, wantCode: 2, wantStderr: `unknown output format "yaml"`},
+		{name: "missing argument", args: []string{"pr", "show"}, wantCode: 1, wantStderr: "pr show: expected 1 argument(s), got 0"},
+		{name: "required flag", args: []string{"team", "add"}, wantCode: 1, wantStderr: "team add: -f is required"},
+		{name: "missing file", args: []string{"team", "add", "-f", missing}, wantCode: 1, wantStderr: "missing.yaml"},
+		{name: "API error", args: []string{"pr", "show", "p2"}, wantCode: 1, wantStderr: "error: NOT_FOUND: PR not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, stderr := runCLI(append([]string{"--server", api.URL}, tt.args...)...)
+			if code != tt.wantCode || !strings.Contains(stderr, tt.wantStderr) {
+				t.Fatalf("exit %d, stderr %q, want %d and %q", code, stderr, tt.wantCode, tt.wantStderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"Backend-trainee-assignment/client"
+	"Backend-trainee-assignment/models"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+)
+
+func prCreate(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("pr create", flag.ContinueOnError)
+	id := fs.String("id", "", "pull request ID")
+	name := fs.String("name", "", "pull request name")
+	author := fs.String("author", "", "author user ID")
+	team := fs.String("team", "", "target team (optional)")
+	repo := fs.String("repo", "", "repository ID (optional)")
+	labels := fs.String("labels", "", "comma-separated labels")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if *id == "" || *name == "" || *author == "" {
+		return errors.New("pr create: --id, --name and --author are required")
+	}
+
+	result, err := a.client.CreatePR(ctx, client.CreatePRRequest{
+		PullRequestID:   *id,
+		PullRequestName: *name,
+		AuthorID:        *author,
+		TeamName:        *team,
+		RepositoryID:    *repo,
+		Labels:          splitList(*labels),
+	})
+	if err != nil {
+		return err
+	}
+	if result.Warning != "" {
+		fmt.Fprintln(a.stderr, "warning:", result.Warning)
+	}
+	return printPR(a, &result.PR, result)
+}
+
+func prShow(ctx context.Context, a *app, args []string) error {
+	rest, err := parseArgs(flag.NewFlagSet("pr show", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	pr, err := a.client.GetPR(ctx, rest[0])
+	if err != nil {
+		return err
+	}
+	return printPR(a, pr, pr)
+}
+
+func prMerge(ctx context.Context, a *app, args []string) error {
+	rest, err := parseArgs(flag.NewFlagSet("pr merge", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	pr, err := a.client.MergePR(ctx, rest[0])
+	if err != nil {
+		return err
+	}
+	return printPR(a, pr, pr)
+}
+
+func prReassign(ctx context.Context, a *app, args []string) error {
+	rest, err := parseArgs(flag.NewFlagSet("pr reassign", flag.ContinueOnError), args, 2)
+	if err != nil {
+		return err
+	}
+	pr, replacedBy, err := a.client.ReassignReviewer(ctx, rest[0], rest[1])
+	if err != nil {
+		return err
+	}
+	if !a.out.json {
+		fmt.Fprintf(a.out.w, "%s replaced by %s\n\n", rest[1], replacedBy)
+	}
+	return printPR(a, pr, map[string]interface{}{"pr": pr, "replaced_by": replacedBy})
+}
+
+// Функция печатает PR таблицей, в режиме JSON печатается value
+func printPR(a *app, pr *models.PullRequest, value interface{}) error {
+	team := pr.TeamName
+	if team == "" {
+		team = "-"
+	}
+	return a.out.print(value,
+		[]string{"PR_ID", "NAME", "AUTHOR", "TEAM", "STATUS", "REVIEWERS", "LABELS"},
+		[][]string{{pr.PullRequestID, pr.PullRequestName, pr.AuthorID, team, pr.Status, joinOrDash(pr.AssignedReviewers), joinOrDash(pr.Labels)}})
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+func stats(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	from := fs.String("from", "", "window start, YYYY-MM-DD or RFC 3339")
+	to := fs.String("to", "", "window end (exclusive), YYYY-MM-DD or RFC 3339")
+	team := fs.String("team", "", "team name")
+	groupBy := fs.String("group-by", "", "group by day, week or month")
+	top := fs.Int("top", 0, "number of most loaded reviewers")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	q := models.StatsQuery{TeamName: *team, GroupBy: *groupBy, Top: *top}
+	var err error
+	if q.From, err = parseTimeFlag("from", *from); err != nil {
+		return err
+	}
+	if q.To, err = parseTimeFlag("to", *to); err != nil {
+		return err
+	}
+	s, err := a.client.Stats(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{
+		{"assignments", strconv.Itoa(s.Totals.Assignments)},
+		{"created_prs", strconv.Itoa(s.Totals.CreatedPRs)},
+		{"merged_prs", strconv.Itoa(s.Totals.MergedPRs)},
+		{"open_prs", strconv.Itoa(s.Totals.OpenPRs)},
+		{"time_to_merge_p50", formatSeconds(s.Latency.TimeToMerge.P50)},
+		{"time_to_merge_p90", formatSeconds(s.Latency.TimeToMerge.P90)},
+		{"assignment_to_merge_p50", formatSeconds(s.Latency.AssignmentToMerge.P50)},
+		{"assignment_to_merge_p90", formatSeconds(s.Latency.AssignmentToMerge.P90)},
+	}
+	for _, t := range s.Teams {
+		name := t.TeamName
+		if name == "" {
+			name = "-"
+		}
+		rows = append(rows, []string{"team:" + name, fmt.Sprintf("%d assignments, %d created, %d merged", t.Assignments, t.CreatedPRs, t.MergedPRs)})
+	}
+	for _, p := range s.Periods {
+		rows = append(rows, []string{"period:" + p.PeriodStart.Format(time.DateOnly), fmt.Sprintf("%d assignments, %d created, %d merged", p.Assignments, p.CreatedPRs, p.MergedPRs)})
+	}
+	for _, r := range s.TopReviewers {
+		rows = append(rows, []string{"user:" + r.UserID, fmt.Sprintf("%d assignments, %d open", r.Assignments, r.OpenAssignments)})
+	}
+	return a.out.print(s, []string{"METRIC", "VALUE"}, rows)
+}
+
+// Функция разбирает дату флага; пустое значение означает отсутствие границы
+func parseTimeFlag(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("--%s must be YYYY-MM-DD or RFC 3339", name)
+	}
+	return &t, nil
+}
+
+func formatSeconds(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second).String()
+}
+
+func massDeactivate(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("mass-deactivate", flag.ContinueOnError)
+	team := fs.String("team", "", "team name")
+	dryRun := fs.Bool("dry-run", false, "show report without applying it")
+	userIDs, err := parseArgs(fs, args, -1)
+	if err != nil {
+		return err
+	}
+	if *team == "" || len(userIDs) == 0 {
+		return errors.New("mass-deactivate: --team and at least one user ID are required")
+	}
+	result, err := a.client.MassDeactivate(ctx, *team, userIDs, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	var rows [][]string
+	for _, userID := range result.DeactivatedUserIDs {
+		rows = append(rows, []string{"deactivate", userID, "-"})
+	}
+	for _, skipped := range result.SkippedUsers {
+		rows = append(rows, []string{"skip", skipped.UserID, skipped.Reason})
+	}
+	for _, r := range result.Reassignments {
+		details := r.OldReviewerID + " -> " + r.NewReviewerID
+		if r.NewReviewerID == "" {
+			details = r.OldReviewerID + " -> (unstaffed: " + r.Reason + ")"
+		}
+		rows = append(rows, []string{"reassign review", r.PullRequestID, details})
+	}
+	status := "applied in " + strconv.FormatInt(result.ProcessingTimeMs, 10) + "ms"
+	if result.DryRun {
+		status = "dry run, nothing changed"
+	}
+	rows = append(rows, []string{"result", "-", status})
+	return a.out.print(result, []string{"ACTION", "SUBJECT", "DETAILS"}, rows)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/goccy/go-yaml"
+)
+
+// Описание команды в YAML, is_active по умолчанию true
+type teamFile struct {
+	TeamName string `yaml:"team_name"`
+	Members  []struct {
+		UserID   string `yaml:"user_id"`
+		Username string `yaml:"username"`
+		IsActive *bool  `yaml:"is_active"`
+	} `yaml:"members"`
+}
+
+// Функция читает команду из YAML-файла
+func loadTeamFile(path string) (models.Team, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return models.Team{}, err
+	}
+	var file teamFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return models.Team{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if file.TeamName == "" {
+		return models.Team{}, fmt.Errorf("%s: team_name is required", path)
+	}
+
+	team := models.Team{TeamName: file.TeamName, Members: []models.TeamMember{}}
+	for i, m := range file.Members {
+		if m.UserID == "" || m.Username == "" {
+			return models.Team{}, fmt.Errorf("%s: member %d: user_id and username are required", path, i+1)
+		}
+		isActive := true
+		if m.IsActive != nil {
+			isActive = *m.IsActive
+		}
+		team.Members = append(team.Members, models.TeamMember{
+			UserID:   m.UserID,
+			Username: m.Username,
+			IsActive: isActive,
+		})
+	}
+	return team, nil
+}
+
+func teamAdd(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("team add", flag.ContinueOnError)
+	file := fs.String("f", "", "YAML file with team definition")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("team add: -f is required")
+	}
+	team, err := loadTeamFile(*file)
+	if err != nil {
+		return err
+	}
+	created, err := a.client.AddTeam(ctx, team)
+	if err != nil {
+		return err
+	}
+	return printTeam(a, created)
+}
+
+func teamGet(ctx context.Context, a *app, args []string) error {
+	rest, err := parseArgs(flag.NewFlagSet("team get", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	team, err := a.client.GetTeam(ctx, rest[0])
+	if err != nil {
+		return err
+	}
+	return printTeam(a, team)
+}
+
+func printTeam(a *app, team *models.Team) error {
+	rows := make([][]string, 0, len(team.Members))
+	for _, m := range team.Members {
+		rows = append(rows, []string{team.TeamName, m.UserID, m.Username, strconv.FormatBool(m.IsActive)})
+	}
+	return a.out.print(team, []string{"TEAM", "USER_ID", "USERNAME", "ACTIVE"}, rows)
+}
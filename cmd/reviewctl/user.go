@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"strconv"
+)
+
+func userActivate(ctx context.Context, a *app, args []string) error {
+	return setActive(ctx, a, "user activate", args, true)
+}
+
+func userDeactivate(ctx context.Context, a *app, args []string) error {
+	return setActive(ctx, a, "user deactivate", args, false)
+}
+
+func setActive(ctx context.Context, a *app, name string, args []string, isActive bool) error {
+	rest, err := parseArgs(flag.NewFlagSet(name, flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	user, err := a.client.SetIsActive(ctx, rest[0], isActive)
+	if err != nil {
+		return err
+	}
+	return a.out.print(user,
+		[]string{"USER_ID", "USERNAME", "TEAMS", "ACTIVE"},
+		[][]string{{user.UserID, user.Username, joinOrDash(user.Teams), strconv.FormatBool(user.IsActive)}})
+}
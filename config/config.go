@@ -2,16 +2,63 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
 	Port        string
+	GRPCPort    string
 	DBHost      string
 	DBPort      string
 	DBName      string
 	DBUser      string
 	DBPassword  string
 	DatabaseURL string
+
+	ReconcileInterval   time.Duration
+	ReconcileBatchSize  int
+	MinMatchedReviewers int
+
+	LogLevel  string
+	LogFormat string
+
+	RequestTimeout  time.Duration
+	ExportTimeout   time.Duration
+	ShutdownTimeout time.Duration
+	ReadinessDelay  time.Duration
+
+	ValidateRequests  bool
+	ValidateResponses bool
+
+	IdempotencyTTL time.Duration
+
+	EventHistorySize int
+	EventHeartbeat   time.Duration
+
+	SLADefaultThresholdHours int
+	SLAAutoReassign          bool
+	SLABusinessDays          bool
+	SLATimezone              string
+	SLACheckInterval         time.Duration
+
+	ReminderInterval time.Duration
+
+	WebhookTimeout     time.Duration
+	WebhookQueueSize   int
+	WebhookWorkers     int
+	WebhookMaxAttempts int
+	WebhookBackoff     time.Duration
+
+	SMTPAddr          string
+	SMTPFrom          string
+	SMTPUsername      string
+	SMTPPassword      string
+	EmailBatchWindow  time.Duration
+	EmailMaxAttempts  int
+	EmailRetryBackoff time.Duration
+
+	ScimToken string
 }
 
 func Load() *Config {
@@ -21,12 +68,57 @@ func Load() *Config {
 	}
 	return &Config{
 		Port:        getEnv("APP_PORT", "8080"),
+		GRPCPort:    getEnv("GRPC_PORT", "9090"),
 		DBHost:      getEnv("DB_HOST", "localhost"),
 		DBPort:      getEnv("DB_PORT", "5432"),
 		DBName:      getEnv("DB_NAME", "review_service"),
 		DBUser:      getEnv("DB_USER", "postgres"),
 		DBPassword:  getEnv("DB_PASSWORD", "password"),
 		DatabaseURL: databaseURL,
+
+		ReconcileInterval:   getEnvDuration("RECONCILE_INTERVAL", time.Minute),
+		ReconcileBatchSize:  getEnvInt("RECONCILE_BATCH_SIZE", 100),
+		MinMatchedReviewers: getEnvInt("LABEL_MIN_MATCHED_REVIEWERS", 1),
+
+		LogLevel:  getEnv("LOG_LEVEL", "info"),
+		LogFormat: getEnv("LOG_FORMAT", "json"),
+
+		RequestTimeout:  getEnvDuration("REQUEST_TIMEOUT", 10*time.Second),
+		ExportTimeout:   getEnvDuration("EXPORT_TIMEOUT", 10*time.Minute),
+		ShutdownTimeout: getEnvDuration("SHUTDOWN_TIMEOUT", 15*time.Second),
+		ReadinessDelay:  getEnvDuration("SHUTDOWN_READINESS_DELAY", 5*time.Second),
+
+		ValidateRequests:  getEnvBool("OPENAPI_VALIDATE_REQUESTS", false),
+		ValidateResponses: getEnvBool("OPENAPI_VALIDATE_RESPONSES", false),
+
+		IdempotencyTTL: getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+
+		EventHistorySize: getEnvInt("EVENTS_HISTORY_SIZE", 1000),
+		EventHeartbeat:   getEnvDuration("EVENTS_HEARTBEAT", 15*time.Second),
+
+		SLADefaultThresholdHours: getEnvInt("SLA_DEFAULT_THRESHOLD_HOURS", 24),
+		SLAAutoReassign:          getEnvBool("SLA_AUTO_REASSIGN", false),
+		SLABusinessDays:          getEnvBool("SLA_BUSINESS_DAYS", true),
+		SLATimezone:              getEnv("SLA_TIMEZONE", "UTC"),
+		SLACheckInterval:         getEnvDuration("SLA_CHECK_INTERVAL", 5*time.Minute),
+
+		ReminderInterval: getEnvDuration("REMINDER_CHECK_INTERVAL", time.Minute),
+
+		WebhookTimeout:     getEnvDuration("WEBHOOK_TIMEOUT", 5*time.Second),
+		WebhookQueueSize:   getEnvInt("WEBHOOK_QUEUE_SIZE", 1000),
+		WebhookWorkers:     getEnvInt("WEBHOOK_WORKERS", 4),
+		WebhookMaxAttempts: getEnvInt("WEBHOOK_MAX_ATTEMPTS", 5),
+		WebhookBackoff:     getEnvDuration("WEBHOOK_BACKOFF", time.Second),
+
+		SMTPAddr:          getEnv("SMTP_ADDR", ""),
+		SMTPFrom:          getEnv("SMTP_FROM", "review-service@localhost"),
+		SMTPUsername:      getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:      getEnv("SMTP_PASSWORD", ""),
+		EmailBatchWindow:  getEnvDuration("EMAIL_BATCH_WINDOW", time.Minute),
+		EmailMaxAttempts:  getEnvInt("EMAIL_MAX_ATTEMPTS", 3),
+		EmailRetryBackoff: getEnvDuration("EMAIL_RETRY_BACKOFF", 5*time.Second),
+
+		ScimToken: getEnv("SCIM_TOKEN", ""),
 	}
 }
 
@@ -36,3 +128,30 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
@@ -1,15 +1,19 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"Backend-trainee-assignment/config"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
+// Код ошибки PostgreSQL при нарушении уникальности
+const uniqueViolation = "23505"
+
 func NewDB(cfg *config.Config) (*sqlx.DB, error) {
 	var connStr string
 	if cfg.DatabaseURL != "" {
@@ -28,6 +32,15 @@ func NewDB(cfg *config.Config) (*sqlx.DB, error) {
 		return nil, fmt.Errorf("ошибка при подключении: %w", err)
 	}
 
-	log.Println("Подключение к базе данных успешно")
+	slog.Info("database connection established")
 	return db, nil
 }
+
+// Функция проверяет, что err - нарушение уникальности, и возвращает имя нарушенного ограничения
+func UniqueViolation(err error) (string, bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return pqErr.Constraint, true
+	}
+	return "", false
+}
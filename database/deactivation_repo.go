@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type DeactivationRepository struct {
+	db *sqlx.DB
+}
+
+func NewDeactivationRepository(db *sqlx.DB) *DeactivationRepository {
+	return &DeactivationRepository{db: db}
+}
+
+// Транзакция массовой деактивации: состояние читается под блокировками и план
+// применяется в той же транзакции, поэтому параллельные мержи и переназначения его не ломают
+type DeactivationTx struct {
+	tx *sqlx.Tx
+}
+
+// Функция открывает транзакцию и читает под блокировкой пользователей userIDs,
+// открытые PR, где они ревьюеры, и активных кандидатов пулов этих PR с их нагрузкой
+func (r *DeactivationRepository) Begin(ctx context.Context, userIDs []string) (*DeactivationTx, *models.DeactivationState, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	dtx := &DeactivationTx{tx: tx}
+	state, err := dtx.load(ctx, userIDs)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	return dtx, state, nil
+}
+
+func (t *DeactivationTx) load(ctx context.Context, userIDs []string) (*models.DeactivationState, error) {
+	state := &models.DeactivationState{
+		TeamPools:       make(map[string][]models.ReviewerCandidate),
+		RepositoryPools: make(map[string][]models.ReviewerCandidate),
+	}
+
+	usersQuery := `
+		SELECT u.user_id, u.username, u.is_active,
+			COALESCE((SELECT array_agg(team_name ORDER BY team_name) FROM team_memberships
+				WHERE user_id = u.user_id), '{}')
+		FROM users u
+		WHERE u.user_id = ANY($1)
+		ORDER BY u.user_id
+		FOR UPDATE
+	`
+	rows, err := t.tx.QueryContext(ctx, usersQuery, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.UserID, &user.Username, &user.IsActive, (*pq.StringArray)(&user.Teams)); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		state.Users = append(state.Users, user)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	prsQuery := `
+		SELECT pr.pull_request_id, pr.author_id, COALESCE(pr.team_name, ''), COALESCE(pr.repository_id, ''),
+			COALESCE((SELECT array_agg(reviewer_user_id ORDER BY assigned_at, reviewer_user_id) FROM pr_reviewers
+				WHERE pr_id = pr.pull_request_id), '{}')
+		FROM pull_requests pr
+		WHERE pr.status = 'OPEN'
+			AND EXISTS (SELECT 1 FROM pr_reviewers r
+				WHERE r.pr_id = pr.pull_request_id AND r.reviewer_user_id = ANY($1))
+		ORDER BY pr.created_at, pr.pull_request_id
+		FOR UPDATE
+	`
+	rows, err = t.tx.QueryContext(ctx, prsQuery, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	var teams, repositories []string
+	for rows.Next() {
+		pr := models.PullRequest{Status: "OPEN"}
+		if err := rows.Scan(&pr.PullRequestID, &pr.AuthorID, &pr.TeamName, &pr.RepositoryID,
+			(*pq.StringArray)(&pr.AssignedReviewers)); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		state.PRs = append(state.PRs, pr)
+		if pr.RepositoryID != "" {
+			repositories = append(repositories, pr.RepositoryID)
+		} else if pr.TeamName != "" {
+			teams = append(teams, pr.TeamName)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(teams) == 0 && len(repositories) == 0 {
+		return state, nil
+	}
+
+	// Пулы как в подборе ревьюеров: пул репозитория, если он задан, иначе целевая команда
+	poolsQuery := `
+		WITH load AS (
+			SELECT r.reviewer_user_id AS user_id, count(*) AS open_reviews
+			FROM pr_reviewers r
+			INNER JOIN pull_requests pr ON pr.pull_request_id = r.pr_id
+			WHERE pr.status = 'OPEN'
+			GROUP BY r.reviewer_user_id
+		), pool AS (
+			SELECT 'team' AS kind, tm.team_name AS pool_id, tm.user_id
+			FROM team_memberships tm
+			WHERE tm.team_name = ANY($1)
+			UNION
+			SELECT 'repository', rt.repository_id, tm.user_id
+			FROM repository_teams rt
+			INNER JOIN team_memberships tm ON tm.team_name = rt.team_name
+			WHERE rt.repository_id = ANY($2)
+			UNION
+			SELECT 'repository', rr.repository_id, rr.user_id
+			FROM repository_reviewers rr
+			WHERE rr.repository_id = ANY($2) AND rr.kind = 'EXTRA'
+		)
+		SELECT p.kind, p.pool_id, u.user_id, COALESCE(l.open_reviews, 0)
+		FROM pool p
+		INNER JOIN users u ON u.user_id = p.user_id
+		LEFT JOIN load l ON l.user_id = u.user_id
+		WHERE u.is_active
+			AND NOT (p.kind = 'repository' AND EXISTS (
+				SELECT 1 FROM repository_reviewers x
+				WHERE x.repository_id = p.pool_id AND x.user_id = p.user_id AND x.kind = 'EXCLUDED'
+			))
+		ORDER BY p.kind, p.pool_id, u.user_id
+	`
+	rows, err = t.tx.QueryContext(ctx, poolsQuery, pq.Array(teams), pq.Array(repositories))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var kind, poolID string
+		var candidate models.ReviewerCandidate
+		if err := rows.Scan(&kind, &poolID, &candidate.UserID, &candidate.OpenReviews); err != nil {
+			return nil, err
+		}
+		if kind == "team" {
+			state.TeamPools[poolID] = append(state.TeamPools[poolID], candidate)
+		} else {
+			state.RepositoryPools[poolID] = append(state.RepositoryPools[poolID], candidate)
+		}
+	}
+	return state, rows.Err()
+}
+
+// Функция меняет имя пользователя в транзакции деактивации
+func (t *DeactivationTx) UpdateUsername(ctx context.Context, userID, username string) error {
+	_, err := t.tx.ExecContext(ctx, `UPDATE users SET username = $1 WHERE user_id = $2`, username, userID)
+	return err
+}
+
+// Функция применяет план и фиксирует транзакцию: деактивирует пользователей,
+// снимает их с ревью и назначает замены, каждое действие - одним запросом
+func (t *DeactivationTx) Apply(ctx context.Context, userIDs []string, reassignments []models.DeactivationReassignment) error {
+	if _, err := t.tx.ExecContext(ctx, `
+		UPDATE users
+		SET is_active = false
+		WHERE user_id = ANY($1) AND is_active
+	`, pq.Array(userIDs)); err != nil {
+		return err
+	}
+
+	var prIDs, oldReviewers, newPRIDs, newReviewers []string
+	for _, reassignment := range reassignments {
+		prIDs = append(prIDs, reassignment.PullRequestID)
+		oldReviewers = append(oldReviewers, reassignment.OldReviewerID)
+		if reassignment.NewReviewerID != "" {
+			newPRIDs = append(newPRIDs, reassignment.PullRequestID)
+			newReviewers = append(newReviewers, reassignment.NewReviewerID)
+		}
+	}
+	if _, err := t.tx.ExecContext(ctx, `
+		DELETE FROM pr_reviewers r
+		USING unnest($1::varchar[], $2::varchar[]) AS d(pr_id, user_id)
+		WHERE r.pr_id = d.pr_id AND r.reviewer_user_id = d.user_id
+	`, pq.Array(prIDs), pq.Array(oldReviewers)); err != nil {
+		return err
+	}
+	if _, err := t.tx.ExecContext(ctx, `
+		INSERT INTO pr_reviewers (pr_id, reviewer_user_id, assigned_at)
+		SELECT d.pr_id, d.user_id, $3
+		FROM unnest($1::varchar[], $2::varchar[]) AS d(pr_id, user_id)
+		ON CONFLICT (pr_id, reviewer_user_id) DO NOTHING
+	`, pq.Array(newPRIDs), pq.Array(newReviewers), time.Now()); err != nil {
+		return err
+	}
+	return t.tx.Commit()
+}
+
+// Функция отменяет транзакцию, после Apply ничего не делает
+func (t *DeactivationTx) Rollback() error {
+	return t.tx.Rollback()
+}
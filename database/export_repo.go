@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Сколько строк выгрузки читается из курсора за раз
+const exportFetchSize = 500
+
+// Выгрузки читаются серверным курсором порциями по exportFetchSize строк,
+// так что объем памяти не зависит от размера выгрузки
+type ExportRepository struct {
+	db *sqlx.DB
+}
+
+func NewExportRepository(db *sqlx.DB) *ExportRepository {
+	return &ExportRepository{db: db}
+}
+
+// Функция выгружает PR с ревьюерами; фильтры: команда, автор (UserID), статус, время создания
+func (r *ExportRepository) StreamPullRequests(ctx context.Context, f models.ExportFilter, fn func(models.PRExport) error) error {
+	query := `
+		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id,
+			COALESCE(pr.team_name, ''), COALESCE(pr.repository_id, ''), pr.status,
+			pr.created_at, pr.merged_at,
+			COALESCE((SELECT array_agg(r.reviewer_user_id ORDER BY r.assigned_at, r.reviewer_user_id)
+				FROM pr_reviewers r WHERE r.pr_id = pr.pull_request_id), '{}')
+		FROM pull_requests pr
+		WHERE ($1 = '' OR pr.team_name = $1)
+			AND ($2 = '' OR pr.author_id = $2)
+			AND ($3 = '' OR pr.status = $3)
+			AND ($4::timestamp IS NULL OR pr.created_at >= $4)
+			AND ($5::timestamp IS NULL OR pr.created_at < $5)
+		ORDER BY pr.created_at, pr.pull_request_id
+	`
+	return r.stream(ctx, query, []interface{}{f.TeamName, f.UserID, f.Status, f.From, f.To}, func(rows *sql.Rows) error {
+		var pr models.PRExport
+		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID,
+			&pr.TeamName, &pr.RepositoryID, &pr.Status, &pr.CreatedAt, &pr.MergedAt,
+			(*pq.StringArray)(&pr.Reviewers)); err != nil {
+			return err
+		}
+		return fn(pr)
+	})
+}
+
+// Функция выгружает историю назначений; фильтры: команда PR, ревьюер, статус PR, время назначения
+func (r *ExportRepository) StreamAssignments(ctx context.Context, f models.ExportFilter, fn func(models.AssignmentExport) error) error {
+	query := `
+		SELECT r.pr_id, pr.pull_request_name, COALESCE(pr.team_name, ''), r.reviewer_user_id,
+			r.assigned_at, r.first_decision_at, COALESCE(r.decision, ''), pr.status, pr.merged_at
+		FROM pr_reviewers r
+		JOIN pull_requests pr ON pr.pull_request_id = r.pr_id
+		WHERE ($1 = '' OR pr.team_name = $1)
+			AND ($2 = '' OR r.reviewer_user_id = $2)
+			AND ($3 = '' OR pr.status = $3)
+			AND ($4::timestamp IS NULL OR r.assigned_at >= $4)
+			AND ($5::timestamp IS NULL OR r.assigned_at < $5)
+		ORDER BY r.assigned_at, r.pr_id, r.reviewer_user_id
+	`
+	return r.stream(ctx, query, []interface{}{f.TeamName, f.UserID, f.Status, f.From, f.To}, func(rows *sql.Rows) error {
+		var a models.AssignmentExport
+		if err := rows.Scan(&a.PullRequestID, &a.PullRequestName, &a.TeamName, &a.ReviewerID,
+			&a.AssignedAt, &a.FirstDecisionAt, &a.Decision, &a.Status, &a.MergedAt); err != nil {
+			return err
+		}
+		return fn(a)
+	})
+}
+
+// Функция выгружает статистику пользователей по назначениям за период;
+// фильтры: команда (участники команды), пользователь, время назначения
+func (r *ExportRepository) StreamUserStats(ctx context.Context, f models.ExportFilter, fn func(models.UserStatsExport) error) error {
+	query := `
+		SELECT u.user_id, u.username, COALESCE(u.is_active, FALSE),
+			COALESCE((SELECT array_agg(team_name ORDER BY team_name) FROM team_memberships
+				WHERE user_id = u.user_id), '{}'),
+			COUNT(a.pr_id),
+			COUNT(a.pr_id) FILTER (WHERE a.status = 'OPEN'),
+			COUNT(a.pr_id) FILTER (WHERE a.decision = 'APPROVED'),
+			COUNT(a.pr_id) FILTER (WHERE a.decision = 'CHANGES_REQUESTED'),
+			COUNT(a.pr_id) FILTER (WHERE a.decision = 'COMMENTED'),
+			AVG(EXTRACT(EPOCH FROM a.first_decision_at - a.assigned_at))::float8 / 3600
+		FROM users u
+		LEFT JOIN (
+			SELECT r.pr_id, r.reviewer_user_id, r.assigned_at, r.first_decision_at, r.decision, pr.status
+			FROM pr_reviewers r
+			JOIN pull_requests pr ON pr.pull_request_id = r.pr_id
+			WHERE ($3::timestamp IS NULL OR r.assigned_at >= $3)
+				AND ($4::timestamp IS NULL OR r.assigned_at < $4)
+		) a ON a.reviewer_user_id = u.user_id
+		WHERE ($1 = '' OR EXISTS (SELECT 1 FROM team_memberships tm
+				WHERE tm.user_id = u.user_id AND tm.team_name = $1))
+			AND ($2 = '' OR u.user_id = $2)
+		GROUP BY u.user_id, u.username, u.is_active
+		ORDER BY u.user_id
+	`
+	return r.stream(ctx, query, []interface{}{f.TeamName, f.UserID, f.From, f.To}, func(rows *sql.Rows) error {
+		var s models.UserStatsExport
+		if err := rows.Scan(&s.UserID, &s.Username, &s.IsActive, (*pq.StringArray)(&s.Teams),
+			&s.Assignments, &s.OpenAssignments, &s.Approved, &s.ChangesRequested, &s.Commented,
+			&s.AvgFirstDecisionHours); err != nil {
+			return err
+		}
+		return fn(s)
+	})
+}
+
+// Функция открывает курсор по query в транзакции только для чтения и передает строки в scan порциями
+func (r *ExportRepository) stream(ctx context.Context, query string, args []interface{}, scan func(*sql.Rows) error) error {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DECLARE export_cursor NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return err
+	}
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM export_cursor", exportFetchSize)
+	for {
+		n, err := fetchBatch(ctx, tx, fetch, scan)
+		if err != nil {
+			return err
+		}
+		if n < exportFetchSize {
+			break
+		}
+	}
+	return tx.Commit()
+}
+
+func fetchBatch(ctx context.Context, tx *sqlx.Tx, fetch string, scan func(*sql.Rows) error) (int, error) {
+	rows, err := tx.QueryContext(ctx, fetch)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		n++
+		if err := scan(rows); err != nil {
+			return n, err
+		}
+	}
+	return n, rows.Err()
+}
@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Чтение текущей оргструктуры и применение плана импорта одной транзакцией
+type ImportRepository struct {
+	db *sqlx.DB
+}
+
+func NewImportRepository(db *sqlx.DB) *ImportRepository {
+	return &ImportRepository{db: db}
+}
+
+// Транзакция импорта оргструктуры: пользователи и открытые PR затронутых ревьюеров читаются
+// под блокировкой, план строится по прочитанному состоянию и применяется в той же транзакции
+type ImportTx struct {
+	tx *sqlx.Tx
+}
+
+// Функция открывает транзакцию импорта
+func (r *ImportRepository) Begin(ctx context.Context) (*ImportTx, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ImportTx{tx: tx}, nil
+}
+
+// Функция возвращает все команды
+func (t *ImportTx) GetTeams(ctx context.Context) ([]models.Team, error) {
+	rows, err := t.tx.QueryContext(ctx, `SELECT team_name FROM teams ORDER BY team_name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var teams []models.Team
+	for rows.Next() {
+		var team models.Team
+		if err := rows.Scan(&team.TeamName); err != nil {
+			return nil, err
+		}
+		teams = append(teams, team)
+	}
+	return teams, rows.Err()
+}
+
+// Функция блокирует всех пользователей и возвращает их с командами
+func (t *ImportTx) LockUsersWithTeams(ctx context.Context) ([]models.User, error) {
+	query := `
+		SELECT u.user_id, u.username, COALESCE(u.is_active, FALSE),
+			COALESCE((SELECT array_agg(team_name ORDER BY team_name) FROM team_memberships
+				WHERE user_id = u.user_id), '{}')
+		FROM users u
+		ORDER BY u.user_id
+		FOR UPDATE
+	`
+	rows, err := t.tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.UserID, &user.Username, &user.IsActive, (*pq.StringArray)(&user.Teams)); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// Функция блокирует открытые PR, где ревьюер - один из userIDs, и возвращает их с метками и всеми ревьюерами
+func (t *ImportTx) LockOpenPRsByReviewers(ctx context.Context, userIDs []string) ([]models.PullRequest, error) {
+	query := `
+		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, COALESCE(pr.team_name, ''),
+			COALESCE(pr.repository_id, ''), pr.status,
+			COALESCE((SELECT array_agg(label ORDER BY label) FROM pr_labels
+				WHERE pr_id = pr.pull_request_id), '{}'),
+			COALESCE((SELECT array_agg(reviewer_user_id ORDER BY reviewer_user_id) FROM pr_reviewers
+				WHERE pr_id = pr.pull_request_id), '{}')
+		FROM pull_requests pr
+		WHERE pr.status = 'OPEN'
+			AND EXISTS (SELECT 1 FROM pr_reviewers r
+				WHERE r.pr_id = pr.pull_request_id AND r.reviewer_user_id = ANY($1))
+		ORDER BY pr.pull_request_id
+		FOR UPDATE OF pr
+	`
+	rows, err := t.tx.QueryContext(ctx, query, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prs []models.PullRequest
+	for rows.Next() {
+		var pr models.PullRequest
+		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.TeamName,
+			&pr.RepositoryID, &pr.Status, (*pq.StringArray)(&pr.Labels),
+			(*pq.StringArray)(&pr.AssignedReviewers)); err != nil {
+			return nil, err
+		}
+		prs = append(prs, pr)
+	}
+	return prs, rows.Err()
+}
+
+// Функция возвращает правила пулов ревьюеров репозиториев: команды, дополнительных и исключенных ревьюеров
+func (t *ImportTx) GetRepositoryPools(ctx context.Context, repositoryIDs []string) (map[string]*models.Repository, error) {
+	query := `
+		SELECT r.repository_id,
+			COALESCE((SELECT array_agg(team_name ORDER BY team_name) FROM repository_teams
+				WHERE repository_id = r.repository_id), '{}'),
+			COALESCE((SELECT array_agg(user_id ORDER BY user_id) FROM repository_reviewers
+				WHERE repository_id = r.repository_id AND kind = 'EXTRA'), '{}'),
+			COALESCE((SELECT array_agg(user_id ORDER BY user_id) FROM repository_reviewers
+				WHERE repository_id = r.repository_id AND kind = 'EXCLUDED'), '{}')
+		FROM repositories r
+		WHERE r.repository_id = ANY($1)
+	`
+	rows, err := t.tx.QueryContext(ctx, query, pq.Array(repositoryIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pools := make(map[string]*models.Repository)
+	for rows.Next() {
+		var repo models.Repository
+		if err := rows.Scan(&repo.RepositoryID, (*pq.StringArray)(&repo.Teams),
+			(*pq.StringArray)(&repo.ExtraReviewers), (*pq.StringArray)(&repo.ExcludedReviewers)); err != nil {
+			return nil, err
+		}
+		pools[repo.RepositoryID] = &repo
+	}
+	return pools, rows.Err()
+}
+
+// Функция применяет план импорта и фиксирует транзакцию: при любой ошибке не меняется ничего.
+// Переназначение выполняется, только если PR еще открыт и старый ревьюер на нем; возвращает
+// выполненные переназначения
+func (t *ImportTx) Apply(ctx context.Context, plan *models.ImportPlan) ([]models.Reassignment, error) {
+	tx := t.tx
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO teams (team_name)
+		SELECT unnest($1::varchar[])
+		ON CONFLICT DO NOTHING
+	`, pq.Array(plan.TeamsToCreate)); err != nil {
+		return nil, err
+	}
+
+	var userIDs, usernames []string
+	var active []bool
+	var addUsers, addTeams, removeUsers, removeTeams []string
+	for _, user := range plan.UsersToAdd {
+		userIDs = append(userIDs, user.UserID)
+		usernames = append(usernames, user.Username)
+		active = append(active, user.IsActive)
+		for _, team := range user.Teams {
+			addUsers, addTeams = append(addUsers, user.UserID), append(addTeams, team)
+		}
+	}
+	for _, move := range plan.UsersToMove {
+		for _, team := range move.AddTeams {
+			addUsers, addTeams = append(addUsers, move.UserID), append(addTeams, team)
+		}
+		for _, team := range move.RemoveTeams {
+			removeUsers, removeTeams = append(removeUsers, move.UserID), append(removeTeams, team)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO users (user_id, username, is_active)
+		SELECT * FROM unnest($1::varchar[], $2::varchar[], $3::boolean[])
+		ON CONFLICT (user_id) DO UPDATE SET
+			username = EXCLUDED.username,
+			is_active = EXCLUDED.is_active
+	`, pq.Array(userIDs), pq.Array(usernames), pq.Array(active)); err != nil {
+		return nil, err
+	}
+
+	var renameIDs, renames []string
+	for _, rename := range plan.UsersToRename {
+		renameIDs, renames = append(renameIDs, rename.UserID), append(renames, rename.Username)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE users u
+		SET username = d.username
+		FROM unnest($1::varchar[], $2::varchar[]) AS d(user_id, username)
+		WHERE u.user_id = d.user_id
+	`, pq.Array(renameIDs), pq.Array(renames)); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO team_memberships (user_id, team_name)
+		SELECT * FROM unnest($1::varchar[], $2::varchar[])
+		ON CONFLICT (user_id, team_name) DO NOTHING
+	`, pq.Array(addUsers), pq.Array(addTeams)); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM team_memberships tm
+		USING unnest($1::varchar[], $2::varchar[]) AS d(user_id, team_name)
+		WHERE tm.user_id = d.user_id AND tm.team_name = d.team_name
+	`, pq.Array(removeUsers), pq.Array(removeTeams)); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET is_active = true WHERE user_id = ANY($1)`,
+		pq.Array(plan.UsersToActivate)); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET is_active = false WHERE user_id = ANY($1)`,
+		pq.Array(plan.UsersToDeactivate)); err != nil {
+		return nil, err
+	}
+
+	applied := make([]models.Reassignment, 0, len(plan.Reassignments))
+	for _, reassignment := range plan.Reassignments {
+		result, err := tx.ExecContext(ctx, `
+			DELETE FROM pr_reviewers r
+			USING pull_requests pr
+			WHERE r.pr_id = $1 AND r.reviewer_user_id = $2
+				AND pr.pull_request_id = r.pr_id AND pr.status = 'OPEN'
+		`, reassignment.PullRequestID, reassignment.OldReviewerID)
+		if err != nil {
+			return nil, err
+		}
+		removed, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if removed == 0 {
+			continue
+		}
+		if reassignment.NewReviewerID != "" {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO pr_reviewers (pr_id, reviewer_user_id)
+				VALUES ($1, $2)
+				ON CONFLICT (pr_id, reviewer_user_id) DO NOTHING
+			`, reassignment.PullRequestID, reassignment.NewReviewerID); err != nil {
+				return nil, err
+			}
+		}
+		applied = append(applied, reassignment)
+	}
+	return applied, tx.Commit()
+}
+
+// Функция отменяет транзакцию, после Apply ничего не делает
+func (t *ImportTx) Rollback() error {
+	return t.tx.Rollback()
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Транзакция изменения состава команды: открытые ревью уходящих участников читаются
+// под блокировкой, а состав и переназначения применяются в той же транзакции
+type MembershipTx struct {
+	tx *sqlx.Tx
+}
+
+// Функция открывает транзакцию изменения состава команды
+func (r *TeamRepository) BeginMembershipChange(ctx context.Context) (*MembershipTx, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &MembershipTx{tx: tx}, nil
+}
+
+// Функция блокирует открытые PR команды, где ревьюер - один из userIDs, и возвращает их с метками и ревьюерами
+func (t *MembershipTx) LockTeamReviews(ctx context.Context, teamName string, userIDs []string) ([]models.PullRequest, error) {
+	query := `
+		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, COALESCE(pr.team_name, ''),
+			COALESCE(pr.repository_id, ''), pr.status,
+			COALESCE((SELECT array_agg(label ORDER BY label) FROM pr_labels
+				WHERE pr_id = pr.pull_request_id), '{}'),
+			COALESCE((SELECT array_agg(reviewer_user_id ORDER BY assigned_at, reviewer_user_id) FROM pr_reviewers
+				WHERE pr_id = pr.pull_request_id), '{}')
+		FROM pull_requests pr
+		WHERE pr.status = 'OPEN' AND pr.team_name = $1
+			AND EXISTS (SELECT 1 FROM pr_reviewers r
+				WHERE r.pr_id = pr.pull_request_id AND r.reviewer_user_id = ANY($2))
+		ORDER BY pr.created_at, pr.pull_request_id
+		FOR UPDATE OF pr
+	`
+	rows, err := t.tx.QueryContext(ctx, query, teamName, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prs []models.PullRequest
+	for rows.Next() {
+		var pr models.PullRequest
+		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.TeamName,
+			&pr.RepositoryID, &pr.Status, (*pq.StringArray)(&pr.Labels),
+			(*pq.StringArray)(&pr.AssignedReviewers)); err != nil {
+			return nil, err
+		}
+		prs = append(prs, pr)
+	}
+	return prs, rows.Err()
+}
+
+// Функция возвращает участников команды
+func (t *MembershipTx) GetMembers(ctx context.Context, teamName string) ([]models.User, error) {
+	query := `
+		SELECT u.user_id, u.username, u.is_active
+		FROM users u
+		INNER JOIN team_memberships tm ON tm.user_id = u.user_id
+		WHERE tm.team_name = $1
+		ORDER BY u.username
+	`
+	rows, err := t.tx.QueryContext(ctx, query, teamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.UserID, &user.Username, &user.IsActive); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// Функция создает команду
+func (t *MembershipTx) CreateTeam(ctx context.Context, teamName string) error {
+	_, err := t.tx.ExecContext(ctx, `INSERT INTO teams (team_name) VALUES ($1)`, teamName)
+	return err
+}
+
+// Функция создает отсутствующих пользователей. Имя и активность существующих не меняются:
+// добавление в команду не переименовывает и не включает деактивированных пользователей
+func (t *MembershipTx) CreateMissingUsers(ctx context.Context, users []models.User) error {
+	var userIDs, usernames []string
+	var active []bool
+	for _, user := range users {
+		userIDs = append(userIDs, user.UserID)
+		usernames = append(usernames, user.Username)
+		active = append(active, user.IsActive)
+	}
+	query := `
+		INSERT INTO users (user_id, username, is_active)
+		SELECT * FROM unnest($1::varchar[], $2::varchar[], $3::boolean[])
+		ON CONFLICT (user_id) DO NOTHING
+	`
+	_, err := t.tx.ExecContext(ctx, query, pq.Array(userIDs), pq.Array(usernames), pq.Array(active))
+	return err
+}
+
+// Функция добавляет пользователей в команду
+func (t *MembershipTx) AddMemberships(ctx context.Context, teamName string, userIDs []string) error {
+	query := `
+		INSERT INTO team_memberships (user_id, team_name)
+		SELECT unnest($2::varchar[]), $1
+		ON CONFLICT (user_id, team_name) DO NOTHING
+	`
+	_, err := t.tx.ExecContext(ctx, query, teamName, pq.Array(userIDs))
+	return err
+}
+
+// Функция убирает пользователей из команды
+func (t *MembershipTx) RemoveMemberships(ctx context.Context, teamName string, userIDs []string) error {
+	query := `
+		DELETE FROM team_memberships
+		WHERE team_name = $1 AND user_id = ANY($2)
+	`
+	_, err := t.tx.ExecContext(ctx, query, teamName, pq.Array(userIDs))
+	return err
+}
+
+// Функция переименовывает команду, членство и PR обновляются каскадно
+func (t *MembershipTx) RenameTeam(ctx context.Context, teamName, newTeamName string) error {
+	_, err := t.tx.ExecContext(ctx, `UPDATE teams SET team_name = $1 WHERE team_name = $2`, newTeamName, teamName)
+	return err
+}
+
+// Функция удаляет команду вместе с членством участников
+func (t *MembershipTx) DeleteTeam(ctx context.Context, teamName string) error {
+	_, err := t.tx.ExecContext(ctx, `DELETE FROM teams WHERE team_name = $1`, teamName)
+	return err
+}
+
+// Функция снимает старых ревьюеров и назначает новых, пустой NewReviewerID - без замены
+func (t *MembershipTx) Reassign(ctx context.Context, reassignments []models.Reassignment) error {
+	var prIDs, oldReviewers, newPRIDs, newReviewers []string
+	for _, reassignment := range reassignments {
+		prIDs = append(prIDs, reassignment.PullRequestID)
+		oldReviewers = append(oldReviewers, reassignment.OldReviewerID)
+		if reassignment.NewReviewerID != "" {
+			newPRIDs = append(newPRIDs, reassignment.PullRequestID)
+			newReviewers = append(newReviewers, reassignment.NewReviewerID)
+		}
+	}
+	if _, err := t.tx.ExecContext(ctx, `
+		DELETE FROM pr_reviewers r
+		USING unnest($1::varchar[], $2::varchar[]) AS d(pr_id, user_id)
+		WHERE r.pr_id = d.pr_id AND r.reviewer_user_id = d.user_id
+	`, pq.Array(prIDs), pq.Array(oldReviewers)); err != nil {
+		return err
+	}
+	_, err := t.tx.ExecContext(ctx, `
+		INSERT INTO pr_reviewers (pr_id, reviewer_user_id, assigned_at)
+		SELECT d.pr_id, d.user_id, $3
+		FROM unnest($1::varchar[], $2::varchar[]) AS d(pr_id, user_id)
+		ON CONFLICT (pr_id, reviewer_user_id) DO NOTHING
+	`, pq.Array(newPRIDs), pq.Array(newReviewers), time.Now())
+	return err
+}
+
+// Функция фиксирует транзакцию
+func (t *MembershipTx) Commit() error {
+	return t.tx.Commit()
+}
+
+// Функция отменяет транзакцию, после Commit ничего не делает
+func (t *MembershipTx) Rollback() error {
+	return t.tx.Rollback()
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Версия схемы, которую ожидает код; увеличивается вместе с каждой новой миграцией
+const SchemaVersion = 11
+
+// Ключ advisory-блокировки, под которой реплики по очереди применяют миграции
+const migrationLockKey = 72010011
+
+// Файл миграции с версией из префикса имени
+type migrationFile struct {
+	version int
+	name    string
+}
+
+// Функция возвращает последнюю примененную версию схемы
+func CurrentSchemaVersion(ctx context.Context, db *sqlx.DB) (int, error) {
+	query := `
+		SELECT COALESCE(MAX(version), 0)
+		FROM schema_migrations
+	`
+	var version int
+	err := db.QueryRowContext(ctx, query).Scan(&version)
+	return version, err
+}
+
+// Функция применяет еще не записанные в schema_migrations миграции из fsys по возрастанию версии.
+// Каждая миграция выполняется вместе с записью своей версии в отдельной транзакции под
+// advisory-блокировкой, поэтому одновременно стартующие реплики не применяют ее дважды.
+// Возвращает примененные версии
+func Migrate(ctx context.Context, db *sqlx.DB, fsys fs.FS) ([]int, error) {
+	files, err := migrationFiles(fsys)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureMigrationsTable(ctx, db); err != nil {
+		return nil, fmt.Errorf("ошибка при создании schema_migrations: %w", err)
+	}
+
+	applied := []int{}
+	for _, file := range files {
+		ok, err := applyMigration(ctx, db, fsys, file)
+		if err != nil {
+			return applied, fmt.Errorf("ошибка при применении миграции %s: %w", file.name, err)
+		}
+		if ok {
+			slog.InfoContext(ctx, "migration applied", "version", file.version, "file", file.name)
+			applied = append(applied, file.version)
+		}
+	}
+	return applied, nil
+}
+
+// Функция создает таблицу учета миграций для баз, созданных до миграции 005
+func ensureMigrationsTable(ctx context.Context, db *sqlx.DB) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockKey); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Функция применяет миграцию, если ее версия еще не записана, и сообщает, была ли она применена
+func applyMigration(ctx context.Context, db *sqlx.DB, fsys fs.FS, file migrationFile) (bool, error) {
+	body, err := fs.ReadFile(fsys, file.name)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockKey); err != nil {
+		return false, err
+	}
+	var exists bool
+	if err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, file.version).Scan(&exists); err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+	// Файл выполняется одним запросом без параметров, поэтому может содержать несколько команд
+	if _, err := tx.ExecContext(ctx, string(body)); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO schema_migrations (version)
+		VALUES ($1)
+		ON CONFLICT DO NOTHING
+	`, file.version); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// Функция находит файлы NNN_*.sql и сортирует их по версии, повтор версии - ошибка
+func migrationFiles(fsys fs.FS) ([]migrationFile, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	var files []migrationFile
+	seen := make(map[int]string)
+	for _, name := range names {
+		prefix, _, ok := strings.Cut(path.Base(name), "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("имя миграции %s должно начинаться с номера версии", name)
+		}
+		if other, dup := seen[version]; dup {
+			return nil, fmt.Errorf("миграции %s и %s имеют одну версию %d", other, name, version)
+		}
+		seen[version] = name
+		files = append(files, migrationFile{version: version, name: name})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].version < files[j].version })
+	return files, nil
+}
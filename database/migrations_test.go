@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"Backend-trainee-assignment/migrations"
+	"context"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Функция подключается к тестовой БД из TEST_DATABASE_URL, без нее тест пропускается
+func testDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sqlx.Connect("postgres", url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrationFilesMatchSchemaVersion(t *testing.T) {
+	files, err := migrationFiles(migrations.Files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, file := range files {
+		if file.version != i+1 {
+			t.Fatalf("migration %s: version %d, want %d", file.name, file.version, i+1)
+		}
+	}
+	if last := files[len(files)-1].version; last != SchemaVersion {
+		t.Fatalf("last migration version %d, SchemaVersion %d", last, SchemaVersion)
+	}
+}
+
+// Версии записывает Migrate, только 005 записывает версии баз, созданных до него
+func TestMigrationFilesDoNotRecordVersions(t *testing.T) {
+	files, err := migrationFiles(migrations.Files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if file.version <= 5 {
+			continue
+		}
+		body, err := fs.ReadFile(migrations.Files, file.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(body), "schema_migrations") {
+			t.Fatalf("migration %s writes schema_migrations itself", file.name)
+		}
+	}
+}
+
+func TestMigrationFilesOrderAndErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		want    []int
+		wantErr bool
+	}{
+		{name: "numeric order", files: []string{"010_b.sql", "002_a.sql", "001_init.sql"}, want: []int{1, 2, 10}},
+		{name: "non sql ignored", files: []string{"001_init.sql", "README.md"}, want: []int{1}},
+		{name: "missing version", files: []string{"init.sql"}, wantErr: true},
+		{name: "duplicate version", files: []string{"003_a.sql", "003_b.sql"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			for _, name := range tt.files {
+				fsys[name] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+			}
+			files, err := migrationFiles(fsys)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", files)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != len(tt.want) {
+				t.Fatalf("got %v, want versions %v", files, tt.want)
+			}
+			for i, file := range files {
+				if file.version != tt.want[i] {
+					t.Fatalf("got %v, want versions %v", files, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	if _, err := Migrate(ctx, db, migrations.Files); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	applied, err := Migrate(ctx, db, migrations.Files)
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Fatalf("second run applied %v", applied)
+	}
+	version, err := CurrentSchemaVersion(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != SchemaVersion {
+		t.Fatalf("schema version %d, want %d", version, SchemaVersion)
+	}
+}
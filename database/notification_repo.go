@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type NotificationRepository struct {
+	db *sqlx.DB
+}
+
+func NewNotificationRepository(db *sqlx.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// Функция возвращает настройки уведомлений пользователя, nil если пользователя нет
+func (r *NotificationRepository) GetPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+	query := `
+		SELECT u.user_id, COALESCE(u.email, ''),
+			COALESCE(ARRAY(SELECT kind FROM email_opt_outs o WHERE o.user_id = u.user_id ORDER BY kind), '{}')
+		FROM users u
+		WHERE u.user_id = $1
+	`
+	var prefs models.NotificationPreferences
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&prefs.UserID, &prefs.Email, pq.Array(&prefs.EmailOptOut))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+// Функция заменяет адрес и отказы от писем пользователя
+func (r *NotificationRepository) SetPreferences(ctx context.Context, prefs *models.NotificationPreferences) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET email = NULLIF($2, ''), updated_at = CURRENT_TIMESTAMP WHERE user_id = $1`,
+		prefs.UserID, prefs.Email); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM email_opt_outs WHERE user_id = $1`, prefs.UserID); err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO email_opt_outs (user_id, kind)
+		SELECT $1, k FROM unnest($2::varchar[]) AS k
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, query, prefs.UserID, pq.Array(prefs.EmailOptOut)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Функция возвращает пользователей с адресом, не отказавшихся от писем вида kind
+func (r *NotificationRepository) GetEmailRecipients(ctx context.Context, userIDs []string, kind string) ([]models.EmailRecipient, error) {
+	query := `
+		SELECT u.user_id, u.username, u.email
+		FROM users u
+		WHERE u.user_id = ANY($1)
+			AND u.email IS NOT NULL
+			AND NOT EXISTS (SELECT 1 FROM email_opt_outs o WHERE o.user_id = u.user_id AND o.kind = $2)
+		ORDER BY u.user_id
+	`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(userIDs), kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []models.EmailRecipient
+	for rows.Next() {
+		var recipient models.EmailRecipient
+		if err := rows.Scan(&recipient.UserID, &recipient.Username, &recipient.Email); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, rows.Err()
+}
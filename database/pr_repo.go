@@ -2,10 +2,12 @@ package repository
 
 import (
 	"Backend-trainee-assignment/models"
+	"context"
 	"database/sql"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type PRRepository struct {
@@ -26,25 +28,28 @@ func (r *PRRepository) DB() *sql.DB {
 }
 
 // Функция создает новый Pull Request
-func (r *PRRepository) CreatePR(pr *models.PullRequest) error {
+func (r *PRRepository) CreatePR(ctx context.Context, pr *models.PullRequest) error {
 	query := `
-		INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, created_at, merged_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, team_name, repository_id, status, created_at, merged_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8)
 	`
-	_, err := r.db.Exec(query, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, pr.CreatedAt, pr.MergedAt)
+	_, err := r.db.ExecContext(ctx, query, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.TeamName, pr.RepositoryID, pr.Status, pr.CreatedAt, pr.MergedAt)
 	return err
 }
 
 // Функция возвращает PR по ID
-func (r *PRRepository) GetPRByID(prID string) (*models.PullRequest, error) {
+func (r *PRRepository) GetPRByID(ctx context.Context, prID string) (*models.PullRequest, error) {
 	query := `
-		SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at
+		SELECT pull_request_id, pull_request_name, author_id, COALESCE(team_name, ''), COALESCE(repository_id, ''),
+			COALESCE((SELECT array_agg(label ORDER BY label) FROM pr_labels WHERE pr_id = pull_request_id), '{}'),
+			status, created_at, merged_at
 		FROM pull_requests
 		WHERE pull_request_id = $1
 	`
 	var pr models.PullRequest
-	err := r.db.QueryRow(query, prID).Scan(
-		&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.CreatedAt, &pr.MergedAt,
+	err := r.db.QueryRowContext(ctx, query, prID).Scan(
+		&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.TeamName, &pr.RepositoryID,
+		(*pq.StringArray)(&pr.Labels), &pr.Status, &pr.CreatedAt, &pr.MergedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -55,8 +60,8 @@ func (r *PRRepository) GetPRByID(prID string) (*models.PullRequest, error) {
 }
 
 // Функция проверяет существование PR
-func (r *PRRepository) PRExists(prID string) (bool, error) {
-	pr, err := r.GetPRByID(prID)
+func (r *PRRepository) PRExists(ctx context.Context, prID string) (bool, error) {
+	pr, err := r.GetPRByID(ctx, prID)
 	if err != nil {
 		return false, err
 	}
@@ -64,35 +69,35 @@ func (r *PRRepository) PRExists(prID string) (bool, error) {
 }
 
 // Функция добавляет ревьюера к PR
-func (r *PRRepository) AddPRReviewer(prID, reviewerID string) error {
+func (r *PRRepository) AddPRReviewer(ctx context.Context, prID, reviewerID string) error {
 	query := `
 		INSERT INTO pr_reviewers (pr_id, reviewer_user_id, assigned_at)
 		VALUES ($1, $2, $3)
 		ON CONFLICT (pr_id, reviewer_user_id) DO NOTHING
 	`
-	_, err := r.db.Exec(query, prID, reviewerID, time.Now())
+	_, err := r.db.ExecContext(ctx, query, prID, reviewerID, time.Now())
 	return err
 }
 
 // Функция удаляет ревьюера из PR
-func (r *PRRepository) RemovePRReviewer(prID, reviewerID string) error {
+func (r *PRRepository) RemovePRReviewer(ctx context.Context, prID, reviewerID string) error {
 	query := `
 		DELETE FROM pr_reviewers 
 		WHERE pr_id = $1 AND reviewer_user_id = $2
 	`
-	_, err := r.db.Exec(query, prID, reviewerID)
+	_, err := r.db.ExecContext(ctx, query, prID, reviewerID)
 	return err
 }
 
 // Функция возвращает ревьюеров PR
-func (r *PRRepository) GetPRReviewers(prID string) ([]string, error) {
+func (r *PRRepository) GetPRReviewers(ctx context.Context, prID string) ([]string, error) {
 	query := `
 		SELECT reviewer_user_id
 		FROM pr_reviewers
 		WHERE pr_id = $1
 		ORDER BY assigned_at
 	`
-	rows, err := r.db.Query(query, prID)
+	rows, err := r.db.QueryContext(ctx, query, prID)
 	if err != nil {
 		return nil, err
 	}
@@ -112,8 +117,8 @@ func (r *PRRepository) GetPRReviewers(prID string) ([]string, error) {
 }
 
 // Функция возвращает PR вместе с ревьюерами
-func (r *PRRepository) GetPRWithReviewers(prID string) (*models.PullRequest, []string, error) {
-	pr, err := r.GetPRByID(prID)
+func (r *PRRepository) GetPRWithReviewers(ctx context.Context, prID string) (*models.PullRequest, []string, error) {
+	pr, err := r.GetPRByID(ctx, prID)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -121,7 +126,7 @@ func (r *PRRepository) GetPRWithReviewers(prID string) (*models.PullRequest, []s
 		return nil, nil, nil
 	}
 
-	reviewers, err := r.GetPRReviewers(prID)
+	reviewers, err := r.GetPRReviewers(ctx, prID)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -130,18 +135,18 @@ func (r *PRRepository) GetPRWithReviewers(prID string) (*models.PullRequest, []s
 }
 
 // Функция меняет статус PR
-func (r *PRRepository) UpdatePRStatus(prID, status string, mergedAt *time.Time) error {
+func (r *PRRepository) UpdatePRStatus(ctx context.Context, prID, status string, mergedAt *time.Time) error {
 	query := `
 		UPDATE pull_requests 
 		SET status = $1, merged_at = $2
 		WHERE pull_request_id = $3
 	`
-	_, err := r.db.Exec(query, status, mergedAt, prID)
+	_, err := r.db.ExecContext(ctx, query, status, mergedAt, prID)
 	return err
 }
 
 // Функция возвращает PR назначенные пользователю
-func (r *PRRepository) GetPRsByReviewer(reviewerID string) ([]models.PullRequestShort, error) {
+func (r *PRRepository) GetPRsByReviewer(ctx context.Context, reviewerID string) ([]models.PullRequestShort, error) {
 	query := `
 		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status
 		FROM pull_requests pr
@@ -149,7 +154,7 @@ func (r *PRRepository) GetPRsByReviewer(reviewerID string) ([]models.PullRequest
 		WHERE prv.reviewer_user_id = $1 AND pr.status = 'OPEN'
 		ORDER BY pr.created_at DESC
 	`
-	rows, err := r.db.Query(query, reviewerID)
+	rows, err := r.db.QueryContext(ctx, query, reviewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -168,7 +173,7 @@ func (r *PRRepository) GetPRsByReviewer(reviewerID string) ([]models.PullRequest
 	return prs, nil
 }
 
-func (r *PRRepository) GetOpenPRsByReviewer(reviewerID string) ([]models.PullRequest, error) {
+func (r *PRRepository) GetOpenPRsByReviewer(ctx context.Context, reviewerID string) ([]models.PullRequest, error) {
 	query := `
 		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status
 		FROM pull_requests pr
@@ -176,7 +181,7 @@ func (r *PRRepository) GetOpenPRsByReviewer(reviewerID string) ([]models.PullReq
 		WHERE prv.reviewer_user_id = $1 AND pr.status = 'OPEN'
 	`
 
-	rows, err := r.db.Query(query, reviewerID)
+	rows, err := r.db.QueryContext(ctx, query, reviewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -194,3 +199,222 @@ func (r *PRRepository) GetOpenPRsByReviewer(reviewerID string) ([]models.PullReq
 
 	return prs, nil
 }
+
+// Функция возвращает до limit открытых PR с ревьюерами меньше minReviewers, с ID после afterID
+func (r *PRRepository) GetUnderstaffedOpenPRs(ctx context.Context, minReviewers int, afterID string, limit int) ([]string, error) {
+	query := `
+		SELECT pr.pull_request_id
+		FROM pull_requests pr
+		LEFT JOIN pr_reviewers prv ON pr.pull_request_id = prv.pr_id
+		WHERE pr.status = 'OPEN' AND pr.pull_request_id > $2
+		GROUP BY pr.pull_request_id
+		HAVING COUNT(prv.reviewer_user_id) < $1
+		ORDER BY pr.pull_request_id
+		LIMIT $3
+	`
+	rows, err := r.db.QueryContext(ctx, query, minReviewers, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prIDs []string
+	for rows.Next() {
+		var prID string
+		if err := rows.Scan(&prID); err != nil {
+			return nil, err
+		}
+		prIDs = append(prIDs, prID)
+	}
+
+	return prIDs, rows.Err()
+}
+
+// Открытый PR, заблокированный в транзакции: пока блокировка не снята,
+// другие изменения ревьюеров этого PR ждут
+type LockedPR struct {
+	tx *sqlx.Tx
+	PR *models.PullRequest
+}
+
+// Функция создает PR с метками в транзакции. До Commit PR не виден другим запросам,
+// поэтому назначение ревьюеров через LockedPR не пересекается с фоновым дополнением
+func (r *PRRepository) CreateLockedPR(ctx context.Context, pr *models.PullRequest, labels []string) (*LockedPR, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, team_name, repository_id, status, created_at, merged_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8)
+	`
+	if _, err := tx.ExecContext(ctx, query, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.TeamName, pr.RepositoryID, pr.Status, pr.CreatedAt, pr.MergedAt); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if len(labels) > 0 {
+		query := `
+			INSERT INTO pr_labels (pr_id, label)
+			SELECT $1, lower(trim(l)) FROM unnest($2::varchar[]) AS l
+			WHERE trim(l) <> ''
+			ON CONFLICT DO NOTHING
+		`
+		if _, err := tx.ExecContext(ctx, query, pr.PullRequestID, pq.Array(labels)); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	return &LockedPR{tx: tx, PR: pr}, nil
+}
+
+// Функция блокирует открытый PR вместе с ревьюерами, дожидаясь снятия чужой блокировки.
+// Возвращает nil, если PR не найден или уже смержен
+func (r *PRRepository) LockOpenPR(ctx context.Context, prID string) (*LockedPR, error) {
+	return r.lockOpenPR(ctx, prID, "FOR UPDATE")
+}
+
+// Функция блокирует открытый PR, как LockOpenPR, но не ждет: PR, заблокированный
+// другой транзакцией, пропускается и возвращается nil (SKIP LOCKED)
+func (r *PRRepository) TryLockOpenPR(ctx context.Context, prID string) (*LockedPR, error) {
+	return r.lockOpenPR(ctx, prID, "FOR UPDATE SKIP LOCKED")
+}
+
+func (r *PRRepository) lockOpenPR(ctx context.Context, prID, lockClause string) (*LockedPR, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT pull_request_id, pull_request_name, author_id, COALESCE(team_name, ''), COALESCE(repository_id, ''),
+			COALESCE((SELECT array_agg(label ORDER BY label) FROM pr_labels WHERE pr_id = pull_request_id), '{}'),
+			COALESCE((SELECT array_agg(reviewer_user_id ORDER BY assigned_at) FROM pr_reviewers WHERE pr_id = pull_request_id), '{}'),
+			status, created_at, merged_at
+		FROM pull_requests
+		WHERE pull_request_id = $1 AND status = 'OPEN'
+		` + lockClause
+	var pr models.PullRequest
+	err = tx.QueryRowContext(ctx, query, prID).Scan(
+		&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.TeamName, &pr.RepositoryID,
+		(*pq.StringArray)(&pr.Labels), (*pq.StringArray)(&pr.AssignedReviewers), &pr.Status, &pr.CreatedAt, &pr.MergedAt,
+	)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &LockedPR{tx: tx, PR: &pr}, nil
+}
+
+// Функция назначает ревьюеров заблокированному PR, изменения видны после Commit
+func (l *LockedPR) AddReviewers(ctx context.Context, reviewerIDs []string) error {
+	query := `
+		INSERT INTO pr_reviewers (pr_id, reviewer_user_id, assigned_at)
+		SELECT $1, unnest($2::varchar[]), $3
+		ON CONFLICT (pr_id, reviewer_user_id) DO NOTHING
+	`
+	_, err := l.tx.ExecContext(ctx, query, l.PR.PullRequestID, pq.Array(reviewerIDs), time.Now())
+	return err
+}
+
+// Функция снимает ревьюера с заблокированного PR, изменения видны после Commit
+func (l *LockedPR) RemoveReviewer(ctx context.Context, reviewerID string) error {
+	_, err := l.tx.ExecContext(ctx, `DELETE FROM pr_reviewers WHERE pr_id = $1 AND reviewer_user_id = $2`, l.PR.PullRequestID, reviewerID)
+	return err
+}
+
+// Функция фиксирует изменения и снимает блокировку
+func (l *LockedPR) Commit() error {
+	return l.tx.Commit()
+}
+
+// Функция снимает блокировку без изменений, после Commit ничего не делает
+func (l *LockedPR) Release() error {
+	return l.tx.Rollback()
+}
+
+// Функция добавляет метки PR
+func (r *PRRepository) AddPRLabels(ctx context.Context, prID string, labels []string) error {
+	query := `
+		INSERT INTO pr_labels (pr_id, label)
+		SELECT $1, lower(trim(l)) FROM unnest($2::varchar[]) AS l
+		WHERE trim(l) <> ''
+		ON CONFLICT DO NOTHING
+	`
+	_, err := r.db.ExecContext(ctx, query, prID, pq.Array(labels))
+	return err
+}
+
+// Функция удаляет метки PR
+func (r *PRRepository) RemovePRLabels(ctx context.Context, prID string, labels []string) error {
+	query := `
+		DELETE FROM pr_labels
+		WHERE pr_id = $1 AND label IN (SELECT lower(trim(l)) FROM unnest($2::varchar[]) AS l)
+	`
+	_, err := r.db.ExecContext(ctx, query, prID, pq.Array(labels))
+	return err
+}
+
+// Функция заменяет метки PR
+func (r *PRRepository) SetPRLabels(ctx context.Context, prID string, labels []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM pr_labels WHERE pr_id = $1`, prID); err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO pr_labels (pr_id, label)
+		SELECT $1, lower(trim(l)) FROM unnest($2::varchar[]) AS l
+		WHERE trim(l) <> ''
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, query, prID, pq.Array(labels)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Функция возвращает количество открытых PR без ревьюеров
+func (r *PRRepository) CountOpenPRsWithoutReviewers(ctx context.Context) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM pull_requests pr
+		WHERE pr.status = 'OPEN'
+			AND NOT EXISTS (SELECT 1 FROM pr_reviewers prv WHERE prv.pr_id = pr.pull_request_id)
+	`
+	var count int
+	err := r.db.QueryRowContext(ctx, query).Scan(&count)
+	return count, err
+}
+
+// Функция возвращает количество открытых PR по целевой команде
+func (r *PRRepository) CountOpenPRsByTeam(ctx context.Context) (map[string]int, error) {
+	query := `
+		SELECT COALESCE(team_name, ''), COUNT(*)
+		FROM pull_requests
+		WHERE status = 'OPEN'
+		GROUP BY team_name
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var team string
+		var count int
+		if err := rows.Scan(&team, &count); err != nil {
+			return nil, err
+		}
+		counts[team] = count
+	}
+
+	return counts, rows.Err()
+}
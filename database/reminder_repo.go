@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Виды напоминаний, для каждого хранится время последнего запуска
+const (
+	ReminderDigest     = "digest"
+	ReminderEscalation = "escalation"
+)
+
+type ReminderRepository struct {
+	db *sqlx.DB
+}
+
+func NewReminderRepository(db *sqlx.DB) *ReminderRepository {
+	return &ReminderRepository{db: db}
+}
+
+const teamRemindersColumns = `
+	team_name, digest_schedule, escalation_schedule, escalation_after_hours, timezone,
+	last_digest_at, last_escalation_at, updated_at
+`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTeamReminders(row rowScanner) (*models.TeamReminders, error) {
+	var reminders models.TeamReminders
+	err := row.Scan(&reminders.TeamName, &reminders.DigestSchedule, &reminders.EscalationSchedule,
+		&reminders.EscalationAfterHours, &reminders.Timezone,
+		&reminders.LastDigestAt, &reminders.LastEscalationAt, &reminders.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &reminders, nil
+}
+
+// Функция возвращает расписания напоминаний команды, nil если они не заданы
+func (r *ReminderRepository) GetTeamReminders(ctx context.Context, teamName string) (*models.TeamReminders, error) {
+	query := `SELECT ` + teamRemindersColumns + ` FROM team_reminders WHERE team_name = $1`
+	reminders, err := scanTeamReminders(r.db.QueryRowContext(ctx, query, teamName))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return reminders, err
+}
+
+// Функция возвращает расписания всех команд
+func (r *ReminderRepository) ListTeamReminders(ctx context.Context) ([]models.TeamReminders, error) {
+	query := `SELECT ` + teamRemindersColumns + ` FROM team_reminders ORDER BY team_name`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.TeamReminders
+	for rows.Next() {
+		reminders, err := scanTeamReminders(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *reminders)
+	}
+	return list, rows.Err()
+}
+
+// Функция создает или заменяет расписания команды; отсчет расписаний начинается заново
+func (r *ReminderRepository) SetTeamReminders(ctx context.Context, reminders *models.TeamReminders) error {
+	query := `
+		INSERT INTO team_reminders (team_name, digest_schedule, escalation_schedule, escalation_after_hours, timezone, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (team_name) DO UPDATE
+		SET digest_schedule = EXCLUDED.digest_schedule,
+			escalation_schedule = EXCLUDED.escalation_schedule,
+			escalation_after_hours = EXCLUDED.escalation_after_hours,
+			timezone = EXCLUDED.timezone,
+			last_digest_at = NULL,
+			last_escalation_at = NULL,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.ExecContext(ctx, query, reminders.TeamName, reminders.DigestSchedule, reminders.EscalationSchedule,
+		reminders.EscalationAfterHours, reminders.Timezone, reminders.UpdatedAt)
+	return err
+}
+
+// Функция отмечает запуск напоминания, если с момента previous его не запустил другой экземпляр.
+// Возвращает false, если запуск уже выполнен
+func (r *ReminderRepository) ClaimRun(ctx context.Context, teamName, kind string, previous *time.Time, now time.Time) (bool, error) {
+	column := "last_digest_at"
+	if kind == ReminderEscalation {
+		column = "last_escalation_at"
+	}
+	query := `
+		UPDATE team_reminders
+		SET ` + column + ` = $3
+		WHERE team_name = $1 AND ` + column + ` IS NOT DISTINCT FROM $2
+	`
+	result, err := r.db.ExecContext(ctx, query, teamName, previous, now)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// Функция возвращает назначения активных ревьюеров на открытые PR команды без решения
+func (r *ReminderRepository) GetPendingReviews(ctx context.Context, teamName string) ([]models.PendingReview, error) {
+	query := `
+		SELECT prv.pr_id, pr.pull_request_name, pr.author_id, pr.team_name, prv.reviewer_user_id, prv.assigned_at
+		FROM pr_reviewers prv
+		INNER JOIN pull_requests pr ON pr.pull_request_id = prv.pr_id
+		INNER JOIN users u ON u.user_id = prv.reviewer_user_id
+		WHERE pr.team_name = $1
+			AND pr.status = 'OPEN'
+			AND prv.first_decision_at IS NULL
+			AND u.is_active = true
+		ORDER BY prv.reviewer_user_id, prv.assigned_at
+	`
+	rows, err := r.db.QueryContext(ctx, query, teamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []models.PendingReview
+	for rows.Next() {
+		var review models.PendingReview
+		if err := rows.Scan(&review.PullRequestID, &review.PullRequestName, &review.AuthorID, &review.TeamName,
+			&review.ReviewerID, &review.AssignedAt); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type RepositoryRepository struct {
+	db *sqlx.DB
+}
+
+func NewRepositoryRepository(db *sqlx.DB) *RepositoryRepository {
+	return &RepositoryRepository{db: db}
+}
+
+// Функция создает репозиторий вместе с пулом ревьюеров
+func (r *RepositoryRepository) CreateRepository(ctx context.Context, repo *models.Repository) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO repositories (repository_id, name)
+		VALUES ($1, $2)
+	`
+	if _, err := tx.ExecContext(ctx, query, repo.RepositoryID, repo.Name); err != nil {
+		return err
+	}
+	if err := insertRepositoryPool(ctx, tx, repo); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Функция заменяет название и пул ревьюеров репозитория
+func (r *RepositoryRepository) UpdateRepository(ctx context.Context, repo *models.Repository) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE repositories SET name = $1 WHERE repository_id = $2`, repo.Name, repo.RepositoryID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM repository_teams WHERE repository_id = $1`, repo.RepositoryID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM repository_reviewers WHERE repository_id = $1`, repo.RepositoryID); err != nil {
+		return err
+	}
+	if err := insertRepositoryPool(ctx, tx, repo); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertRepositoryPool(ctx context.Context, tx *sqlx.Tx, repo *models.Repository) error {
+	teamsQuery := `
+		INSERT INTO repository_teams (repository_id, team_name)
+		SELECT $1, unnest($2::varchar[])
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, teamsQuery, repo.RepositoryID, pq.Array(repo.Teams)); err != nil {
+		return err
+	}
+
+	reviewersQuery := `
+		INSERT INTO repository_reviewers (repository_id, user_id, kind)
+		SELECT $1, unnest($2::varchar[]), $3
+		ON CONFLICT (repository_id, user_id) DO UPDATE SET kind = EXCLUDED.kind
+	`
+	if _, err := tx.ExecContext(ctx, reviewersQuery, repo.RepositoryID, pq.Array(repo.ExtraReviewers), "EXTRA"); err != nil {
+		return err
+	}
+	// Исключение сильнее добавления
+	if _, err := tx.ExecContext(ctx, reviewersQuery, repo.RepositoryID, pq.Array(repo.ExcludedReviewers), "EXCLUDED"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Функция возвращает репозиторий по ID
+func (r *RepositoryRepository) GetRepositoryByID(ctx context.Context, repositoryID string) (*models.Repository, error) {
+	query := `
+		SELECT r.repository_id, r.name,
+			COALESCE((SELECT array_agg(team_name ORDER BY team_name) FROM repository_teams
+				WHERE repository_id = r.repository_id), '{}'),
+			COALESCE((SELECT array_agg(user_id ORDER BY user_id) FROM repository_reviewers
+				WHERE repository_id = r.repository_id AND kind = 'EXTRA'), '{}'),
+			COALESCE((SELECT array_agg(user_id ORDER BY user_id) FROM repository_reviewers
+				WHERE repository_id = r.repository_id AND kind = 'EXCLUDED'), '{}')
+		FROM repositories r
+		WHERE r.repository_id = $1
+	`
+	var repo models.Repository
+	err := r.db.QueryRowContext(ctx, query, repositoryID).Scan(
+		&repo.RepositoryID, &repo.Name,
+		(*pq.StringArray)(&repo.Teams),
+		(*pq.StringArray)(&repo.ExtraReviewers),
+		(*pq.StringArray)(&repo.ExcludedReviewers),
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return &repo, err
+}
+
+// Функция проверяет существование репозитория
+func (r *RepositoryRepository) RepositoryExists(ctx context.Context, repositoryID string) (bool, error) {
+	repo, err := r.GetRepositoryByID(ctx, repositoryID)
+	if err != nil {
+		return false, err
+	}
+	return repo != nil, nil
+}
+
+// Функция возвращает пул ревьюеров репозитория: участники команд и дополнительные ревьюеры без исключенных
+func (r *RepositoryRepository) GetReviewerPool(ctx context.Context, repositoryID string) ([]models.User, error) {
+	query := `
+		SELECT u.user_id, u.username, u.is_active
+		FROM users u
+		WHERE (
+			u.user_id IN (
+				SELECT tm.user_id
+				FROM team_memberships tm
+				INNER JOIN repository_teams rt ON rt.team_name = tm.team_name
+				WHERE rt.repository_id = $1
+			)
+			OR u.user_id IN (
+				SELECT user_id FROM repository_reviewers
+				WHERE repository_id = $1 AND kind = 'EXTRA'
+			)
+		)
+		AND u.user_id NOT IN (
+			SELECT user_id FROM repository_reviewers
+			WHERE repository_id = $1 AND kind = 'EXCLUDED'
+		)
+		ORDER BY u.username
+	`
+	rows, err := r.db.QueryContext(ctx, query, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.UserID, &user.Username, &user.IsActive); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SLARepository struct {
+	db *sqlx.DB
+}
+
+func NewSLARepository(db *sqlx.DB) *SLARepository {
+	return &SLARepository{db: db}
+}
+
+// Функция возвращает порог SLA команды, nil если он не задан
+func (r *SLARepository) GetTeamSLA(ctx context.Context, teamName string) (*models.TeamSLA, error) {
+	query := `
+		SELECT team_name, threshold_hours, auto_reassign
+		FROM team_sla
+		WHERE team_name = $1
+	`
+	var sla models.TeamSLA
+	err := r.db.QueryRowContext(ctx, query, teamName).Scan(&sla.TeamName, &sla.ThresholdHours, &sla.AutoReassign)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sla, nil
+}
+
+// Функция создает или заменяет порог SLA команды
+func (r *SLARepository) SetTeamSLA(ctx context.Context, sla *models.TeamSLA) error {
+	query := `
+		INSERT INTO team_sla (team_name, threshold_hours, auto_reassign, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (team_name) DO UPDATE
+		SET threshold_hours = EXCLUDED.threshold_hours,
+			auto_reassign = EXCLUDED.auto_reassign,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.ExecContext(ctx, query, sla.TeamName, sla.ThresholdHours, sla.AutoReassign, time.Now())
+	return err
+}
+
+// Функция фиксирует первое решение ревьюера, повторные решения его не меняют.
+// first сообщает, что решение записано этим вызовом; nil, если ревьюер не назначен на PR
+func (r *SLARepository) RecordDecision(ctx context.Context, prID, reviewerID, decision string, decidedAt time.Time) (result *models.ReviewDecision, first bool, err error) {
+	query := `
+		WITH prev AS (
+			SELECT first_decision_at
+			FROM pr_reviewers
+			WHERE pr_id = $1 AND reviewer_user_id = $2
+			FOR UPDATE
+		)
+		UPDATE pr_reviewers prv
+		SET first_decision_at = COALESCE(prv.first_decision_at, $3),
+			decision = COALESCE(prv.decision, $4)
+		FROM prev
+		WHERE prv.pr_id = $1 AND prv.reviewer_user_id = $2
+		RETURNING prv.pr_id, prv.reviewer_user_id, prv.decision, prv.assigned_at, prv.first_decision_at,
+			prev.first_decision_at IS NULL
+	`
+	var decisionRow models.ReviewDecision
+	err = r.db.QueryRowContext(ctx, query, prID, reviewerID, decidedAt, decision).Scan(
+		&decisionRow.PullRequestID, &decisionRow.ReviewerID, &decisionRow.Decision,
+		&decisionRow.AssignedAt, &decisionRow.FirstDecisionAt, &first,
+	)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &decisionRow, first, nil
+}
+
+// Функция возвращает назначения открытых PR без решения, у которых календарный срок
+// уже прошел: он не позже срока в рабочих днях, поэтому окончательно срок проверяет сервис.
+// Команды без записи в team_sla получают порог и автопереназначение по умолчанию
+func (r *SLARepository) GetPendingPastThreshold(ctx context.Context, teamName string, defaultHours int, defaultAutoReassign bool, now time.Time) ([]models.OverdueAssignment, error) {
+	query := `
+		SELECT prv.pr_id, pr.pull_request_name, COALESCE(pr.team_name, ''), prv.reviewer_user_id, prv.assigned_at,
+			COALESCE(ts.threshold_hours, $1), COALESCE(ts.auto_reassign, $2)
+		FROM pr_reviewers prv
+		INNER JOIN pull_requests pr ON pr.pull_request_id = prv.pr_id
+		LEFT JOIN team_sla ts ON ts.team_name = pr.team_name
+		WHERE pr.status = 'OPEN'
+			AND prv.first_decision_at IS NULL
+			AND prv.assigned_at + COALESCE(ts.threshold_hours, $1) * INTERVAL '1 hour' < $3
+			AND ($4 = '' OR pr.team_name = $4)
+		ORDER BY prv.assigned_at, prv.pr_id
+	`
+	rows, err := r.db.QueryContext(ctx, query, defaultHours, defaultAutoReassign, now, teamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assignments []models.OverdueAssignment
+	for rows.Next() {
+		var a models.OverdueAssignment
+		if err := rows.Scan(&a.PullRequestID, &a.PullRequestName, &a.TeamName, &a.ReviewerID, &a.AssignedAt,
+			&a.ThresholdHours, &a.AutoReassign); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments, rows.Err()
+}
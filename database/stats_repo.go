@@ -0,0 +1,343 @@
+package repository
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Функция возвращает статистику назначений по пользователям, PR и статусам
+func (r *PRRepository) GetAssignmentStats(ctx context.Context) (*models.AssignmentStats, error) {
+	userStats, err := r.getUserAssignmentStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prStats, totalAssignments, activePRs, mergedPRs, err := r.getPRAssignmentStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AssignmentStats{
+		UserAssignments:  userStats,
+		PRAssignments:    prStats,
+		TotalAssignments: totalAssignments,
+		ActivePRs:        activePRs,
+		MergedPRs:        mergedPRs,
+	}, nil
+}
+
+// Функция возвращает статистику по пользователям
+func (r *PRRepository) getUserAssignmentStats(ctx context.Context) (map[string]int, error) {
+	query := `
+		SELECT reviewer_user_id, COUNT(*) as assignment_count
+		FROM pr_reviewers
+		GROUP BY reviewer_user_id
+		ORDER BY assignment_count DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[string]int)
+	for rows.Next() {
+		var userID string
+		var count int
+		if err := rows.Scan(&userID, &count); err != nil {
+			return nil, err
+		}
+		stats[userID] = count
+	}
+	return stats, nil
+}
+
+// Функция возвращает статистику по PR
+func (r *PRRepository) getPRAssignmentStats(ctx context.Context) (map[string]int, int, int, int, error) {
+	prQuery := `
+		SELECT pr_id, COUNT(*) as reviewer_count
+		FROM pr_reviewers
+		GROUP BY pr_id
+	`
+	prRows, err := r.db.QueryContext(ctx, prQuery)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	defer prRows.Close()
+
+	prStats := make(map[string]int)
+	totalAssignments := 0
+	for prRows.Next() {
+		var prID string
+		var count int
+		if err := prRows.Scan(&prID, &count); err != nil {
+			return nil, 0, 0, 0, err
+		}
+		prStats[prID] = count
+		totalAssignments += count
+	}
+
+	statusQuery := `
+		SELECT status, COUNT(*) 
+		FROM pull_requests 
+		GROUP BY status
+	`
+	statusRows, err := r.db.QueryContext(ctx, statusQuery)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	defer statusRows.Close()
+
+	activePRs, mergedPRs := 0, 0
+	for statusRows.Next() {
+		var status string
+		var count int
+		if err := statusRows.Scan(&status, &count); err != nil {
+			return nil, 0, 0, 0, err
+		}
+		switch status {
+		case "OPEN":
+			activePRs = count
+		case "MERGED":
+			mergedPRs = count
+		}
+	}
+	return prStats, totalAssignments, activePRs, mergedPRs, nil
+}
+
+// Группировки статистики по периодам, значения date_trunc
+const (
+	StatsGroupDay   = "day"
+	StatsGroupWeek  = "week"
+	StatsGroupMonth = "month"
+)
+
+// Счетчики за период по ключу группировки. В ключе %[1]s заменяется на столбец времени счетчика,
+// фильтры: $1 - начало, $2 - конец полуинтервала (NULL - без границы), $3 - команда PR (” - все)
+const statsCountsQuery = `
+	WITH a AS (
+		SELECT %[1]s AS k, COUNT(*) AS n
+		FROM (SELECT r.assigned_at AS ts, pr.team_name
+			FROM pr_reviewers r
+			JOIN pull_requests pr ON pr.pull_request_id = r.pr_id) t
+		WHERE ($1::timestamp IS NULL OR ts >= $1) AND ($2::timestamp IS NULL OR ts < $2)
+			AND ($3 = '' OR team_name = $3)
+		GROUP BY 1
+	), c AS (
+		SELECT %[1]s AS k, COUNT(*) AS n, COUNT(*) FILTER (WHERE status = 'OPEN') AS open
+		FROM (SELECT created_at AS ts, team_name, status FROM pull_requests) t
+		WHERE ($1::timestamp IS NULL OR ts >= $1) AND ($2::timestamp IS NULL OR ts < $2)
+			AND ($3 = '' OR team_name = $3)
+		GROUP BY 1
+	), m AS (
+		SELECT %[1]s AS k, COUNT(*) AS n
+		FROM (SELECT merged_at AS ts, team_name FROM pull_requests WHERE merged_at IS NOT NULL) t
+		WHERE ($1::timestamp IS NULL OR ts >= $1) AND ($2::timestamp IS NULL OR ts < $2)
+			AND ($3 = '' OR team_name = $3)
+		GROUP BY 1
+	)
+	SELECT k, COALESCE(a.n, 0), COALESCE(c.n, 0), COALESCE(m.n, 0), COALESCE(c.open, 0)
+	FROM a
+	FULL JOIN c USING (k)
+	FULL JOIN m USING (k)
+	ORDER BY k
+`
+
+// Функция возвращает счетчики за период по интервалам группировки groupBy
+func (r *PRRepository) GetPeriodStats(ctx context.Context, q models.StatsQuery, groupBy string) ([]models.PeriodStats, error) {
+	switch groupBy {
+	case StatsGroupDay, StatsGroupWeek, StatsGroupMonth:
+	default:
+		return nil, fmt.Errorf("unknown stats grouping %q", groupBy)
+	}
+	query := fmt.Sprintf(statsCountsQuery, "date_trunc('"+groupBy+"', ts)")
+	rows, err := r.db.QueryContext(ctx, query, q.From, q.To, q.TeamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var periods []models.PeriodStats
+	for rows.Next() {
+		var p models.PeriodStats
+		if err := rows.Scan(&p.PeriodStart, &p.Assignments, &p.CreatedPRs, &p.MergedPRs, &p.OpenPRs); err != nil {
+			return nil, err
+		}
+		periods = append(periods, p)
+	}
+	return periods, rows.Err()
+}
+
+// Функция возвращает счетчики за период по командам PR
+func (r *PRRepository) GetTeamStats(ctx context.Context, q models.StatsQuery) ([]models.TeamStats, error) {
+	query := fmt.Sprintf(statsCountsQuery, "COALESCE(team_name, '')")
+	rows, err := r.db.QueryContext(ctx, query, q.From, q.To, q.TeamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := []models.TeamStats{}
+	for rows.Next() {
+		var t models.TeamStats
+		if err := rows.Scan(&t.TeamName, &t.Assignments, &t.CreatedPRs, &t.MergedPRs, &t.OpenPRs); err != nil {
+			return nil, err
+		}
+		teams = append(teams, t)
+	}
+	return teams, rows.Err()
+}
+
+// Функция возвращает перцентили времени до мержа для PR, смерженных за период
+func (r *PRRepository) GetReviewLatency(ctx context.Context, q models.StatsQuery) (*models.ReviewLatency, error) {
+	query := `
+		WITH merged AS (
+			SELECT pull_request_id, created_at, merged_at
+			FROM pull_requests
+			WHERE merged_at IS NOT NULL
+				AND ($1::timestamp IS NULL OR merged_at >= $1) AND ($2::timestamp IS NULL OR merged_at < $2)
+				AND ($3 = '' OR team_name = $3)
+		)
+		SELECT
+			(SELECT COUNT(*) FROM merged),
+			(SELECT percentile_cont(ARRAY[0.5, 0.9, 0.95, 0.99]) WITHIN GROUP (
+				ORDER BY EXTRACT(EPOCH FROM merged_at - created_at)::float8)
+			FROM merged),
+			(SELECT COUNT(*) FROM merged m JOIN pr_reviewers r ON r.pr_id = m.pull_request_id
+			WHERE r.assigned_at <= m.merged_at),
+			(SELECT percentile_cont(ARRAY[0.5, 0.9, 0.95, 0.99]) WITHIN GROUP (
+				ORDER BY EXTRACT(EPOCH FROM m.merged_at - r.assigned_at)::float8)
+			FROM merged m JOIN pr_reviewers r ON r.pr_id = m.pull_request_id
+			WHERE r.assigned_at <= m.merged_at)
+	`
+	var latency models.ReviewLatency
+	var toMerge, assignmentToMerge pq.Float64Array
+	err := r.db.QueryRowContext(ctx, query, q.From, q.To, q.TeamName).Scan(
+		&latency.TimeToMerge.Count, &toMerge,
+		&latency.AssignmentToMerge.Count, &assignmentToMerge,
+	)
+	if err != nil {
+		return nil, err
+	}
+	setPercentiles(&latency.TimeToMerge, toMerge)
+	setPercentiles(&latency.AssignmentToMerge, assignmentToMerge)
+	return &latency, nil
+}
+
+// Функция раскладывает результат percentile_cont; при пустой выборке перцентили остаются нулевыми
+func setPercentiles(p *models.LatencyPercentiles, values []float64) {
+	if len(values) != 4 {
+		return
+	}
+	p.P50, p.P90, p.P95, p.P99 = values[0], values[1], values[2], values[3]
+}
+
+// Функция возвращает limit ревьюеров с наибольшим числом назначений за период
+func (r *PRRepository) GetTopReviewers(ctx context.Context, q models.StatsQuery, limit int) ([]models.ReviewerLoad, error) {
+	query := `
+		SELECT r.reviewer_user_id, u.username, COUNT(*) AS assignments,
+			COUNT(*) FILTER (WHERE pr.status = 'OPEN') AS open_assignments
+		FROM pr_reviewers r
+		JOIN pull_requests pr ON pr.pull_request_id = r.pr_id
+		JOIN users u ON u.user_id = r.reviewer_user_id
+		WHERE ($1::timestamp IS NULL OR r.assigned_at >= $1) AND ($2::timestamp IS NULL OR r.assigned_at < $2)
+			AND ($3 = '' OR pr.team_name = $3)
+		GROUP BY r.reviewer_user_id, u.username
+		ORDER BY assignments DESC, r.reviewer_user_id
+		LIMIT $4
+	`
+	rows, err := r.db.QueryContext(ctx, query, q.From, q.To, q.TeamName, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviewers := []models.ReviewerLoad{}
+	for rows.Next() {
+		var load models.ReviewerLoad
+		if err := rows.Scan(&load.UserID, &load.Username, &load.Assignments, &load.OpenAssignments); err != nil {
+			return nil, err
+		}
+		reviewers = append(reviewers, load)
+	}
+	return reviewers, rows.Err()
+}
+
+// Функция возвращает назначения и активные дни участников команд по периодам отчета о справедливости.
+// Учитываются назначения на PR команды; участники берутся из текущего состава команд.
+// Доли и отклонения считает сервис
+func (r *PRRepository) GetMemberLoad(ctx context.Context, q models.FairnessQuery) ([]models.TeamFairness, error) {
+	periods := `SELECT $1::timestamp AS period_start, $1::timestamp AS window_start, $2::timestamp AS period_end`
+	if q.GroupBy != "" {
+		switch q.GroupBy {
+		case StatsGroupDay, StatsGroupWeek, StatsGroupMonth:
+		default:
+			return nil, fmt.Errorf("unknown stats grouping %q", q.GroupBy)
+		}
+		periods = fmt.Sprintf(`
+			SELECT p AS period_start,
+				GREATEST(p, $1::timestamp) AS window_start,
+				LEAST(p + interval '1 %[1]s', $2::timestamp) AS period_end
+			FROM generate_series(date_trunc('%[1]s', $1::timestamp), $2::timestamp - interval '1 microsecond', interval '1 %[1]s') p`,
+			q.GroupBy)
+	}
+	query := `
+		WITH periods AS (` + periods + `
+		), members AS (
+			SELECT tm.team_name, u.user_id, u.username
+			FROM team_memberships tm
+			JOIN users u ON u.user_id = tm.user_id
+			WHERE $3 = '' OR tm.team_name = $3
+		), intervals AS (
+			SELECT user_id, is_active, changed_at AS start_at,
+				COALESCE(LEAD(changed_at) OVER (PARTITION BY user_id ORDER BY changed_at, id), 'infinity') AS end_at
+			FROM user_activity_log
+		), active AS (
+			SELECT pe.period_start, i.user_id,
+				SUM(EXTRACT(EPOCH FROM LEAST(i.end_at, pe.period_end) - GREATEST(i.start_at, pe.window_start)))::float8 / 86400 AS days
+			FROM periods pe
+			JOIN intervals i ON i.is_active AND i.start_at < pe.period_end AND i.end_at > pe.window_start
+			GROUP BY pe.period_start, i.user_id
+		), assigned AS (
+			SELECT pe.period_start, pr.team_name, r.reviewer_user_id AS user_id, COUNT(*) AS n
+			FROM periods pe
+			JOIN pr_reviewers r ON r.assigned_at >= pe.window_start AND r.assigned_at < pe.period_end
+			JOIN pull_requests pr ON pr.pull_request_id = r.pr_id
+			WHERE $3 = '' OR pr.team_name = $3
+			GROUP BY pe.period_start, pr.team_name, r.reviewer_user_id
+		)
+		SELECT m.team_name, pe.window_start, pe.period_end, m.user_id, m.username,
+			COALESCE(a.days, 0), COALESCE(s.n, 0)
+		FROM periods pe
+		CROSS JOIN members m
+		LEFT JOIN active a ON a.period_start = pe.period_start AND a.user_id = m.user_id
+		LEFT JOIN assigned s ON s.period_start = pe.period_start AND s.user_id = m.user_id
+			AND s.team_name = m.team_name
+		ORDER BY m.team_name, pe.period_start, m.user_id
+	`
+	rows, err := r.db.QueryContext(ctx, query, q.From, q.To, q.TeamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := []models.TeamFairness{}
+	for rows.Next() {
+		var teamName string
+		var start, end time.Time
+		var member models.MemberFairness
+		if err := rows.Scan(&teamName, &start, &end, &member.UserID, &member.Username,
+			&member.ActiveDays, &member.Assignments); err != nil {
+			return nil, err
+		}
+		if n := len(teams); n == 0 || teams[n-1].TeamName != teamName || !teams[n-1].PeriodStart.Equal(start) {
+			teams = append(teams, models.TeamFairness{TeamName: teamName, PeriodStart: start, PeriodEnd: end})
+		}
+		team := &teams[len(teams)-1]
+		team.Members = append(team.Members, member)
+		team.TotalAssignments += member.Assignments
+	}
+	return teams, rows.Err()
+}
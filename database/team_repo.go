@@ -2,6 +2,7 @@ package repository
 
 import (
 	"Backend-trainee-assignment/models"
+	"context"
 	"database/sql"
 
 	"github.com/jmoiron/sqlx"
@@ -16,24 +17,24 @@ func NewTeamRepository(db *sqlx.DB) *TeamRepository {
 }
 
 // Функция создает новую команду
-func (r *TeamRepository) CreateTeam(team *models.Team) error {
+func (r *TeamRepository) CreateTeam(ctx context.Context, team *models.Team) error {
 	query := `
 		INSERT INTO teams (team_name)
 		VALUES ($1)
 	`
-	_, err := r.db.Exec(query, team.TeamName)
+	_, err := r.db.ExecContext(ctx, query, team.TeamName)
 	return err
 }
 
 // Функция возвращает команду по имени
-func (r *TeamRepository) GetTeamByName(teamName string) (*models.Team, error) {
+func (r *TeamRepository) GetTeamByName(ctx context.Context, teamName string) (*models.Team, error) {
 	query := `
 		SELECT team_name
 		FROM teams
 		WHERE team_name = $1
 	`
 	var team models.Team
-	err := r.db.QueryRow(query, teamName).Scan(&team.TeamName)
+	err := r.db.QueryRowContext(ctx, query, teamName).Scan(&team.TeamName)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -43,8 +44,8 @@ func (r *TeamRepository) GetTeamByName(teamName string) (*models.Team, error) {
 }
 
 // Функция проверяет существование команды
-func (r *TeamRepository) TeamExists(teamName string) (bool, error) {
-	team, err := r.GetTeamByName(teamName)
+func (r *TeamRepository) TeamExists(ctx context.Context, teamName string) (bool, error) {
+	team, err := r.GetTeamByName(ctx, teamName)
 	if err != nil {
 		return false, err
 	}
@@ -52,14 +53,14 @@ func (r *TeamRepository) TeamExists(teamName string) (bool, error) {
 }
 
 // Функция возвращает все команды
-func (r *TeamRepository) GetAllTeams() ([]models.Team, error) {
+func (r *TeamRepository) GetAllTeams(ctx context.Context) ([]models.Team, error) {
 	query := `
 		SELECT team_name
 		FROM teams
 		ORDER BY team_name
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -77,3 +78,61 @@ func (r *TeamRepository) GetAllTeams() ([]models.Team, error) {
 
 	return teams, nil
 }
+
+// Функция переименовывает команду, членство и PR обновляются каскадно
+func (r *TeamRepository) RenameTeam(ctx context.Context, teamName, newTeamName string) error {
+	query := `
+		UPDATE teams
+		SET team_name = $1
+		WHERE team_name = $2
+	`
+	_, err := r.db.ExecContext(ctx, query, newTeamName, teamName)
+	return err
+}
+
+// Функция удаляет команду вместе с членством участников
+func (r *TeamRepository) DeleteTeam(ctx context.Context, teamName string) error {
+	query := `
+		DELETE FROM teams
+		WHERE team_name = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, teamName)
+	return err
+}
+
+// Функция возвращает страницу команд и общее их число. Пустой teamName означает все команды
+func (r *TeamRepository) ListTeams(ctx context.Context, teamName string, offset, limit int) ([]models.Team, int, error) {
+	var total int
+	countQuery := `
+		SELECT count(*)
+		FROM teams
+		WHERE $1 = '' OR team_name = $1
+	`
+	if err := r.db.QueryRowContext(ctx, countQuery, teamName).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT team_name
+		FROM teams
+		WHERE $1 = '' OR team_name = $1
+		ORDER BY team_name
+		OFFSET $2 LIMIT $3
+	`
+	rows, err := r.db.QueryContext(ctx, query, teamName, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	teams := []models.Team{}
+	for rows.Next() {
+		var team models.Team
+		if err := rows.Scan(&team.TeamName); err != nil {
+			return nil, 0, err
+		}
+		teams = append(teams, team)
+	}
+
+	return teams, total, rows.Err()
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"Backend-trainee-assignment/models"
+	"context"
 	"database/sql"
 
 	"github.com/jmoiron/sqlx"
@@ -17,29 +18,33 @@ func NewUserRepository(db *sqlx.DB) *UserRepository {
 }
 
 // Функция создает нового пользователя
-func (r *UserRepository) CreateUser(user *models.User) error {
+func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (user_id, username, team_name, is_active)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO users (user_id, username, is_active)
+		VALUES ($1, $2, $3)
 		ON CONFLICT (user_id) DO UPDATE SET
 			username = EXCLUDED.username,
-			team_name = EXCLUDED.team_name,
 			is_active = EXCLUDED.is_active
 	`
-	_, err := r.db.Exec(query, user.UserID, user.Username, user.TeamName, user.IsActive)
+	_, err := r.db.ExecContext(ctx, query, user.UserID, user.Username, user.IsActive)
 	return err
 }
 
-// Функция возвращает пользователя по ID
-func (r *UserRepository) GetUserByID(userID string) (*models.User, error) {
+// Функция возвращает пользователя по ID вместе с его командами и навыками
+func (r *UserRepository) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
 	query := `
-		SELECT user_id, username, team_name, is_active
-		FROM users
-		WHERE user_id = $1
+		SELECT u.user_id, u.username, u.is_active,
+			COALESCE((SELECT array_agg(team_name ORDER BY team_name) FROM team_memberships
+				WHERE user_id = u.user_id), '{}'),
+			COALESCE((SELECT array_agg(tag ORDER BY tag) FROM user_skills
+				WHERE user_id = u.user_id), '{}')
+		FROM users u
+		WHERE u.user_id = $1
 	`
 	var user models.User
-	err := r.db.QueryRow(query, userID).Scan(
-		&user.UserID, &user.Username, &user.TeamName, &user.IsActive,
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&user.UserID, &user.Username, &user.IsActive,
+		(*pq.StringArray)(&user.Teams), (*pq.StringArray)(&user.Skills),
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -49,25 +54,25 @@ func (r *UserRepository) GetUserByID(userID string) (*models.User, error) {
 }
 
 // Функция обновляет статус пользователя
-func (r *UserRepository) UpdateUserActiveStatus(userID string, isActive bool) error {
+func (r *UserRepository) UpdateUserActiveStatus(ctx context.Context, userID string, isActive bool) error {
 	query := `
 		UPDATE users 
 		SET is_active = $1
 		WHERE user_id = $2
 	`
-	_, err := r.db.Exec(query, isActive, userID)
+	_, err := r.db.ExecContext(ctx, query, isActive, userID)
 	return err
 }
 
 // Функция возвращает список активных пользователей
-func (r *UserRepository) GetActiveUsers() ([]models.User, error) {
+func (r *UserRepository) GetActiveUsers(ctx context.Context) ([]models.User, error) {
 	query := `
-		SELECT user_id, username, team_name, is_active
+		SELECT user_id, username, is_active
 		FROM users
 		WHERE is_active = true
 		ORDER BY username
 	`
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -76,7 +81,7 @@ func (r *UserRepository) GetActiveUsers() ([]models.User, error) {
 	var users []models.User
 	for rows.Next() {
 		var user models.User
-		err := rows.Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive)
+		err := rows.Scan(&user.UserID, &user.Username, &user.IsActive)
 		if err != nil {
 			return nil, err
 		}
@@ -87,14 +92,15 @@ func (r *UserRepository) GetActiveUsers() ([]models.User, error) {
 }
 
 // Функция возвращает участников команды
-func (r *UserRepository) GetUsersByTeam(teamName string) ([]models.User, error) {
+func (r *UserRepository) GetUsersByTeam(ctx context.Context, teamName string) ([]models.User, error) {
 	query := `
-		SELECT user_id, username, team_name, is_active
-		FROM users
-		WHERE team_name = $1
-		ORDER BY username
+		SELECT u.user_id, u.username, u.is_active
+		FROM users u
+		INNER JOIN team_memberships tm ON tm.user_id = u.user_id
+		WHERE tm.team_name = $1
+		ORDER BY u.username
 	`
-	rows, err := r.db.Query(query, teamName)
+	rows, err := r.db.QueryContext(ctx, query, teamName)
 	if err != nil {
 		return nil, err
 	}
@@ -103,7 +109,7 @@ func (r *UserRepository) GetUsersByTeam(teamName string) ([]models.User, error)
 	var users []models.User
 	for rows.Next() {
 		var user models.User
-		err := rows.Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive)
+		err := rows.Scan(&user.UserID, &user.Username, &user.IsActive)
 		if err != nil {
 			return nil, err
 		}
@@ -113,17 +119,145 @@ func (r *UserRepository) GetUsersByTeam(teamName string) ([]models.User, error)
 	return users, nil
 }
 
-func (r *UserRepository) BulkDeactivateUsers(teamName string, userIDs []string) (int, error) {
+// Функция добавляет пользователя в команду
+func (r *UserRepository) AddTeamMembership(ctx context.Context, userID, teamName string) error {
 	query := `
-		UPDATE users 
-		SET is_active = false 
-		WHERE team_name = $1 AND user_id = ANY($2)
+		INSERT INTO team_memberships (user_id, team_name)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, team_name) DO NOTHING
+	`
+	_, err := r.db.ExecContext(ctx, query, userID, teamName)
+	return err
+}
+
+// Функция убирает пользователя из команды
+func (r *UserRepository) RemoveTeamMembership(ctx context.Context, userID, teamName string) error {
+	query := `
+		DELETE FROM team_memberships
+		WHERE user_id = $1 AND team_name = $2
+	`
+	_, err := r.db.ExecContext(ctx, query, userID, teamName)
+	return err
+}
+
+// Функция добавляет навыки пользователю
+func (r *UserRepository) AddUserSkills(ctx context.Context, userID string, tags []string) error {
+	query := `
+		INSERT INTO user_skills (user_id, tag)
+		SELECT $1, lower(trim(t)) FROM unnest($2::varchar[]) AS t
+		WHERE trim(t) <> ''
+		ON CONFLICT DO NOTHING
+	`
+	_, err := r.db.ExecContext(ctx, query, userID, pq.Array(tags))
+	return err
+}
+
+// Функция удаляет навыки пользователя
+func (r *UserRepository) RemoveUserSkills(ctx context.Context, userID string, tags []string) error {
+	query := `
+		DELETE FROM user_skills
+		WHERE user_id = $1 AND tag IN (SELECT lower(trim(t)) FROM unnest($2::varchar[]) AS t)
 	`
-	result, err := r.db.Exec(query, teamName, pq.Array(userIDs))
+	_, err := r.db.ExecContext(ctx, query, userID, pq.Array(tags))
+	return err
+}
+
+// Функция заменяет навыки пользователя
+func (r *UserRepository) SetUserSkills(ctx context.Context, userID string, tags []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return 0, err
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_skills WHERE user_id = $1`, userID); err != nil {
+		return err
 	}
+	query := `
+		INSERT INTO user_skills (user_id, tag)
+		SELECT $1, lower(trim(t)) FROM unnest($2::varchar[]) AS t
+		WHERE trim(t) <> ''
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, query, userID, pq.Array(tags)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
 
-	rowsAffected, _ := result.RowsAffected()
-	return int(rowsAffected), nil
+// Функция возвращает навыки пользователей, ключ - ID пользователя
+func (r *UserRepository) GetSkillsByUsers(ctx context.Context, userIDs []string) (map[string][]string, error) {
+	query := `
+		SELECT user_id, tag
+		FROM user_skills
+		WHERE user_id = ANY($1)
+		ORDER BY user_id, tag
+	`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	skills := make(map[string][]string)
+	for rows.Next() {
+		var userID, tag string
+		if err := rows.Scan(&userID, &tag); err != nil {
+			return nil, err
+		}
+		skills[userID] = append(skills[userID], tag)
+	}
+
+	return skills, rows.Err()
+}
+
+// Функция возвращает страницу пользователей с командами и общее их число.
+// Пустой userID означает всех пользователей
+func (r *UserRepository) ListUsers(ctx context.Context, userID string, offset, limit int) ([]models.User, int, error) {
+	var total int
+	countQuery := `
+		SELECT count(*)
+		FROM users
+		WHERE $1 = '' OR user_id = $1
+	`
+	if err := r.db.QueryRowContext(ctx, countQuery, userID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT u.user_id, u.username, u.is_active,
+			COALESCE((SELECT array_agg(team_name ORDER BY team_name) FROM team_memberships
+				WHERE user_id = u.user_id), '{}')
+		FROM users u
+		WHERE $1 = '' OR u.user_id = $1
+		ORDER BY u.user_id
+		OFFSET $2 LIMIT $3
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.UserID, &user.Username, &user.IsActive, (*pq.StringArray)(&user.Teams)); err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+
+	return users, total, rows.Err()
+}
+
+// Функция меняет имя и активность пользователя одним запросом
+func (r *UserRepository) UpdateUser(ctx context.Context, userID, username string, isActive bool) error {
+	query := `
+		UPDATE users
+		SET username = $1, is_active = $2
+		WHERE user_id = $3
+	`
+	_, err := r.db.ExecContext(ctx, query, username, isActive, userID)
+	return err
 }
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type WebhookRepository struct {
+	db *sqlx.DB
+}
+
+func NewWebhookRepository(db *sqlx.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// Функция возвращает вебхук команды, nil если он не задан
+func (r *WebhookRepository) GetTeamWebhook(ctx context.Context, teamName string) (*models.TeamWebhook, error) {
+	query := `
+		SELECT team_name, url, channel, username, templates, enabled
+		FROM team_webhooks
+		WHERE team_name = $1
+	`
+	var webhook models.TeamWebhook
+	var templates []byte
+	err := r.db.QueryRowContext(ctx, query, teamName).Scan(
+		&webhook.TeamName, &webhook.URL, &webhook.Channel, &webhook.Username, &templates, &webhook.Enabled,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(templates, &webhook.Templates); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// Функция создает или заменяет вебхук команды
+func (r *WebhookRepository) SetTeamWebhook(ctx context.Context, webhook *models.TeamWebhook) error {
+	templates, err := json.Marshal(webhook.Templates)
+	if err != nil {
+		return err
+	}
+	if webhook.Templates == nil {
+		templates = []byte("{}")
+	}
+	query := `
+		INSERT INTO team_webhooks (team_name, url, channel, username, templates, enabled, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (team_name) DO UPDATE
+		SET url = EXCLUDED.url,
+			channel = EXCLUDED.channel,
+			username = EXCLUDED.username,
+			templates = EXCLUDED.templates,
+			enabled = EXCLUDED.enabled,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err = r.db.ExecContext(ctx, query, webhook.TeamName, webhook.URL, webhook.Channel, webhook.Username,
+		string(templates), webhook.Enabled, time.Now())
+	return err
+}
+
+// Функция удаляет вебхук команды, возвращает false, если его не было
+func (r *WebhookRepository) DeleteTeamWebhook(ctx context.Context, teamName string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM team_webhooks WHERE team_name = $1`, teamName)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
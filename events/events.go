@@ -0,0 +1,181 @@
+package events
+
+import (
+	"Backend-trainee-assignment/metrics"
+	"sync"
+	"time"
+)
+
+type Type string
+
+const (
+	ReviewersBackfilled Type = "reviewers_backfilled"
+	ReviewersAssigned   Type = "reviewers_assigned"
+	ReviewerReassigned  Type = "reviewer_reassigned"
+	PRMerged            Type = "pr_merged"
+	UserDeactivated     Type = "user_deactivated"
+
+	// Служебная отметка подписчику: часть событий пропущена из-за переполнения его буфера.
+	// Приходит перед первым событием после пропуска, ID у нее нулевой
+	Resync Type = "resync"
+)
+
+// Событие. Для reviewers_assigned и pr_merged UserID - автор PR, Reviewers - ревьюеры;
+// для reviewer_reassigned UserID - снятый ревьюер, Reviewers - новый ревьюер (если найден);
+// для user_deactivated UserID - деактивированный пользователь
+type Event struct {
+	ID        uint64    `json:"id"`
+	Type      Type      `json:"type"`
+	PRID      string    `json:"pull_request_id,omitempty"`
+	UserID    string    `json:"user_id,omitempty"`
+	TeamName  string    `json:"team_name,omitempty"`
+	Reviewers []string  `json:"reviewers,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Функция проверяет, относится ли событие к пользователю: он участник события или ревьюер в нем
+func (e Event) Involves(userID string) bool {
+	if e.UserID == userID {
+		return true
+	}
+	for _, reviewer := range e.Reviewers {
+		if reviewer == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Шина событий, рассылает события всем подписчикам и хранит последние события для возобновления
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[int]*subscriber
+	nextID      int
+
+	lastEventID uint64
+	history     []Event
+	historySize int
+	historyHead int
+}
+
+type subscriber struct {
+	ch chan Event
+	// Подписчик пропустил события и еще не получил отметку Resync
+	lagging bool
+}
+
+// Функция создает шину, historySize - число последних событий, доступных для возобновления.
+// Отрицательный размер считается нулевым: история не хранится
+func NewBus(historySize int) *Bus {
+	if historySize < 0 {
+		historySize = 0
+	}
+	return &Bus{
+		subscribers: make(map[int]*subscriber),
+		history:     make([]Event, 0, historySize),
+		historySize: historySize,
+	}
+}
+
+// Функция публикует событие. Медленный подписчик с полным буфером пропускает событие и, как только
+// в буфере появится место, получает отметку Resync. Публикация в nil-шину ничего не делает
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastEventID++
+	event.ID = b.lastEventID
+	b.remember(event)
+
+	for _, sub := range b.subscribers {
+		sub.deliver(event)
+	}
+}
+
+// Функция отправляет событие подписчику без ожидания, предварая его отметкой Resync после пропуска
+func (s *subscriber) deliver(event Event) {
+	if s.lagging {
+		select {
+		case s.ch <- Event{Type: Resync, CreatedAt: event.CreatedAt}:
+			s.lagging = false
+		default:
+			metrics.EventsDropped.Inc()
+			return
+		}
+	}
+	select {
+	case s.ch <- event:
+	default:
+		s.lagging = true
+		metrics.EventsDropped.Inc()
+	}
+}
+
+// Функция подписывает на события, возвращает канал и функцию отписки
+func (b *Bus) Subscribe(buffer int) (<-chan Event, func()) {
+	_, _, ch, unsubscribe := b.SubscribeFrom(0, buffer)
+	return ch, unsubscribe
+}
+
+// Функция подписывает на события после lastID и возвращает пропущенные события из истории.
+// complete == false, если часть событий после lastID уже вытеснена из истории
+func (b *Bus) SubscribeFrom(lastID uint64, buffer int) (missed []Event, complete bool, ch <-chan Event, unsubscribe func()) {
+	events := make(chan Event, buffer)
+
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = &subscriber{ch: events}
+	complete = true
+	if lastID > 0 {
+		missed, complete = b.since(lastID)
+	}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe = func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, id)
+			b.mu.Unlock()
+			close(events)
+		})
+	}
+	return missed, complete, events, unsubscribe
+}
+
+// Функция сохраняет событие в кольцевой буфер истории
+func (b *Bus) remember(event Event) {
+	if b.historySize <= 0 {
+		return
+	}
+	if len(b.history) < b.historySize {
+		b.history = append(b.history, event)
+		return
+	}
+	b.history[b.historyHead] = event
+	b.historyHead = (b.historyHead + 1) % b.historySize
+}
+
+// Функция возвращает события истории с ID больше lastID в порядке публикации
+func (b *Bus) since(lastID uint64) ([]Event, bool) {
+	if lastID >= b.lastEventID {
+		return nil, true
+	}
+	var result []Event
+	for i := 0; i < len(b.history); i++ {
+		event := b.history[(b.historyHead+i)%len(b.history)]
+		if event.ID > lastID {
+			result = append(result, event)
+		}
+	}
+	oldest := b.lastEventID - uint64(len(b.history)) + 1
+	return result, lastID+1 >= oldest
+}
@@ -0,0 +1,61 @@
+package events
+
+import (
+	"Backend-trainee-assignment/metrics"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNewBusNegativeHistorySize(t *testing.T) {
+	bus := NewBus(-5)
+	bus.Publish(Event{Type: PRMerged})
+	missed, complete, _, unsubscribe := bus.SubscribeFrom(0, 1)
+	defer unsubscribe()
+	if len(missed) != 0 || !complete {
+		t.Fatalf("missed %v complete %v, want empty history", missed, complete)
+	}
+}
+
+func TestSlowSubscriberGetsResync(t *testing.T) {
+	bus := NewBus(10)
+	slow, unsubscribeSlow := bus.Subscribe(2)
+	defer unsubscribeSlow()
+	fast, unsubscribeFast := bus.Subscribe(10)
+	defer unsubscribeFast()
+	dropped := testutil.ToFloat64(metrics.EventsDropped)
+
+	for i := 0; i < 3; i++ {
+		bus.Publish(Event{Type: PRMerged, PRID: "pr-1"})
+	}
+	if got := testutil.ToFloat64(metrics.EventsDropped) - dropped; got != 1 {
+		t.Fatalf("dropped %v, want 1", got)
+	}
+	for want := uint64(1); want <= 2; want++ {
+		if event := <-slow; event.ID != want {
+			t.Fatalf("event %+v, want ID %d", event, want)
+		}
+	}
+
+	// После пропуска события 3 подписчик сначала получает отметку, затем событие 4
+	bus.Publish(Event{Type: PRMerged, PRID: "pr-1"})
+	if event := <-slow; event.Type != Resync || event.ID != 0 {
+		t.Fatalf("event %+v, want resync marker", event)
+	}
+	if event := <-slow; event.ID != 4 {
+		t.Fatalf("event %+v, want ID 4 after resync", event)
+	}
+	bus.Publish(Event{Type: PRMerged, PRID: "pr-1"})
+	if event := <-slow; event.ID != 5 {
+		t.Fatalf("event %+v, want ID 5 without another resync", event)
+	}
+	if got := testutil.ToFloat64(metrics.EventsDropped) - dropped; got != 1 {
+		t.Fatalf("dropped %v, want 1", got)
+	}
+
+	for want := uint64(1); want <= 5; want++ {
+		if event := <-fast; event.ID != want || event.Type == Resync {
+			t.Fatalf("fast subscriber event %+v, want ID %d", event, want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package grpcserver
+
+import (
+	"Backend-trainee-assignment/api/reviewpb"
+	"Backend-trainee-assignment/models"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func fromPBTeam(team *reviewpb.Team) models.Team {
+	result := models.Team{TeamName: team.GetTeamName(), Members: []models.TeamMember{}}
+	for _, m := range team.GetMembers() {
+		result.Members = append(result.Members, models.TeamMember{
+			UserID:   m.GetUserId(),
+			Username: m.GetUsername(),
+			IsActive: m.GetIsActive(),
+		})
+	}
+	return result
+}
+
+func toPBTeam(team models.Team) *reviewpb.Team {
+	result := &reviewpb.Team{TeamName: team.TeamName}
+	for _, m := range team.Members {
+		result.Members = append(result.Members, &reviewpb.TeamMember{
+			UserId:   m.UserID,
+			Username: m.Username,
+			IsActive: m.IsActive,
+		})
+	}
+	return result
+}
+
+func toPBUser(user *models.User) *reviewpb.User {
+	return &reviewpb.User{
+		UserId:   user.UserID,
+		Username: user.Username,
+		Teams:    user.Teams,
+		Skills:   user.Skills,
+		IsActive: user.IsActive,
+	}
+}
+
+func toPBPullRequest(pr *models.PullRequest) *reviewpb.PullRequest {
+	result := &reviewpb.PullRequest{
+		PullRequestId:     pr.PullRequestID,
+		PullRequestName:   pr.PullRequestName,
+		AuthorId:          pr.AuthorID,
+		TeamName:          pr.TeamName,
+		RepositoryId:      pr.RepositoryID,
+		Labels:            pr.Labels,
+		Status:            pr.Status,
+		AssignedReviewers: pr.AssignedReviewers,
+	}
+	if !pr.CreatedAt.IsZero() {
+		result.CreatedAt = timestamppb.New(pr.CreatedAt)
+	}
+	if pr.MergedAt != nil {
+		result.MergedAt = timestamppb.New(*pr.MergedAt)
+	}
+	return result
+}
+
+func toInt32Map(values map[string]int) map[string]int32 {
+	result := make(map[string]int32, len(values))
+	for k, v := range values {
+		result[k] = int32(v)
+	}
+	return result
+}
@@ -0,0 +1,170 @@
+package grpcserver
+
+import (
+	"Backend-trainee-assignment/api/reviewpb"
+	"Backend-trainee-assignment/logging"
+	service "Backend-trainee-assignment/services"
+	"context"
+	"errors"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Домен в ErrorInfo, Reason содержит код ошибки REST API (NOT_FOUND, PR_MERGED, ...)
+const errorDomain = "review-service"
+
+// Метаданные с ID запроса, аналог заголовка X-Request-ID
+const requestIDMetadata = "x-request-id"
+
+// Функция создает gRPC-сервер с перехватчиками и регистрирует в нем API
+func NewGRPCServer(srv *Server, requestTimeout time.Duration) *grpc.Server {
+	gs := newServer(requestTimeout)
+	reviewpb.RegisterReviewServiceServer(gs, srv)
+	return gs
+}
+
+// Функция создает gRPC-сервер с цепочкой перехватчиков. Восстановление после паники -
+// внешнее звено: паника в любом перехватчике или обработчике становится codes.Internal
+func newServer(requestTimeout time.Duration) *grpc.Server {
+	return grpc.NewServer(grpc.ChainUnaryInterceptor(
+		recoveryInterceptor(),
+		requestIDInterceptor(),
+		accessLogInterceptor(),
+		timeoutInterceptor(requestTimeout),
+		errorInterceptor(),
+	))
+}
+
+// Перехватчик, переводящий панику обработчика в ошибку codes.Internal вместо падения процесса
+func recoveryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.ErrorContext(ctx, "grpc handler panic", "method", info.FullMethod, "panic", r, "stack", string(debug.Stack()))
+				resp, err = nil, toStatus(ctx, errors.New("internal server error"))
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// Перехватчик, берущий ID запроса из метаданных, если он допустим, или создающий новый
+func requestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		requestID := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(requestIDMetadata); len(values) > 0 {
+				requestID = values[0]
+			}
+		}
+		if !logging.ValidRequestID(requestID) {
+			requestID = logging.NewRequestID()
+		}
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, requestID))
+		return handler(logging.WithRequestID(ctx, requestID), req)
+	}
+}
+
+// Перехватчик журнала доступа, одна запись на вызов
+func accessLogInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		level := slog.LevelInfo
+		switch code {
+		case codes.OK:
+		case codes.Internal, codes.Unknown, codes.DeadlineExceeded:
+			level = slog.LevelError
+		default:
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("method", info.FullMethod),
+			slog.String("code", code.String()),
+			slog.Int64("duration_ms", time.Since(start).Milliseconds()),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		slog.LogAttrs(ctx, level, "grpc request", attrs...)
+		return resp, err
+	}
+}
+
+// Перехватчик, ограничивающий время обработки вызова
+func timeoutInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
+
+// Перехватчик, переводящий ошибки сервисов в статусы gRPC
+func errorInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, toStatus(ctx, err)
+		}
+		return resp, nil
+	}
+}
+
+// Функция сопоставляет ошибку со статусом gRPC, код ошибки REST API передается в ErrorInfo
+func toStatus(ctx context.Context, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var domainErr *service.Error
+	var validationErr *service.ValidationError
+	var code codes.Code
+	var reason string
+	switch {
+	case errors.As(err, &domainErr):
+		code, reason = domainCode(domainErr.Code), domainErr.Code
+	case errors.As(err, &validationErr):
+		code, reason = codes.InvalidArgument, service.CodeValidation
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		code, reason = codes.DeadlineExceeded, service.CodeTimeout
+	case errors.Is(err, context.Canceled):
+		code, reason = codes.Canceled, service.CodeInternal
+	default:
+		code, reason = codes.Internal, service.CodeInternal
+	}
+
+	st := status.New(code, err.Error())
+	if detailed, detailErr := st.WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: errorDomain}); detailErr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// Функция выбирает код gRPC для кода доменной ошибки
+func domainCode(code string) codes.Code {
+	switch code {
+	case service.CodeNotFound:
+		return codes.NotFound
+	case service.CodeTeamExists, service.CodePRExists, service.CodeRepositoryExists,
+		service.CodeAlreadyMember, service.CodeAlreadyAssigned:
+		return codes.AlreadyExists
+	case service.CodeValidation:
+		return codes.InvalidArgument
+	case service.CodeInProgress:
+		return codes.Aborted
+	case service.CodeTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.FailedPrecondition
+	}
+}
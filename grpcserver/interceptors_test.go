@@ -0,0 +1,172 @@
+package grpcserver
+
+import (
+	"Backend-trainee-assignment/api/reviewpb"
+	"Backend-trainee-assignment/logging"
+	service "Backend-trainee-assignment/services"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Сервис, у которого GetTeam выполняет заданную функцию
+type fakeReviewServer struct {
+	reviewpb.UnimplementedReviewServiceServer
+	getTeam func(ctx context.Context) (*reviewpb.GetTeamResponse, error)
+}
+
+func (s *fakeReviewServer) GetTeam(ctx context.Context, _ *reviewpb.GetTeamRequest) (*reviewpb.GetTeamResponse, error) {
+	return s.getTeam(ctx)
+}
+
+// Функция поднимает сервер с перехватчиками на bufconn и возвращает клиента
+func newTestClient(t *testing.T, timeout time.Duration, getTeam func(ctx context.Context) (*reviewpb.GetTeamResponse, error)) reviewpb.ReviewServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	gs := newServer(timeout)
+	reviewpb.RegisterReviewServiceServer(gs, &fakeReviewServer{getTeam: getTeam})
+	go gs.Serve(listener)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return reviewpb.NewReviewServiceClient(conn)
+}
+
+func errorReason(t *testing.T, st *status.Status) string {
+	t.Helper()
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			if info.Domain != errorDomain {
+				t.Fatalf("ErrorInfo domain %q, want %q", info.Domain, errorDomain)
+			}
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+func TestStatusMapping(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    func(ctx context.Context) (*reviewpb.GetTeamResponse, error)
+		wantCode   codes.Code
+		wantReason string
+	}{
+		{name: "not found", handler: fail(service.ErrTeamNotFound), wantCode: codes.NotFound, wantReason: service.CodeNotFound},
+		{name: "exists", handler: fail(service.ErrPRExists), wantCode: codes.AlreadyExists, wantReason: service.CodePRExists},
+		{name: "precondition", handler: fail(service.ErrPRMerged), wantCode: codes.FailedPrecondition, wantReason: service.CodePRMerged},
+		{name: "in progress", handler: fail(service.ErrRequestInProgress), wantCode: codes.Aborted, wantReason: service.CodeInProgress},
+		{name: "validation", handler: fail(service.NewValidationError("team_name is required")), wantCode: codes.InvalidArgument, wantReason: service.CodeValidation},
+		{name: "wrapped domain error", handler: fail(errors.Join(errors.New("lookup"), service.ErrUserNotFound)), wantCode: codes.NotFound, wantReason: service.CodeNotFound},
+		{name: "internal", handler: fail(errors.New("connection refused")), wantCode: codes.Internal, wantReason: service.CodeInternal},
+		{
+			name: "deadline",
+			handler: func(ctx context.Context) (*reviewpb.GetTeamResponse, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+			wantCode: codes.DeadlineExceeded, wantReason: service.CodeTimeout,
+		},
+		{
+			name:     "panic",
+			handler:  func(ctx context.Context) (*reviewpb.GetTeamResponse, error) { panic("nil map write") },
+			wantCode: codes.Internal, wantReason: service.CodeInternal,
+		},
+		{name: "status passes through", handler: fail(status.Error(codes.Unauthenticated, "no token")), wantCode: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, 50*time.Millisecond, tt.handler)
+			_, err := client.GetTeam(context.Background(), &reviewpb.GetTeamRequest{TeamName: "backend"})
+			st, ok := status.FromError(err)
+			if !ok || st.Code() != tt.wantCode {
+				t.Fatalf("error %v, want code %s", err, tt.wantCode)
+			}
+			if reason := errorReason(t, st); reason != tt.wantReason {
+				t.Fatalf("reason %q, want %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func fail(err error) func(ctx context.Context) (*reviewpb.GetTeamResponse, error) {
+	return func(ctx context.Context) (*reviewpb.GetTeamResponse, error) { return nil, err }
+}
+
+func TestPanicDoesNotStopServer(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, time.Second, func(ctx context.Context) (*reviewpb.GetTeamResponse, error) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		return &reviewpb.GetTeamResponse{Team: &reviewpb.Team{TeamName: "backend"}}, nil
+	})
+
+	_, err := client.GetTeam(context.Background(), &reviewpb.GetTeamRequest{TeamName: "backend"})
+	if status.Code(err) != codes.Internal || strings.Contains(err.Error(), "boom") {
+		t.Fatalf("first call error %v, want Internal without panic value", err)
+	}
+	resp, err := client.GetTeam(context.Background(), &reviewpb.GetTeamRequest{TeamName: "backend"})
+	if err != nil || resp.GetTeam().GetTeamName() != "backend" {
+		t.Fatalf("second call: %v, %v", resp, err)
+	}
+}
+
+func TestRequestIDMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		sent     string
+		wantSame bool
+	}{
+		{name: "client id", sent: "req-42.a_b", wantSame: true},
+		{name: "missing"},
+		{name: "invalid", sent: "bad id/../x"},
+		{name: "too long", sent: strings.Repeat("x", 129)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			client := newTestClient(t, time.Second, func(ctx context.Context) (*reviewpb.GetTeamResponse, error) {
+				seen = logging.RequestID(ctx)
+				return &reviewpb.GetTeamResponse{}, nil
+			})
+
+			ctx := context.Background()
+			if tt.sent != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, requestIDMetadata, tt.sent)
+			}
+			var header metadata.MD
+			if _, err := client.GetTeam(ctx, &reviewpb.GetTeamRequest{}, grpc.Header(&header)); err != nil {
+				t.Fatal(err)
+			}
+			values := header.Get(requestIDMetadata)
+			if len(values) != 1 || values[0] != seen {
+				t.Fatalf("response metadata %v, handler context id %q", values, seen)
+			}
+			if tt.wantSame && seen != tt.sent {
+				t.Fatalf("request id %q, want client id %q", seen, tt.sent)
+			}
+			if !tt.wantSame && (seen == tt.sent || !logging.ValidRequestID(seen)) {
+				t.Fatalf("request id %q, want newly generated id", seen)
+			}
+		})
+	}
+}
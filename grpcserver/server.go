@@ -0,0 +1,191 @@
+package grpcserver
+
+import (
+	"Backend-trainee-assignment/api/reviewpb"
+	repository "Backend-trainee-assignment/database"
+	service "Backend-trainee-assignment/services"
+	"context"
+)
+
+// Реализация gRPC API поверх тех же сервисов и репозиториев, что и REST API
+type Server struct {
+	reviewpb.UnimplementedReviewServiceServer
+
+	teamRepo        *repository.TeamRepository
+	userRepo        *repository.UserRepository
+	prRepo          *repository.PRRepository
+	teamService     *service.TeamService
+	prService       *service.PRService
+	userService     *service.UserService
+	reviewerService *service.ReviewerService
+	reconciler      *service.Reconciler
+}
+
+func NewServer(teamRepo *repository.TeamRepository, userRepo *repository.UserRepository, prRepo *repository.PRRepository, teamService *service.TeamService, prService *service.PRService, userService *service.UserService, reviewerService *service.ReviewerService, reconciler *service.Reconciler) *Server {
+	return &Server{
+		teamRepo:        teamRepo,
+		userRepo:        userRepo,
+		prRepo:          prRepo,
+		teamService:     teamService,
+		prService:       prService,
+		userService:     userService,
+		reviewerService: reviewerService,
+		reconciler:      reconciler,
+	}
+}
+
+// Функция создает команду с участниками
+func (s *Server) AddTeam(ctx context.Context, req *reviewpb.AddTeamRequest) (*reviewpb.AddTeamResponse, error) {
+	if req.GetTeam().GetTeamName() == "" {
+		return nil, service.NewValidationError("team.team_name is required")
+	}
+	team := fromPBTeam(req.GetTeam())
+	if err := s.teamService.CreateTeam(ctx, team); err != nil {
+		return nil, err
+	}
+	s.reconciler.Trigger()
+	return &reviewpb.AddTeamResponse{Team: toPBTeam(team)}, nil
+}
+
+// Функция возвращает команду с участниками
+func (s *Server) GetTeam(ctx context.Context, req *reviewpb.GetTeamRequest) (*reviewpb.GetTeamResponse, error) {
+	if req.GetTeamName() == "" {
+		return nil, service.NewValidationError("team_name is required")
+	}
+	team, err := s.teamRepo.GetTeamByName(ctx, req.GetTeamName())
+	if err != nil {
+		return nil, err
+	}
+	if team == nil {
+		return nil, service.ErrTeamNotFound
+	}
+	members, err := s.userRepo.GetUsersByTeam(ctx, req.GetTeamName())
+	if err != nil {
+		return nil, err
+	}
+
+	pbTeam := &reviewpb.Team{TeamName: team.TeamName}
+	for _, user := range members {
+		pbTeam.Members = append(pbTeam.Members, &reviewpb.TeamMember{
+			UserId:   user.UserID,
+			Username: user.Username,
+			IsActive: user.IsActive,
+		})
+	}
+	return &reviewpb.GetTeamResponse{Team: pbTeam}, nil
+}
+
+// Функция устанавливает флаг активности пользователя
+func (s *Server) SetIsActive(ctx context.Context, req *reviewpb.SetIsActiveRequest) (*reviewpb.SetIsActiveResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, service.NewValidationError("user_id is required")
+	}
+	user, err := s.userService.SetIsActive(ctx, req.GetUserId(), req.GetIsActive())
+	if err != nil {
+		return nil, err
+	}
+	return &reviewpb.SetIsActiveResponse{User: toPBUser(user)}, nil
+}
+
+// Функция возвращает PR, назначенные пользователю
+func (s *Server) GetReview(ctx context.Context, req *reviewpb.GetReviewRequest) (*reviewpb.GetReviewResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, service.NewValidationError("user_id is required")
+	}
+	user, err := s.userRepo.GetUserByID(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, service.ErrUserNotFound
+	}
+	prs, err := s.prRepo.GetPRsByReviewer(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &reviewpb.GetReviewResponse{UserId: req.GetUserId()}
+	for _, pr := range prs {
+		resp.PullRequests = append(resp.PullRequests, &reviewpb.PullRequestShort{
+			PullRequestId:   pr.PullRequestID,
+			PullRequestName: pr.PullRequestName,
+			AuthorId:        pr.AuthorID,
+			Status:          pr.Status,
+		})
+	}
+	return resp, nil
+}
+
+// Функция создает PR и назначает ревьюеров
+func (s *Server) CreatePullRequest(ctx context.Context, req *reviewpb.CreatePullRequestRequest) (*reviewpb.CreatePullRequestResponse, error) {
+	if req.GetPullRequestId() == "" || req.GetPullRequestName() == "" || req.GetAuthorId() == "" {
+		return nil, service.NewValidationError("pull_request_id, pull_request_name and author_id are required")
+	}
+	pr, warning, err := s.prService.CreatePR(ctx, service.CreatePRInput{
+		PullRequestID:   req.GetPullRequestId(),
+		PullRequestName: req.GetPullRequestName(),
+		AuthorID:        req.GetAuthorId(),
+		TeamName:        req.GetTeamName(),
+		RepositoryID:    req.GetRepositoryId(),
+		Labels:          req.GetLabels(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reviewpb.CreatePullRequestResponse{Pr: toPBPullRequest(pr), Warning: warning}, nil
+}
+
+// Функция возвращает PR с ревьюерами
+func (s *Server) GetPullRequest(ctx context.Context, req *reviewpb.GetPullRequestRequest) (*reviewpb.GetPullRequestResponse, error) {
+	if req.GetPullRequestId() == "" {
+		return nil, service.NewValidationError("pull_request_id is required")
+	}
+	pr, err := s.prService.GetPR(ctx, req.GetPullRequestId())
+	if err != nil {
+		return nil, err
+	}
+	return &reviewpb.GetPullRequestResponse{Pr: toPBPullRequest(pr)}, nil
+}
+
+// Функция мержит PR
+func (s *Server) MergePullRequest(ctx context.Context, req *reviewpb.MergePullRequestRequest) (*reviewpb.MergePullRequestResponse, error) {
+	if req.GetPullRequestId() == "" {
+		return nil, service.NewValidationError("pull_request_id is required")
+	}
+	pr, err := s.prService.MergePR(ctx, req.GetPullRequestId())
+	if err != nil {
+		return nil, err
+	}
+	return &reviewpb.MergePullRequestResponse{Pr: toPBPullRequest(pr)}, nil
+}
+
+// Функция переназначает ревьюера
+func (s *Server) ReassignReviewer(ctx context.Context, req *reviewpb.ReassignReviewerRequest) (*reviewpb.ReassignReviewerResponse, error) {
+	if req.GetPullRequestId() == "" || req.GetOldUserId() == "" {
+		return nil, service.NewValidationError("pull_request_id and old_user_id are required")
+	}
+	newReviewerID, err := s.reviewerService.ReassignReviewer(ctx, req.GetPullRequestId(), req.GetOldUserId())
+	if err != nil {
+		return nil, err
+	}
+	pr, err := s.prService.GetPR(ctx, req.GetPullRequestId())
+	if err != nil {
+		return nil, err
+	}
+	return &reviewpb.ReassignReviewerResponse{Pr: toPBPullRequest(pr), ReplacedBy: newReviewerID}, nil
+}
+
+// Функция возвращает статистику назначений
+func (s *Server) GetStats(ctx context.Context, _ *reviewpb.GetStatsRequest) (*reviewpb.GetStatsResponse, error) {
+	stats, err := s.prRepo.GetAssignmentStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &reviewpb.GetStatsResponse{
+		UserAssignments:  toInt32Map(stats.UserAssignments),
+		PrAssignments:    toInt32Map(stats.PRAssignments),
+		TotalAssignments: int32(stats.TotalAssignments),
+		ActivePrs:        int32(stats.ActivePRs),
+		MergedPrs:        int32(stats.MergedPRs),
+	}, nil
+}
@@ -1,7 +1,9 @@
 package handler
 
 import (
-	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/metrics"
+	"Backend-trainee-assignment/models"
+	service "Backend-trainee-assignment/services"
 	"net/http"
 	"time"
 
@@ -9,131 +11,52 @@ import (
 )
 
 type BulkHandler struct {
-	userRepo *repository.UserRepository
-	prRepo   *repository.PRRepository
+	deactivationService *service.DeactivationService
 }
 
-func NewBulkHandler(userRepo *repository.UserRepository, prRepo *repository.PRRepository) *BulkHandler {
-	return &BulkHandler{
-		userRepo: userRepo,
-		prRepo:   prRepo,
-	}
+func NewBulkHandler(deactivationService *service.DeactivationService) *BulkHandler {
+	return &BulkHandler{deactivationService: deactivationService}
 }
 
 type BulkDeactivateRequest struct {
 	TeamName string   `json:"team_name" binding:"required"`
 	UserIDs  []string `json:"user_ids" binding:"required"`
+	DryRun   bool     `json:"dry_run"`
+}
+
+// Ответ массовой деактивации: отчет с временем обработки
+type BulkDeactivateResponse struct {
+	*models.DeactivationReport
+	ProcessingTimeMs int64  `json:"processing_time_ms"`
+	Message          string `json:"message"`
 }
 
-// Функция массово деактивирует пользователей и переназначает PR
+// Функция массово деактивирует пользователей и переназначает их открытые ревью одной транзакцией;
+// с dry_run возвращает отчет без изменений
 func (h *BulkHandler) BulkDeactivate(c *gin.Context) {
+	ctx := c.Request.Context()
 	startTime := time.Now()
 	var req BulkDeactivateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": map[string]interface{}{
-				"code":    "VALIDATION_ERROR",
-				"message": err.Error(),
-			},
-		})
+		c.Error(service.NewValidationError(err.Error()))
 		return
 	}
 
-	// Деактивация пользователей
-	deactivatedUsers, err := h.userRepo.BulkDeactivateUsers(req.TeamName, req.UserIDs)
+	report, err := h.deactivationService.BulkDeactivate(ctx, req.TeamName, req.UserIDs, req.DryRun)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
+		c.Error(err)
 		return
 	}
 
-	// Переназначаение ревьюеров
-	reassignedPRs, err := h.reassignReviewersForDeactivatedUsers(req.UserIDs)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
-		return
+	message := "Bulk deactivation completed successfully"
+	if req.DryRun {
+		message = "Dry run, nothing changed"
+	} else {
+		metrics.BulkDeactivationDuration.Observe(time.Since(startTime).Seconds())
 	}
-
-	processingTime := time.Since(startTime).Milliseconds()
-	c.JSON(http.StatusOK, gin.H{
-		"deactivated_users":  deactivatedUsers,
-		"reassigned_prs":     reassignedPRs,
-		"processing_time_ms": processingTime,
-		"message":            "Bulk deactivation completed successfully",
+	c.JSON(http.StatusOK, BulkDeactivateResponse{
+		DeactivationReport: report,
+		ProcessingTimeMs:   time.Since(startTime).Milliseconds(),
+		Message:            message,
 	})
 }
-
-// Функция по переназначению
-func (h *BulkHandler) reassignReviewersForDeactivatedUsers(userIDs []string) ([]string, error) {
-	var reassignedPRs []string
-	for _, userID := range userIDs {
-		prs, err := h.prRepo.GetOpenPRsByReviewer(userID)
-		if err != nil {
-			return nil, err
-		}
-
-		for _, pr := range prs {
-			newReviewer, err := h.findReplacementReviewer(pr.PullRequestID, userID)
-			if err == nil && newReviewer != "" {
-				// Удаление старого и добавление нового
-				h.prRepo.RemovePRReviewer(pr.PullRequestID, userID)
-				h.prRepo.AddPRReviewer(pr.PullRequestID, newReviewer)
-				reassignedPRs = append(reassignedPRs, pr.PullRequestID)
-			} else {
-				h.prRepo.RemovePRReviewer(pr.PullRequestID, userID)
-				reassignedPRs = append(reassignedPRs, pr.PullRequestID)
-			}
-		}
-	}
-
-	return reassignedPRs, nil
-}
-
-func (h *BulkHandler) findReplacementReviewer(prID, oldReviewerID string) (string, error) {
-	pr, err := h.prRepo.GetPRByID(prID)
-	if err != nil {
-		return "", err
-	}
-	author, err := h.userRepo.GetUserByID(pr.AuthorID)
-	if err != nil {
-		return "", err
-	}
-	teamMembers, err := h.userRepo.GetUsersByTeam(author.TeamName)
-	if err != nil {
-		return "", err
-	}
-	currentReviewers, err := h.prRepo.GetPRReviewers(prID)
-	if err != nil {
-		return "", err
-	}
-
-	// Поиск замены
-	for _, member := range teamMembers {
-		if member.IsActive &&
-			member.UserID != pr.AuthorID &&
-			member.UserID != oldReviewerID &&
-			!contains(currentReviewers, member.UserID) {
-			return member.UserID, nil
-		}
-	}
-
-	return "", nil
-}
-
-func contains(slice []string, item string) bool {
-	for _, s := range slice {
-		if s == item {
-			return true
-		}
-	}
-	return false
-}
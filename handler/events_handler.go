@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"Backend-trainee-assignment/events"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// Размер буфера подписчика, при переполнении событие пропускается
+const streamBuffer = 64
+
+type EventsHandler struct {
+	bus       *events.Bus
+	heartbeat time.Duration
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewEventsHandler(bus *events.Bus, heartbeat time.Duration) *EventsHandler {
+	return &EventsHandler{
+		bus:       bus,
+		heartbeat: heartbeat,
+		done:      make(chan struct{}),
+	}
+}
+
+// Функция закрывает все открытые потоки, иначе остановка сервера ждет их до таймаута
+func (h *EventsHandler) Close() {
+	h.closeOnce.Do(func() {
+		close(h.done)
+	})
+}
+
+// Функция отдает поток событий в формате SSE с фильтрами team_name и user_id.
+// Возобновление по Last-Event-ID (или last_event_id) отдает пропущенные события из истории шины,
+// если часть уже вытеснена - сначала отправляется событие resync. Resync приходит и тогда, когда
+// клиент не успевает читать поток и шина пропускает события из-за переполнения буфера
+func (h *EventsHandler) Stream(c *gin.Context) {
+	teamName := c.Query("team_name")
+	userID := c.Query("user_id")
+	lastID := lastEventID(c)
+
+	missed, complete, stream, unsubscribe := h.bus.SubscribeFrom(lastID, streamBuffer)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if !complete {
+		sse.Encode(c.Writer, sse.Event{Event: "resync", Data: gin.H{"last_event_id": lastID}})
+	}
+	sent := lastID
+	send := func(event events.Event) {
+		if event.Type == events.Resync {
+			sse.Encode(c.Writer, sse.Event{Event: "resync", Data: gin.H{"last_event_id": sent}})
+			return
+		}
+		if event.ID <= sent {
+			return
+		}
+		sent = event.ID
+		if teamName != "" && event.TeamName != teamName {
+			return
+		}
+		if userID != "" && !event.Involves(userID) {
+			return
+		}
+		sse.Encode(c.Writer, sse.Event{
+			Id:    strconv.FormatUint(event.ID, 10),
+			Event: string(event.Type),
+			Data:  event,
+		})
+	}
+	for _, event := range missed {
+		send(event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-h.done:
+			return
+		case event, ok := <-stream:
+			if !ok {
+				return
+			}
+			send(event)
+		case <-heartbeat.C:
+			// Комментарий SSE, клиенты его игнорируют, а прокси не закрывают соединение
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+// Функция возвращает ID последнего полученного события из заголовка или параметра запроса
+func lastEventID(c *gin.Context) uint64 {
+	value := c.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"Backend-trainee-assignment/models"
+	service "Backend-trainee-assignment/services"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Форматы выгрузок
+const (
+	ExportCSV    = "csv"
+	ExportNDJSON = "ndjson"
+)
+
+// Через сколько строк буфер выгрузки отправляется клиенту
+const exportFlushRows = 200
+
+// Первое поле последней строки CSV, если выгрузку оборвала ошибка: #error,код,сообщение
+const ExportErrorMarker = "#error"
+
+// Выгрузки для таблиц: строки пишутся в ответ по мере чтения курсора БД
+type ExportHandler struct {
+	exportService *service.ExportService
+	timeout       time.Duration
+}
+
+func NewExportHandler(exportService *service.ExportService, timeout time.Duration) *ExportHandler {
+	return &ExportHandler{exportService: exportService, timeout: timeout}
+}
+
+// Функция выгружает PR с ревьюерами; фильтры team_name, user_id (автор), status, from и to (время создания)
+func (h *ExportHandler) ExportPullRequests(c *gin.Context) {
+	header := []string{"pull_request_id", "pull_request_name", "author_id", "team_name", "repository_id",
+		"status", "created_at", "merged_at", "reviewers"}
+	h.export(c, "pull_requests", header, func(ctx context.Context, f models.ExportFilter, w *exportWriter) error {
+		return h.exportService.ExportPullRequests(ctx, f, func(pr models.PRExport) error {
+			return w.write(pr, []string{pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.TeamName, pr.RepositoryID,
+				pr.Status, formatExportTime(&pr.CreatedAt), formatExportTime(pr.MergedAt), strings.Join(pr.Reviewers, ";")})
+		})
+	})
+}
+
+// Функция выгружает историю назначений; фильтры team_name, user_id (ревьюер), status PR, from и to (время назначения)
+func (h *ExportHandler) ExportAssignments(c *gin.Context) {
+	header := []string{"pull_request_id", "pull_request_name", "team_name", "reviewer_id", "assigned_at",
+		"first_decision_at", "decision", "status", "merged_at"}
+	h.export(c, "assignments", header, func(ctx context.Context, f models.ExportFilter, w *exportWriter) error {
+		return h.exportService.ExportAssignments(ctx, f, func(a models.AssignmentExport) error {
+			return w.write(a, []string{a.PullRequestID, a.PullRequestName, a.TeamName, a.ReviewerID, formatExportTime(&a.AssignedAt),
+				formatExportTime(a.FirstDecisionAt), a.Decision, a.Status, formatExportTime(a.MergedAt)})
+		})
+	})
+}
+
+// Функция выгружает статистику пользователей; фильтры team_name (участники), user_id, from и to (время назначения)
+func (h *ExportHandler) ExportUserStats(c *gin.Context) {
+	header := []string{"user_id", "username", "is_active", "teams", "assignments", "open_assignments",
+		"approved", "changes_requested", "commented", "avg_first_decision_hours"}
+	h.export(c, "user_stats", header, func(ctx context.Context, f models.ExportFilter, w *exportWriter) error {
+		return h.exportService.ExportUserStats(ctx, f, func(s models.UserStatsExport) error {
+			avg := ""
+			if s.AvgFirstDecisionHours != nil {
+				avg = strconv.FormatFloat(*s.AvgFirstDecisionHours, 'f', 2, 64)
+			}
+			return w.write(s, []string{s.UserID, s.Username, strconv.FormatBool(s.IsActive), strings.Join(s.Teams, ";"),
+				strconv.Itoa(s.Assignments), strconv.Itoa(s.OpenAssignments), strconv.Itoa(s.Approved),
+				strconv.Itoa(s.ChangesRequested), strconv.Itoa(s.Commented), avg})
+		})
+	})
+}
+
+// Функция разбирает общие параметры и запускает выгрузку. До первой строки ошибка возвращается
+// обычным ответом, после - выгрузка завершается строкой с ошибкой, чтобы оборванный ответ 200
+// нельзя было принять за полный
+func (h *ExportHandler) export(c *gin.Context, name string, header []string,
+	run func(ctx context.Context, f models.ExportFilter, w *exportWriter) error) {
+	format := c.DefaultQuery("format", ExportCSV)
+	if format != ExportCSV && format != ExportNDJSON {
+		c.Error(service.NewValidationError("format must be csv or ndjson"))
+		return
+	}
+	from, to, err := queryTimeRange(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	filter := models.ExportFilter{
+		TeamName: c.Query("team_name"),
+		UserID:   c.Query("user_id"),
+		Status:   c.Query("status"),
+		From:     from,
+		To:       to,
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+	w := &exportWriter{c: c, name: name, format: format, header: header}
+	if err := run(ctx, filter, w); err != nil {
+		if !w.started {
+			c.Error(err)
+			return
+		}
+		slog.ErrorContext(ctx, "export interrupted", "export", name, "rows", w.rows, "error", err)
+		if err := w.fail(ctx, err); err != nil {
+			slog.WarnContext(ctx, "failed to write export error", "export", name, "error", err)
+		}
+		return
+	}
+	if err := w.finish(); err != nil {
+		slog.WarnContext(ctx, "failed to finish export", "export", name, "error", err)
+	}
+}
+
+// Запись выгрузки в ответ: заголовки отправляются с первой строкой, чтобы ошибку
+// фильтров или БД до нее можно было вернуть обычным ответом
+type exportWriter struct {
+	c       *gin.Context
+	name    string
+	format  string
+	header  []string
+	started bool
+	rows    int
+	csv     *csv.Writer
+	json    *json.Encoder
+}
+
+func (w *exportWriter) start() error {
+	w.started = true
+	if w.format == ExportCSV {
+		w.c.Header("Content-Type", "text/csv; charset=utf-8")
+		w.c.Header("Content-Disposition", `attachment; filename="`+w.name+`.csv"`)
+	} else {
+		w.c.Header("Content-Type", "application/x-ndjson")
+		w.c.Header("Content-Disposition", `attachment; filename="`+w.name+`.ndjson"`)
+	}
+	w.c.Status(http.StatusOK)
+	if w.format == ExportCSV {
+		w.csv = csv.NewWriter(w.c.Writer)
+		return w.csv.Write(w.header)
+	}
+	w.json = json.NewEncoder(w.c.Writer)
+	return nil
+}
+
+// Функция пишет строку: value - в NDJSON, record - в CSV
+func (w *exportWriter) write(value interface{}, record []string) error {
+	if !w.started {
+		if err := w.start(); err != nil {
+			return err
+		}
+	}
+	var err error
+	if w.csv != nil {
+		err = w.csv.Write(record)
+	} else {
+		err = w.json.Encode(value)
+	}
+	if err != nil {
+		return err
+	}
+	w.rows++
+	if w.rows%exportFlushRows == 0 {
+		return w.flush()
+	}
+	return nil
+}
+
+// Функция завершает выгрузку; пустая выгрузка в CSV состоит из строки заголовков
+func (w *exportWriter) finish() error {
+	if !w.started {
+		if err := w.start(); err != nil {
+			return err
+		}
+	}
+	return w.flush()
+}
+
+// Функция завершает оборванную выгрузку строкой с ошибкой: в NDJSON - объектом {"error": {"code", "message"}},
+// в CSV - строкой #error,код,сообщение
+func (w *exportWriter) fail(ctx context.Context, err error) error {
+	_, code, message := mapError(ctx, err)
+	if w.csv != nil {
+		// Строка ошибки должна остаться одной строкой файла
+		message = strings.ReplaceAll(message, "\n", " ")
+		if err := w.csv.Write([]string{ExportErrorMarker, code, message}); err != nil {
+			return err
+		}
+	} else if err := w.json.Encode(gin.H{"error": gin.H{"code": code, "message": message}}); err != nil {
+		return err
+	}
+	return w.flush()
+}
+
+func (w *exportWriter) flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+	w.c.Writer.Flush()
+	return nil
+}
+
+func formatExportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"Backend-trainee-assignment/models"
+	service "Backend-trainee-assignment/services"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type exportRow struct {
+	ID string `json:"id"`
+}
+
+// Функция выполняет выгрузку, которая пишет rows строк и завершается ошибкой err
+func runExport(format string, rows int, err error) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	h := NewExportHandler(nil, time.Minute)
+	router := gin.New()
+	router.Use(ErrorMiddleware())
+	router.GET("/export", func(c *gin.Context) {
+		h.export(c, "rows", []string{"id"}, func(ctx context.Context, f models.ExportFilter, w *exportWriter) error {
+			for i := 0; i < rows; i++ {
+				id := "r" + strconv.Itoa(i+1)
+				if err := w.write(exportRow{ID: id}, []string{id}); err != nil {
+					return err
+				}
+			}
+			return err
+		})
+	})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/export?format="+format, nil))
+	return rec
+}
+
+func TestExportErrorAfterFirstRow(t *testing.T) {
+	failure := errors.Join(errors.New("read rows"), errors.New("connection reset"))
+	tests := []struct {
+		name   string
+		format string
+		err    error
+		want   string
+	}{
+		{name: "csv complete", format: ExportCSV, want: "id\nr1\nr2\n"},
+		{name: "ndjson complete", format: ExportNDJSON, want: "{\"id\":\"r1\"}\n{\"id\":\"r2\"}\n"},
+		{
+			name: "csv interrupted", format: ExportCSV, err: failure,
+			want: "id\nr1\nr2\n#error,INTERNAL_ERROR,read rows connection reset\n",
+		},
+		{
+			name: "ndjson interrupted", format: ExportNDJSON, err: context.DeadlineExceeded,
+			want: "{\"id\":\"r1\"}\n{\"id\":\"r2\"}\n{\"error\":{\"code\":\"TIMEOUT\",\"message\":\"request deadline exceeded\"}}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := runExport(tt.format, 2, tt.err)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status %d", rec.Code)
+			}
+			if body := strings.ReplaceAll(rec.Body.String(), "\r\n", "\n"); body != tt.want {
+				t.Fatalf("body %q, want %q", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportErrorBeforeFirstRow(t *testing.T) {
+	rec := runExport(ExportNDJSON, 0, service.ErrTeamNotFound)
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), service.CodeNotFound) {
+		t.Fatalf("status %d body %s, want regular 404 error", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// Ограничение времени проверок готовности
+const readinessTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	db           *sqlx.DB
+	shuttingDown atomic.Bool
+}
+
+func NewHealthHandler(db *sqlx.DB) *HealthHandler {
+	return &HealthHandler{db: db}
+}
+
+// Функция переводит сервис в состояние остановки, /readyz начинает отвечать ошибкой
+func (h *HealthHandler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Функция проверки живости процесса
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Функция проверки готовности: сервис не останавливается, БД доступна, миграции применены
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	if h.shuttingDown.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "shutting_down",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	if err := h.db.PingContext(ctx); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "database_unavailable",
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	version, err := repository.CurrentSchemaVersion(ctx, h.db)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "migrations_unknown",
+			"error":  err.Error(),
+		})
+		return
+	}
+	if version < repository.SchemaVersion {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":          "migrations_pending",
+			"schema_version":  version,
+			"expected_schema": repository.SchemaVersion,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":         "ready",
+		"schema_version": version,
+	})
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+// Готовность при доступной БД проверяет контрактный тест server, здесь - без БД
+func TestHealthHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// Порт 1 закрыт: проверка готовности получает отказ в соединении
+	unreachable, err := sqlx.Open("postgres", "postgres://review@127.0.0.1:1/review?sslmode=disable&connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unreachable.Close()
+
+	tests := []struct {
+		name         string
+		path         string
+		db           *sqlx.DB
+		shuttingDown bool
+		wantStatus   int
+		want         string
+	}{
+		{name: "liveness", path: "/healthz", wantStatus: http.StatusOK, want: "ok"},
+		{name: "liveness during shutdown", path: "/healthz", shuttingDown: true, wantStatus: http.StatusOK, want: "ok"},
+		{name: "readiness during shutdown", path: "/readyz", shuttingDown: true, wantStatus: http.StatusServiceUnavailable, want: "shutting_down"},
+		{name: "readiness without database", path: "/readyz", db: unreachable, wantStatus: http.StatusServiceUnavailable, want: "database_unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealthHandler(tt.db)
+			if tt.shuttingDown {
+				h.SetShuttingDown()
+			}
+			router := gin.New()
+			router.GET("/healthz", h.Liveness)
+			router.GET("/readyz", h.Readiness)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			var body struct {
+				Status string `json:"status"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid body %q: %v", rec.Body.String(), err)
+			}
+			if rec.Code != tt.wantStatus || body.Status != tt.want {
+				t.Fatalf("%s: %d %s, want %d %s", tt.path, rec.Code, rec.Body.String(), tt.wantStatus, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	service "Backend-trainee-assignment/services"
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Заголовок, по которому повтор запроса получает сохраненный ответ
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Заголовок, которым помечается ответ, повторенный из хранилища
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+type idempotencyEntry struct {
+	key         string
+	fingerprint [sha256.Size]byte
+	done        bool
+	status      int
+	contentType string
+	body        []byte
+	expiresAt   time.Time
+	element     *list.Element
+}
+
+// Хранилище ответов на POST-запросы с ключом идемпотентности. Хранится в памяти процесса:
+// повтор, попавший на другую реплику или после перезапуска, выполняется заново, поэтому
+// за несколькими репликами нужна привязка клиента к реплике или общее хранилище
+type IdempotencyStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[string]*idempotencyEntry
+	// Записи в порядке истечения срока: TTL общий, срок продлевается только переносом в конец
+	expiry *list.List
+}
+
+func NewIdempotencyStore(ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]*idempotencyEntry),
+		expiry:  list.New(),
+	}
+}
+
+// Middleware, повторяющий ответ для повторного запроса с тем же ключом. Ключ привязан к методу,
+// пути и телу запроса: тот же ключ с другим запросом получает 422. Сохраняется итоговый ответ
+// с любым статусом, включая ошибки обработчика. Ключ освобождается, только если запрос
+// отклонен до побочных эффектов (ошибка валидации) или обработчик завершился паникой
+func (s *IdempotencyStore) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Error(service.NewValidationError("failed to read request body"))
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := requestFingerprint(c.Request.Method, c.Request.URL.Path, body)
+
+		entry, isNew := s.reserve(key, fingerprint)
+		if !isNew {
+			switch {
+			case entry.fingerprint != fingerprint:
+				c.Error(service.ErrIdempotencyKeyReused)
+			case !entry.done:
+				c.Error(service.ErrRequestInProgress)
+			default:
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Data(entry.status, entry.contentType, entry.body)
+			}
+			c.Abort()
+			return
+		}
+
+		// Ключ освобождается и при панике обработчика
+		completed := false
+		defer func() {
+			if !completed {
+				s.release(key)
+			}
+		}()
+
+		writer := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		if !writer.Written() {
+			if len(c.Errors) == 0 {
+				return
+			}
+			var validationErr *service.ValidationError
+			if errors.As(c.Errors.Last().Err, &validationErr) {
+				return
+			}
+			// Ошибка записывается здесь, а не в ErrorMiddleware, чтобы сохранить ее для повторов
+			writeError(c, c.Errors.Last().Err)
+		}
+		s.complete(key, writer.Status(), writer.Header().Get("Content-Type"), writer.body.Bytes())
+		completed = true
+	}
+}
+
+// Функция возвращает отпечаток запроса, с которым связывается ключ
+func requestFingerprint(method, path string, body []byte) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// Функция занимает ключ, возвращает копию существующей записи, если ключ уже использован.
+// Истекшие записи удаляются с начала очереди истечения
+func (s *IdempotencyStore) reserve(key string, fingerprint [sha256.Size]byte) (idempotencyEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.evictExpired(now)
+	if entry, ok := s.entries[key]; ok {
+		return *entry, false
+	}
+	entry := &idempotencyEntry{key: key, fingerprint: fingerprint, expiresAt: now.Add(s.ttl)}
+	entry.element = s.expiry.PushBack(entry)
+	s.entries[key] = entry
+	return *entry, true
+}
+
+func (s *IdempotencyStore) evictExpired(now time.Time) {
+	for front := s.expiry.Front(); front != nil; front = s.expiry.Front() {
+		entry := front.Value.(*idempotencyEntry)
+		if now.Before(entry.expiresAt) {
+			return
+		}
+		s.expiry.Remove(front)
+		delete(s.entries, entry.key)
+	}
+}
+
+func (s *IdempotencyStore) complete(key string, status int, contentType string, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	entry.done = true
+	entry.status = status
+	entry.contentType = contentType
+	entry.body = append([]byte(nil), body...)
+	entry.expiresAt = s.now().Add(s.ttl)
+	s.expiry.MoveToBack(entry.element)
+}
+
+func (s *IdempotencyStore) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.entries[key]; ok {
+		s.expiry.Remove(entry.element)
+		delete(s.entries, key)
+	}
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	service "Backend-trainee-assignment/services"
+	"crypto/sha256"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Функция собирает маршрутизатор с хранилищем в том же порядке middleware, что и сервер
+func idempotencyRouter(store *IdempotencyStore, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gin.Recovery(), ErrorMiddleware(), store.Middleware())
+	router.POST("/op", handler)
+	router.POST("/other", handler)
+	return router
+}
+
+func postWithKey(router http.Handler, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyReplaysFinalResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    func(c *gin.Context)
+		wantStatus int
+	}{
+		{name: "success", handler: func(c *gin.Context) { c.JSON(http.StatusCreated, gin.H{"ok": true}) }, wantStatus: http.StatusCreated},
+		{name: "domain error", handler: func(c *gin.Context) { c.Error(service.ErrPRExists) }, wantStatus: http.StatusConflict},
+		{name: "internal error", handler: func(c *gin.Context) { c.Error(http.ErrHandlerTimeout) }, wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			router := idempotencyRouter(NewIdempotencyStore(time.Hour), func(c *gin.Context) {
+				calls.Add(1)
+				tt.handler(c)
+			})
+
+			first := postWithKey(router, "/op", "k1", `{"a":1}`)
+			second := postWithKey(router, "/op", "k1", `{"a":1}`)
+			if first.Code != tt.wantStatus || second.Code != tt.wantStatus {
+				t.Fatalf("statuses %d, %d, want %d", first.Code, second.Code, tt.wantStatus)
+			}
+			if first.Body.String() != second.Body.String() {
+				t.Fatalf("replayed body %q, want %q", second.Body.String(), first.Body.String())
+			}
+			if second.Header().Get(IdempotentReplayedHeader) != "true" || first.Header().Get(IdempotentReplayedHeader) != "" {
+				t.Fatalf("replayed header: first %q, second %q",
+					first.Header().Get(IdempotentReplayedHeader), second.Header().Get(IdempotentReplayedHeader))
+			}
+			if calls.Load() != 1 {
+				t.Fatalf("handler called %d times, want 1", calls.Load())
+			}
+		})
+	}
+}
+
+func TestIdempotencyReleasesKeyBeforeSideEffects(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(c *gin.Context)
+	}{
+		{name: "validation error", handler: func(c *gin.Context) { c.Error(service.NewValidationError("bad")) }},
+		{name: "panic", handler: func(c *gin.Context) { panic("boom") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			router := idempotencyRouter(NewIdempotencyStore(time.Hour), func(c *gin.Context) {
+				if calls.Add(1) == 1 {
+					tt.handler(c)
+					return
+				}
+				c.JSON(http.StatusOK, gin.H{"ok": true})
+			})
+
+			first := postWithKey(router, "/op", "k1", `{}`)
+			if first.Code < http.StatusBadRequest {
+				t.Fatalf("first status %d, want error", first.Code)
+			}
+			second := postWithKey(router, "/op", "k1", `{}`)
+			if second.Code != http.StatusOK || second.Header().Get(IdempotentReplayedHeader) != "" {
+				t.Fatalf("second status %d replayed %q, want fresh 200", second.Code, second.Header().Get(IdempotentReplayedHeader))
+			}
+			if calls.Load() != 2 {
+				t.Fatalf("handler called %d times, want 2", calls.Load())
+			}
+		})
+	}
+}
+
+func TestIdempotencyKeyReusedWithDifferentRequest(t *testing.T) {
+	var calls atomic.Int32
+	router := idempotencyRouter(NewIdempotencyStore(time.Hour), func(c *gin.Context) {
+		calls.Add(1)
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	if rec := postWithKey(router, "/op", "k1", `{"a":1}`); rec.Code != http.StatusOK {
+		t.Fatalf("first status %d", rec.Code)
+	}
+	for _, tt := range []struct{ path, body string }{{"/op", `{"a":2}`}, {"/other", `{"a":1}`}} {
+		rec := postWithKey(router, tt.path, "k1", tt.body)
+		if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), service.CodeKeyReused) {
+			t.Fatalf("%s %s: status %d body %s, want 422 %s", tt.path, tt.body, rec.Code, rec.Body.String(), service.CodeKeyReused)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("handler called %d times, want 1", calls.Load())
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	router := idempotencyRouter(NewIdempotencyStore(time.Hour), func(c *gin.Context) {
+		close(started)
+		<-finish
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- postWithKey(router, "/op", "k1", `{}`) }()
+	<-started
+	rec := postWithKey(router, "/op", "k1", `{}`)
+	close(finish)
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), service.CodeInProgress) {
+		t.Fatalf("concurrent status %d body %s, want 409 %s", rec.Code, rec.Body.String(), service.CodeInProgress)
+	}
+	if first := <-done; first.Code != http.StatusOK {
+		t.Fatalf("first status %d", first.Code)
+	}
+}
+
+func TestIdempotencyWithoutKey(t *testing.T) {
+	var calls atomic.Int32
+	router := idempotencyRouter(NewIdempotencyStore(time.Hour), func(c *gin.Context) {
+		calls.Add(1)
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+	postWithKey(router, "/op", "", `{}`)
+	postWithKey(router, "/op", "", `{}`)
+	if calls.Load() != 2 {
+		t.Fatalf("handler called %d times, want 2", calls.Load())
+	}
+}
+
+func TestIdempotencyStoreEvictsInExpiryOrder(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewIdempotencyStore(time.Minute)
+	store.now = func() time.Time { return now }
+	var fp [sha256.Size]byte
+
+	store.reserve("a", fp)
+	now = now.Add(10 * time.Second)
+	store.reserve("b", fp)
+	now = now.Add(20 * time.Second)
+	// Завершение продлевает срок записи "a" и переносит ее в конец очереди
+	store.complete("a", http.StatusOK, "application/json", nil)
+
+	now = now.Add(45 * time.Second)
+	store.reserve("c", fp)
+	if _, ok := store.entries["b"]; ok {
+		t.Fatal("expired entry b was not evicted")
+	}
+	if _, ok := store.entries["a"]; !ok {
+		t.Fatal("entry a evicted before its extended expiry")
+	}
+
+	now = now.Add(time.Minute)
+	store.reserve("d", fp)
+	if len(store.entries) != 1 || store.expiry.Len() != 1 {
+		t.Fatalf("entries %d, queue %d, want only d", len(store.entries), store.expiry.Len())
+	}
+	if entry, isNew := store.reserve("d", fp); isNew || entry.key != "d" {
+		t.Fatalf("reserve d again: new %v", isNew)
+	}
+}
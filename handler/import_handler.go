@@ -0,0 +1,42 @@
+package handler
+
+import (
+	service "Backend-trainee-assignment/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImportHandler struct {
+	importService *service.ImportService
+}
+
+func NewImportHandler(importService *service.ImportService) *ImportHandler {
+	return &ImportHandler{importService: importService}
+}
+
+// Функция импортирует оргструктуру из CSV или YAML; с dry_run возвращает план без изменений
+func (h *ImportHandler) ImportOrgChart(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req struct {
+		Format  string `json:"format" binding:"required"`
+		Content string `json:"content" binding:"required"`
+		DryRun  bool   `json:"dry_run"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+
+	chart, err := service.ParseOrgChart(req.Format, []byte(req.Content))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	plan, err := h.importService.Import(ctx, chart, req.DryRun)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	service "Backend-trainee-assignment/services"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware, ограничивающий время обработки запроса, включая запросы к БД
+func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// Middleware, превращающий ошибки обработчиков (c.Error) в ответ вида {"error": {"code", "message"}}
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		writeError(c, c.Errors.Last().Err)
+	}
+}
+
+// Функция записывает ответ с ошибкой в едином формате
+func writeError(c *gin.Context, err error) {
+	status, code, message := mapError(c.Request.Context(), err)
+	if status == http.StatusInternalServerError {
+		slog.ErrorContext(c.Request.Context(), "request failed", "path", c.FullPath(), "error", err)
+	}
+	c.JSON(status, gin.H{
+		"error": map[string]interface{}{
+			"code":    code,
+			"message": message,
+		},
+	})
+}
+
+// Функция сопоставляет ошибку с HTTP-статусом и кодом ответа
+func mapError(ctx context.Context, err error) (int, string, string) {
+	var domainErr *service.Error
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &domainErr):
+		return domainErr.Status, domainErr.Code, err.Error()
+	case errors.As(err, &validationErr):
+		return http.StatusBadRequest, service.CodeValidation, err.Error()
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, service.CodeTimeout, "request deadline exceeded"
+	default:
+		return http.StatusInternalServerError, service.CodeInternal, err.Error()
+	}
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	service "Backend-trainee-assignment/services"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Функция выполняет запрос и возвращает статус, код и сообщение ошибки ответа
+func serveError(t *testing.T, router *gin.Engine) (int, string, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return decodeError(t, rec)
+}
+
+// Функция возвращает статус, код и сообщение ошибки из ответа, для 200 код и сообщение пустые
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) (int, string, string) {
+	t.Helper()
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if rec.Code != http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("invalid error body %q: %v", rec.Body.String(), err)
+		}
+	}
+	return rec.Code, body.Error.Code, body.Error.Message
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		handler    gin.HandlerFunc
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "finished in time",
+			handler:    func(c *gin.Context) { c.Status(http.StatusOK) },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "deadline error from query",
+			handler: func(c *gin.Context) {
+				<-c.Request.Context().Done()
+				c.Error(fmt.Errorf("query: %w", c.Request.Context().Err()))
+			},
+			wantStatus: http.StatusGatewayTimeout,
+			wantCode:   "TIMEOUT",
+		},
+		{
+			// Драйвер БД при отмене возвращает свою ошибку, истекший срок берется из контекста запроса
+			name: "driver error after deadline",
+			handler: func(c *gin.Context) {
+				<-c.Request.Context().Done()
+				c.Error(errors.New("pq: canceling statement due to user request"))
+			},
+			wantStatus: http.StatusGatewayTimeout,
+			wantCode:   "TIMEOUT",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(ErrorMiddleware(), TimeoutMiddleware(20*time.Millisecond))
+			router.GET("/", func(c *gin.Context) {
+				if _, ok := c.Request.Context().Deadline(); !ok {
+					t.Error("request context has no deadline")
+				}
+			}, tt.handler)
+
+			status, code, _ := serveError(t, router)
+			if status != tt.wantStatus || code != tt.wantCode {
+				t.Fatalf("status %d, code %q, want %d, %q", status, code, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestErrorMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	type errorCase struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+	}
+	var tests []errorCase
+	for _, err := range []*service.Error{
+		service.ErrPRNotFound, service.ErrUserNotFound, service.ErrAuthorNotFound, service.ErrReviewerNotFound,
+		service.ErrTeamNotFound, service.ErrRepositoryNotFound, service.ErrWebhookNotFound,
+		service.ErrTeamExists, service.ErrUserExists, service.ErrPRExists, service.ErrRepositoryExists,
+		service.ErrRepositoryNameExists, service.ErrPRMerged, service.ErrReviewerNotAssigned, service.ErrNoCandidate,
+		service.ErrNoTargetTeam, service.ErrAlreadyMember, service.ErrNotMember, service.ErrAddToMergedPR,
+		service.ErrMaxReviewers, service.ErrUserInactive, service.ErrAuthorSelfReview, service.ErrAlreadyAssigned,
+		service.ErrRequestInProgress, service.ErrIdempotencyKeyReused,
+	} {
+		tests = append(tests, errorCase{name: err.Code + " " + err.Message, err: err,
+			wantStatus: err.Status, wantCode: err.Code, wantMessage: err.Message})
+	}
+	tests = append(tests,
+		errorCase{name: "PR_MERGED on manual add", err: service.ErrAddToMergedPR,
+			wantStatus: http.StatusBadRequest, wantCode: service.CodePRMerged, wantMessage: "cannot add reviewers to merged PR"},
+		errorCase{name: "PR_MERGED on reassignment", err: service.ErrPRMerged,
+			wantStatus: http.StatusConflict, wantCode: service.CodePRMerged, wantMessage: "cannot change reviewers of merged PR"},
+		errorCase{name: "wrapped domain error", err: fmt.Errorf("assign: %w", service.ErrNoCandidate),
+			wantStatus: http.StatusConflict, wantCode: service.CodeNoCandidate, wantMessage: "assign: no active replacement candidate"},
+		errorCase{name: "validation", err: service.NewValidationError("team_name is required"),
+			wantStatus: http.StatusBadRequest, wantCode: service.CodeValidation, wantMessage: "team_name is required"},
+		errorCase{name: "deadline", err: fmt.Errorf("query: %w", context.DeadlineExceeded),
+			wantStatus: http.StatusGatewayTimeout, wantCode: service.CodeTimeout, wantMessage: "request deadline exceeded"},
+		errorCase{name: "unknown", err: errors.New("connection reset"),
+			wantStatus: http.StatusInternalServerError, wantCode: service.CodeInternal, wantMessage: "connection reset"},
+	)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(ErrorMiddleware())
+			router.GET("/", func(c *gin.Context) { c.Error(tt.err) })
+
+			status, code, message := serveError(t, router)
+			if status != tt.wantStatus || code != tt.wantCode || message != tt.wantMessage {
+				t.Fatalf("%v: %d %s %q, want %d %s %q", tt.err, status, code, message, tt.wantStatus, tt.wantCode, tt.wantMessage)
+			}
+		})
+	}
+
+	t.Run("written response kept", func(t *testing.T) {
+		router := gin.New()
+		router.Use(ErrorMiddleware())
+		router.GET("/", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{})
+			c.Error(service.ErrPRNotFound)
+		})
+		if status, _, _ := serveError(t, router); status != http.StatusOK {
+			t.Fatalf("status %d, want the written 200", status)
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"Backend-trainee-assignment/models"
+	service "Backend-trainee-assignment/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+}
+
+func NewNotificationHandler(notificationService *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// Функция возвращает настройки почтовых уведомлений пользователя
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.Query("user_id")
+	if userID == "" {
+		c.Error(service.NewValidationError("user_id is required"))
+		return
+	}
+
+	prefs, err := h.notificationService.GetPreferences(ctx, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+// Функция заменяет адрес и отказы от писем пользователя
+func (h *NotificationHandler) SetPreferences(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req struct {
+		UserID      string   `json:"user_id" binding:"required"`
+		Email       string   `json:"email"`
+		EmailOptOut []string `json:"email_opt_out"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+
+	prefs, err := h.notificationService.SetPreferences(ctx, models.NotificationPreferences{
+		UserID:      req.UserID,
+		Email:       req.Email,
+		EmailOptOut: req.EmailOptOut,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"Backend-trainee-assignment/api"
+	service "Backend-trainee-assignment/services"
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gin-gonic/gin"
+)
+
+type OpenAPIHandler struct {
+	doc *openapi3.T
+}
+
+func NewOpenAPIHandler(doc *openapi3.T) *OpenAPIHandler {
+	return &OpenAPIHandler{doc: doc}
+}
+
+// Функция отдает спецификацию OpenAPI
+func (h *OpenAPIHandler) Spec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", api.Spec)
+}
+
+// Функция отдает страницу Swagger UI
+func (h *OpenAPIHandler) SwaggerUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", api.SwaggerUI)
+}
+
+// Middleware, проверяющий запросы по спецификации, некорректные запросы получают VALIDATION_ERROR
+func (h *OpenAPIHandler) RequestValidationMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		input, ok := h.requestInput(c)
+		if !ok {
+			c.Next()
+			return
+		}
+		if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+			c.Error(service.NewValidationError(validationMessage(err)))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// Middleware, сверяющий ответы обработчиков со спецификацией и пишущий расхождения в лог
+func (h *OpenAPIHandler) ResponseValidationMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		input, ok := h.requestInput(c)
+		if !ok {
+			c.Next()
+			return
+		}
+		writer := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		err := openapi3filter.ValidateResponse(c.Request.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 writer.Status(),
+			Header:                 writer.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(writer.body.Bytes())),
+		})
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "response does not match openapi spec",
+				"method", c.Request.Method, "path", c.FullPath(), "status", writer.Status(), "error", err)
+		}
+	}
+}
+
+// Функция находит операцию спецификации по маршруту gin
+func (h *OpenAPIHandler) requestInput(c *gin.Context) (*openapi3filter.RequestValidationInput, bool) {
+	specPath := specPath(c.FullPath())
+	pathItem := h.doc.Paths.Value(specPath)
+	if pathItem == nil {
+		return nil, false
+	}
+	operation := pathItem.GetOperation(c.Request.Method)
+	if operation == nil {
+		return nil, false
+	}
+	params := make(map[string]string, len(c.Params))
+	for _, p := range c.Params {
+		params[p.Key] = p.Value
+	}
+	return &openapi3filter.RequestValidationInput{
+		Request:    c.Request,
+		PathParams: params,
+		Route: &routers.Route{
+			Spec:      h.doc,
+			Path:      specPath,
+			PathItem:  pathItem,
+			Method:    c.Request.Method,
+			Operation: operation,
+		},
+		Options: &openapi3filter.Options{MultiError: false},
+	}, true
+}
+
+// Функция переводит путь gin (/a/:id) в путь спецификации (/a/{id})
+func specPath(fullPath string) string {
+	parts := strings.Split(fullPath, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") {
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// Функция возвращает краткое описание ошибки проверки без схемы и значения
+func validationMessage(err error) string {
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		field := strings.Join(schemaErr.JSONPointer(), ".")
+		if field == "" {
+			return "invalid request body: " + schemaErr.Reason
+		}
+		return "invalid field " + field + ": " + schemaErr.Reason
+	}
+	var reqErr *openapi3filter.RequestError
+	if errors.As(err, &reqErr) && reqErr.Parameter != nil {
+		return "invalid parameter " + reqErr.Parameter.Name + ": " + reqErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// Обертка над ResponseWriter, сохраняющая тело ответа для проверки
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
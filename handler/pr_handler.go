@@ -2,10 +2,12 @@ package handler
 
 import (
 	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/events"
+	"Backend-trainee-assignment/metrics"
 	"Backend-trainee-assignment/models"
 	service "Backend-trainee-assignment/services"
+	"context"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,229 +15,115 @@ import (
 type PRHandler struct {
 	prRepo          *repository.PRRepository
 	userRepo        *repository.UserRepository
+	prService       *service.PRService
 	reviewerService *service.ReviewerService
+	bus             *events.Bus
 }
 
-func NewPRHandler(prRepo *repository.PRRepository, userRepo *repository.UserRepository, reviewerService *service.ReviewerService) *PRHandler {
+func NewPRHandler(prRepo *repository.PRRepository, userRepo *repository.UserRepository, prService *service.PRService, reviewerService *service.ReviewerService, bus *events.Bus) *PRHandler {
 	return &PRHandler{
 		prRepo:          prRepo,
 		userRepo:        userRepo,
+		prService:       prService,
 		reviewerService: reviewerService,
+		bus:             bus,
 	}
 }
 
 // Функция создает новый PR
 func (h *PRHandler) CreatePR(c *gin.Context) {
+	ctx := c.Request.Context()
 	var req struct {
-		PullRequestID   string `json:"pull_request_id" binding:"required"`
-		PullRequestName string `json:"pull_request_name" binding:"required"`
-		AuthorID        string `json:"author_id" binding:"required"`
+		PullRequestID   string   `json:"pull_request_id" binding:"required"`
+		PullRequestName string   `json:"pull_request_name" binding:"required"`
+		AuthorID        string   `json:"author_id" binding:"required"`
+		TeamName        string   `json:"team_name"`
+		RepositoryID    string   `json:"repository_id"`
+		Labels          []string `json:"labels"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": map[string]interface{}{
-				"code":    "VALIDATION_ERROR",
-				"message": err.Error(),
-			},
-		})
+		c.Error(service.NewValidationError(err.Error()))
 		return
 	}
 
-	// Проверка что PR не существует
-	exists, err := h.prRepo.PRExists(req.PullRequestID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
-		return
-	}
-	if exists {
-		c.JSON(http.StatusConflict, gin.H{
-			"error": map[string]interface{}{
-				"code":    "PR_EXISTS",
-				"message": "PR id already exists",
-			},
-		})
-		return
-	}
-
-	// Проверка, что автор существует
-	author, err := h.userRepo.GetUserByID(req.AuthorID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
-		return
-	}
-	if author == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": map[string]interface{}{
-				"code":    "NOT_FOUND",
-				"message": "author not found",
-			},
-		})
-		return
-	}
-
-	// Создание PR
-	pr := &models.PullRequest{
+	pr, warning, err := h.prService.CreatePR(ctx, service.CreatePRInput{
 		PullRequestID:   req.PullRequestID,
 		PullRequestName: req.PullRequestName,
 		AuthorID:        req.AuthorID,
-		Status:          "OPEN",
-		CreatedAt:       time.Now(),
-	}
-	if err := h.prRepo.CreatePR(pr); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
+		TeamName:        req.TeamName,
+		RepositoryID:    req.RepositoryID,
+		Labels:          req.Labels,
+	})
+	if err != nil {
+		c.Error(err)
 		return
 	}
-
-	// Автоматическое назначение ревьюеров
-	if err := h.reviewerService.AssignReviewers(pr); err != nil {
-		reviewers, _ := h.prRepo.GetPRReviewers(pr.PullRequestID)
-		pr.AssignedReviewers = reviewers
+	if warning != "" {
 		c.JSON(http.StatusCreated, gin.H{
 			"pr":      pr,
-			"warning": "PR created but reviewers assignment failed: " + err.Error(),
+			"warning": warning,
 		})
 		return
 	}
+	c.JSON(http.StatusCreated, gin.H{"pr": pr})
+}
 
-	prWithReviewers, reviewers, err := h.prRepo.GetPRWithReviewers(pr.PullRequestID)
+// Функция возвращает PR с назначенными ревьюерами
+func (h *PRHandler) GetPR(c *gin.Context) {
+	ctx := c.Request.Context()
+	prID := c.Query("pull_request_id")
+	if prID == "" {
+		c.Error(service.NewValidationError("pull_request_id is required"))
+		return
+	}
+	pr, err := h.prService.GetPR(ctx, prID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
+		c.Error(err)
 		return
 	}
-
-	prWithReviewers.AssignedReviewers = reviewers
-	c.JSON(http.StatusCreated, gin.H{"pr": prWithReviewers})
+	c.JSON(http.StatusOK, gin.H{"pr": pr})
 }
 
 // Функция мержит PR
 func (h *PRHandler) MergePR(c *gin.Context) {
+	ctx := c.Request.Context()
 	var req struct {
 		PullRequestID string `json:"pull_request_id" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": map[string]interface{}{
-				"code":    "VALIDATION_ERROR",
-				"message": err.Error(),
-			},
-		})
+		c.Error(service.NewValidationError(err.Error()))
 		return
 	}
 
-	pr, reviewers, err := h.prRepo.GetPRWithReviewers(req.PullRequestID)
+	pr, err := h.prService.MergePR(ctx, req.PullRequestID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
-		return
-	}
-	if pr == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": map[string]interface{}{
-				"code":    "NOT_FOUND",
-				"message": "PR not found",
-			},
-		})
+		c.Error(err)
 		return
 	}
-	// Проверка ситуации второго мерджа
-	if pr.Status == "MERGED" {
-		pr.AssignedReviewers = reviewers
-		c.JSON(http.StatusOK, gin.H{"pr": pr})
-		return
-	}
-	mergedAt := time.Now()
-	if err := h.prRepo.UpdatePRStatus(req.PullRequestID, "MERGED", &mergedAt); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
-		return
-	}
-
-	mergedPR, reviewers, _ := h.prRepo.GetPRWithReviewers(req.PullRequestID)
-	mergedPR.AssignedReviewers = reviewers
-	mergedPR.MergedAt = &mergedAt
-	c.JSON(http.StatusOK, gin.H{"pr": mergedPR})
+	c.JSON(http.StatusOK, gin.H{"pr": pr})
 }
 
 // Функция переназначает ревьюера
 func (h *PRHandler) ReassignReviewer(c *gin.Context) {
+	ctx := c.Request.Context()
 	var req struct {
 		PullRequestID string `json:"pull_request_id" binding:"required"`
 		OldUserID     string `json:"old_user_id" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": map[string]interface{}{
-				"code":    "VALIDATION_ERROR",
-				"message": err.Error(),
-			},
-		})
+		c.Error(service.NewValidationError(err.Error()))
 		return
 	}
 
-	newReviewerID, err := h.reviewerService.ReassignReviewer(req.PullRequestID, req.OldUserID)
+	newReviewerID, err := h.reviewerService.ReassignReviewer(ctx, req.PullRequestID, req.OldUserID)
 	if err != nil {
-		errorResponse := gin.H{
-			"error": map[string]interface{}{
-				"message": err.Error(),
-			},
-		}
-
-		switch err.Error() {
-		case "PR не найдено":
-			errorResponse["error"].(map[string]interface{})["code"] = "NOT_FOUND"
-			c.JSON(http.StatusNotFound, errorResponse)
-		case "статус PR MERGED":
-			errorResponse["error"].(map[string]interface{})["code"] = "PR_MERGED"
-			c.JSON(http.StatusConflict, errorResponse)
-		case "ревьюер не относится к данному PR":
-			errorResponse["error"].(map[string]interface{})["code"] = "NOT_ASSIGNED"
-			c.JSON(http.StatusConflict, errorResponse)
-		case "нет доступных ревьюеров":
-			errorResponse["error"].(map[string]interface{})["code"] = "NO_CANDIDATE"
-			c.JSON(http.StatusConflict, errorResponse)
-		default:
-			errorResponse["error"].(map[string]interface{})["code"] = "INTERNAL_ERROR"
-			c.JSON(http.StatusInternalServerError, errorResponse)
-		}
+		c.Error(err)
 		return
 	}
 
-	pr, reviewers, err := h.prRepo.GetPRWithReviewers(req.PullRequestID)
+	pr, reviewers, err := h.prRepo.GetPRWithReviewers(ctx, req.PullRequestID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
+		c.Error(err)
 		return
 	}
 
@@ -248,126 +136,170 @@ func (h *PRHandler) ReassignReviewer(c *gin.Context) {
 
 // Функция добавляет ревьюера к PR
 func (h *PRHandler) AddReviewer(c *gin.Context) {
+	ctx := c.Request.Context()
 	prID := c.Param("id")
 	var req struct {
 		ReviewerID string `json:"reviewer_id" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": map[string]interface{}{
-				"code":    "VALIDATION_ERROR",
-				"message": err.Error(),
-			},
-		})
+		c.Error(service.NewValidationError(err.Error()))
 		return
 	}
 
-	// Проверка, что PR существует и не замержен
-	pr, reviewers, err := h.prRepo.GetPRWithReviewers(prID)
+	// Проверка, что PR существует и не замержен. PR блокируется до добавления,
+	// чтобы фоновое дополнение не превысило число ревьюеров
+	locked, err := h.prRepo.LockOpenPR(ctx, prID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
-		return
-	}
-	if pr == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": map[string]interface{}{
-				"code":    "NOT_FOUND",
-				"message": "PR not found",
-			},
-		})
+		c.Error(err)
 		return
 	}
-	if pr.Status == "MERGED" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": map[string]interface{}{
-				"code":    "PR_MERGED",
-				"message": "cannot add reviewers to merged PR",
-			},
-		})
+	if locked == nil {
+		exists, err := h.prRepo.PRExists(ctx, prID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if !exists {
+			c.Error(service.ErrPRNotFound)
+			return
+		}
+		c.Error(service.ErrAddToMergedPR)
 		return
 	}
+	defer locked.Release()
+	pr, reviewers := locked.PR, locked.PR.AssignedReviewers
 
 	// Проверка, что ревьюеров не больше 2
 	if len(reviewers) >= 2 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": map[string]interface{}{
-				"code":    "MAX_REVIEWERS",
-				"message": "PR already has maximum reviewers (2)",
-			},
-		})
+		c.Error(service.ErrMaxReviewers)
 		return
 	}
 
 	// Проверка пользователя
-	reviewer, err := h.userRepo.GetUserByID(req.ReviewerID)
+	reviewer, err := h.userRepo.GetUserByID(ctx, req.ReviewerID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
+		c.Error(err)
 		return
 	}
 	if reviewer == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": map[string]interface{}{
-				"code":    "NOT_FOUND",
-				"message": "reviewer not found",
-			},
-		})
+		c.Error(service.ErrReviewerNotFound)
 		return
 	}
 	if !reviewer.IsActive {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": map[string]interface{}{
-				"code":    "USER_INACTIVE",
-				"message": "cannot assign inactive user as reviewer",
-			},
-		})
+		c.Error(service.ErrUserInactive)
 		return
 	}
 	if reviewer.UserID == pr.AuthorID {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": map[string]interface{}{
-				"code":    "AUTHOR_SELF_REVIEW",
-				"message": "cannot assign author as reviewer",
-			},
-		})
+		c.Error(service.ErrAuthorSelfReview)
 		return
 	}
 	for _, existingReviewer := range reviewers {
 		if existingReviewer == req.ReviewerID {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": map[string]interface{}{
-					"code":    "ALREADY_ASSIGNED",
-					"message": "user is already assigned as reviewer",
-				},
-			})
+			c.Error(service.ErrAlreadyAssigned)
 			return
 		}
 	}
 
 	// Добавка ревьюера
-	if err := h.prRepo.AddPRReviewer(prID, req.ReviewerID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
+	if err := locked.AddReviewers(ctx, []string{req.ReviewerID}); err != nil {
+		c.Error(err)
 		return
 	}
+	if err := locked.Commit(); err != nil {
+		c.Error(err)
+		return
+	}
+	metrics.ReviewersAssigned.Inc()
+	h.bus.Publish(events.Event{
+		Type:      events.ReviewersAssigned,
+		PRID:      prID,
+		UserID:    pr.AuthorID,
+		TeamName:  pr.TeamName,
+		Reviewers: []string{req.ReviewerID},
+		Reason:    metrics.ReasonManual,
+	})
 
-	updatedPR, updatedReviewers, _ := h.prRepo.GetPRWithReviewers(prID)
+	updatedPR, updatedReviewers, _ := h.prRepo.GetPRWithReviewers(ctx, prID)
 	updatedPR.AssignedReviewers = updatedReviewers
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Reviewer added successfully",
 		"pr":      updatedPR,
 	})
 }
+
+type labelsRequest struct {
+	PullRequestID string   `json:"pull_request_id" binding:"required"`
+	Labels        []string `json:"labels" binding:"required"`
+}
+
+// Функция возвращает метки PR
+func (h *PRHandler) GetLabels(c *gin.Context) {
+	prID := c.Query("pull_request_id")
+	if prID == "" {
+		c.Error(service.NewValidationError("pull_request_id is required"))
+		return
+	}
+	pr, ok := h.requirePR(c, prID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"pull_request_id": pr.PullRequestID,
+		"labels":          nonNil(pr.Labels),
+	})
+}
+
+// Функция добавляет метки PR
+func (h *PRHandler) AddLabels(c *gin.Context) {
+	h.updateLabels(c, h.prRepo.AddPRLabels)
+}
+
+// Функция удаляет метки PR
+func (h *PRHandler) RemoveLabels(c *gin.Context) {
+	h.updateLabels(c, h.prRepo.RemovePRLabels)
+}
+
+// Функция заменяет метки PR
+func (h *PRHandler) SetLabels(c *gin.Context) {
+	h.updateLabels(c, h.prRepo.SetPRLabels)
+}
+
+func (h *PRHandler) updateLabels(c *gin.Context, update func(ctx context.Context, prID string, labels []string) error) {
+	ctx := c.Request.Context()
+	var req labelsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+	if _, ok := h.requirePR(c, req.PullRequestID); !ok {
+		return
+	}
+	if err := update(ctx, req.PullRequestID, req.Labels); err != nil {
+		c.Error(err)
+		return
+	}
+
+	pr, ok := h.requirePR(c, req.PullRequestID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"pull_request_id": pr.PullRequestID,
+		"labels":          nonNil(pr.Labels),
+	})
+}
+
+// Функция возвращает PR, ошибку передает в c.Error
+func (h *PRHandler) requirePR(c *gin.Context, prID string) (*models.PullRequest, bool) {
+	ctx := c.Request.Context()
+	pr, err := h.prRepo.GetPRByID(ctx, prID)
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+	if pr == nil {
+		c.Error(service.ErrPRNotFound)
+		return nil, false
+	}
+	return pr, true
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	service "Backend-trainee-assignment/services"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Найденный PR отдается контрактным тестом server, здесь - проверка запроса без БД
+func TestGetPRRequiresID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorMiddleware())
+	router.GET("/", (&PRHandler{}).GetPR)
+
+	status, code, message := serveError(t, router)
+	if status != http.StatusBadRequest || code != service.CodeValidation || message != "pull_request_id is required" {
+		t.Fatalf("%d %s %q, want 400 validation error", status, code, message)
+	}
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"Backend-trainee-assignment/models"
+	"Backend-trainee-assignment/notify"
+	service "Backend-trainee-assignment/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReminderHandler struct {
+	reminderService *service.ReminderService
+}
+
+func NewReminderHandler(reminderService *service.ReminderService) *ReminderHandler {
+	return &ReminderHandler{reminderService: reminderService}
+}
+
+// Функция возвращает расписания напоминаний команды
+func (h *ReminderHandler) GetReminders(c *gin.Context) {
+	ctx := c.Request.Context()
+	teamName := c.Query("team_name")
+	if teamName == "" {
+		c.Error(service.NewValidationError("team_name is required"))
+		return
+	}
+
+	reminders, err := h.reminderService.GetTeamReminders(ctx, teamName)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, reminders)
+}
+
+// Функция задает расписания напоминаний команды
+func (h *ReminderHandler) SetReminders(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req struct {
+		TeamName             string `json:"team_name" binding:"required"`
+		DigestSchedule       string `json:"digest_schedule"`
+		EscalationSchedule   string `json:"escalation_schedule"`
+		EscalationAfterHours int    `json:"escalation_after_hours"`
+		Timezone             string `json:"timezone"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+
+	reminders, err := h.reminderService.SetTeamReminders(ctx, models.TeamReminders{
+		TeamName:             req.TeamName,
+		DigestSchedule:       req.DigestSchedule,
+		EscalationSchedule:   req.EscalationSchedule,
+		EscalationAfterHours: req.EscalationAfterHours,
+		Timezone:             req.Timezone,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, reminders)
+}
+
+// Функция отправляет напоминание команде вне расписания
+func (h *ReminderHandler) RunReminders(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req struct {
+		TeamName string `json:"team_name" binding:"required"`
+		Kind     string `json:"kind" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+
+	reminders, err := h.reminderService.GetTeamReminders(ctx, req.TeamName)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var sent int
+	switch req.Kind {
+	case notify.KindDigest:
+		sent, err = h.reminderService.SendDigests(ctx, req.TeamName)
+	case notify.KindEscalation:
+		sent, err = h.reminderService.SendEscalation(ctx, req.TeamName, reminders.EscalationAfterHours, time.Now())
+	default:
+		c.Error(service.NewValidationError("kind must be digest or escalation"))
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"team_name": req.TeamName, "kind": req.Kind, "sent": sent})
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/models"
+	service "Backend-trainee-assignment/services"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RepositoryHandler struct {
+	repoRepo *repository.RepositoryRepository
+	teamRepo *repository.TeamRepository
+	userRepo *repository.UserRepository
+}
+
+func NewRepositoryHandler(repoRepo *repository.RepositoryRepository, teamRepo *repository.TeamRepository, userRepo *repository.UserRepository) *RepositoryHandler {
+	return &RepositoryHandler{
+		repoRepo: repoRepo,
+		teamRepo: teamRepo,
+		userRepo: userRepo,
+	}
+}
+
+type repositoryRequest struct {
+	RepositoryID      string   `json:"repository_id" binding:"required"`
+	Name              string   `json:"name" binding:"required"`
+	Teams             []string `json:"teams"`
+	ExtraReviewers    []string `json:"extra_reviewers"`
+	ExcludedReviewers []string `json:"excluded_reviewers"`
+}
+
+// Функция создает репозиторий с пулом ревьюеров
+func (h *RepositoryHandler) AddRepository(c *gin.Context) {
+	ctx := c.Request.Context()
+	repo, ok := h.bindRepository(c)
+	if !ok {
+		return
+	}
+	exists, err := h.repoRepo.RepositoryExists(ctx, repo.RepositoryID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if exists {
+		c.Error(service.ErrRepositoryExists)
+		return
+	}
+
+	if err := h.repoRepo.CreateRepository(ctx, repo); err != nil {
+		c.Error(repositoryConflict(err))
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"repository": repo})
+}
+
+// Функция заменяет пул ревьюеров репозитория
+func (h *RepositoryHandler) UpdateRepository(c *gin.Context) {
+	ctx := c.Request.Context()
+	repo, ok := h.bindRepository(c)
+	if !ok {
+		return
+	}
+	exists, err := h.repoRepo.RepositoryExists(ctx, repo.RepositoryID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !exists {
+		c.Error(service.ErrRepositoryNotFound)
+		return
+	}
+
+	if err := h.repoRepo.UpdateRepository(ctx, repo); err != nil {
+		c.Error(repositoryConflict(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"repository": repo})
+}
+
+// Функция возвращает репозиторий с пулом ревьюеров
+func (h *RepositoryHandler) GetRepository(c *gin.Context) {
+	ctx := c.Request.Context()
+	repositoryID := c.Query("repository_id")
+	if repositoryID == "" {
+		c.Error(service.NewValidationError("repository_id is required"))
+		return
+	}
+	repo, err := h.repoRepo.GetRepositoryByID(ctx, repositoryID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if repo == nil {
+		c.Error(service.ErrRepositoryNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, repo)
+}
+
+// Функция разбирает запрос и проверяет, что команды и пользователи пула существуют
+func (h *RepositoryHandler) bindRepository(c *gin.Context) (*models.Repository, bool) {
+	ctx := c.Request.Context()
+	var req repositoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return nil, false
+	}
+
+	for _, teamName := range req.Teams {
+		exists, err := h.teamRepo.TeamExists(ctx, teamName)
+		if err != nil {
+			c.Error(err)
+			return nil, false
+		}
+		if !exists {
+			c.Error(fmt.Errorf("team %s: %w", teamName, service.ErrTeamNotFound))
+			return nil, false
+		}
+	}
+	for _, userID := range append(append([]string{}, req.ExtraReviewers...), req.ExcludedReviewers...) {
+		user, err := h.userRepo.GetUserByID(ctx, userID)
+		if err != nil {
+			c.Error(err)
+			return nil, false
+		}
+		if user == nil {
+			c.Error(fmt.Errorf("user %s: %w", userID, service.ErrUserNotFound))
+			return nil, false
+		}
+	}
+
+	repo := &models.Repository{
+		RepositoryID:      req.RepositoryID,
+		Name:              req.Name,
+		Teams:             nonNil(req.Teams),
+		ExtraReviewers:    nonNil(req.ExtraReviewers),
+		ExcludedReviewers: nonNil(req.ExcludedReviewers),
+	}
+	return repo, true
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// Функция переводит нарушение уникальности в 409: проверка существования не защищает
+// от параллельного создания того же repository_id и от занятого названия
+func repositoryConflict(err error) error {
+	constraint, ok := repository.UniqueViolation(err)
+	switch {
+	case !ok:
+		return err
+	case constraint == "repositories_name_key":
+		return service.ErrRepositoryNameExists
+	default:
+		return service.ErrRepositoryExists
+	}
+}
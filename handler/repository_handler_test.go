@@ -0,0 +1,36 @@
+package handler
+
+import (
+	service "Backend-trainee-assignment/services"
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestRepositoryConflict(t *testing.T) {
+	other := errors.New("connection reset")
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "primary key", err: &pq.Error{Code: "23505", Constraint: "repositories_pkey"}, want: service.ErrRepositoryExists},
+		{name: "name", err: &pq.Error{Code: "23505", Constraint: "repositories_name_key"}, want: service.ErrRepositoryNameExists},
+		{name: "wrapped", err: errors.Join(errors.New("insert"), &pq.Error{Code: "23505"}), want: service.ErrRepositoryExists},
+		{name: "foreign key", err: &pq.Error{Code: "23503"}, want: nil},
+		{name: "not pq", err: other, want: other},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := repositoryConflict(tt.err)
+			want := tt.want
+			if want == nil {
+				want = tt.err
+			}
+			if got != want {
+				t.Fatalf("repositoryConflict(%v) = %v, want %v", tt.err, got, want)
+			}
+		})
+	}
+}
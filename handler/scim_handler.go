@@ -0,0 +1,678 @@
+package handler
+
+import (
+	"Backend-trainee-assignment/models"
+	service "Backend-trainee-assignment/services"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Схемы SCIM 2.0 (RFC 7643, RFC 7644)
+const (
+	scimUserSchema       = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimGroupSchema      = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimListSchema       = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimErrorSchema      = "urn:ietf:params:scim:api:messages:2.0:Error"
+	scimSPConfigSchema   = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	scimContentType      = "application/scim+json"
+	scimDefaultPageCount = 100
+)
+
+type ScimHandler struct {
+	scimService *service.ScimService
+}
+
+func NewScimHandler(scimService *service.ScimService) *ScimHandler {
+	return &ScimHandler{scimService: scimService}
+}
+
+type scimMeta struct {
+	ResourceType string `json:"resourceType"`
+	Location     string `json:"location"`
+}
+
+type scimName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type scimRef struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+// Пользователь SCIM: id и userName - это user_id, displayName - username
+type scimUser struct {
+	Schemas     []string  `json:"schemas"`
+	ID          string    `json:"id"`
+	UserName    string    `json:"userName"`
+	DisplayName string    `json:"displayName,omitempty"`
+	Active      bool      `json:"active"`
+	Groups      []scimRef `json:"groups,omitempty"`
+	Meta        scimMeta  `json:"meta"`
+}
+
+// Группа SCIM: id и displayName - это team_name
+type scimGroup struct {
+	Schemas     []string  `json:"schemas"`
+	ID          string    `json:"id"`
+	DisplayName string    `json:"displayName"`
+	Members     []scimRef `json:"members,omitempty"`
+	Meta        scimMeta  `json:"meta"`
+}
+
+type scimListResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int         `json:"totalResults"`
+	StartIndex   int         `json:"startIndex"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	Resources    interface{} `json:"Resources"`
+}
+
+// Тело POST/PUT пользователя
+type scimUserRequest struct {
+	UserName    string    `json:"userName"`
+	DisplayName string    `json:"displayName"`
+	Name        *scimName `json:"name"`
+	Active      *bool     `json:"active"`
+}
+
+// Тело POST/PUT группы
+type scimGroupRequest struct {
+	DisplayName string    `json:"displayName"`
+	Members     []scimRef `json:"members"`
+}
+
+type scimPatchRequest struct {
+	Operations []scimPatchOperation `json:"Operations"`
+}
+
+type scimPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Middleware, пропускающий только запросы с токеном каталога в заголовке Authorization
+func ScimAuthMiddleware(token string) gin.HandlerFunc {
+	expected := []byte("Bearer " + token)
+	return func(c *gin.Context) {
+		got := []byte(c.GetHeader("Authorization"))
+		if subtle.ConstantTimeCompare(got, expected) != 1 {
+			scimError(c, http.StatusUnauthorized, "", "invalid or missing bearer token")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// Middleware, превращающий ошибки обработчиков в ответ об ошибке по RFC 7644
+func ScimErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+		status, code, message := mapError(c.Request.Context(), err)
+		scimType := ""
+		switch {
+		case code == service.CodeTeamExists || code == service.CodeUserExists:
+			status, scimType = http.StatusConflict, "uniqueness"
+		case code == service.CodeValidation:
+			scimType = "invalidValue"
+		}
+		if status == http.StatusInternalServerError {
+			slog.ErrorContext(c.Request.Context(), "request failed", "path", c.FullPath(), "error", err)
+		}
+		scimError(c, status, scimType, message)
+	}
+}
+
+// Функция возвращает возможности сервиса для настройки провайдера
+func (h *ScimHandler) ServiceProviderConfig(c *gin.Context) {
+	scimJSON(c, http.StatusOK, gin.H{
+		"schemas":        []string{scimSPConfigSchema},
+		"patch":          gin.H{"supported": true},
+		"bulk":           gin.H{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         gin.H{"supported": true, "maxResults": service.ScimMaxPageSize},
+		"changePassword": gin.H{"supported": false},
+		"sort":           gin.H{"supported": false},
+		"etag":           gin.H{"supported": false},
+		"authenticationSchemes": []gin.H{{
+			"type":        "oauthbearertoken",
+			"name":        "OAuth Bearer Token",
+			"description": "Authentication with the SCIM_TOKEN bearer token",
+		}},
+	})
+}
+
+// Функция возвращает пользователей, поддерживается фильтр userName eq "..."
+func (h *ScimHandler) ListUsers(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, err := parseScimFilter(c.Query("filter"), "userName")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	startIndex, count, err := scimPagination(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	users, total, err := h.scimService.ListUsers(ctx, userID, startIndex, count)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	resources := make([]scimUser, 0, len(users))
+	for i := range users {
+		resources = append(resources, toScimUser(c, &users[i]))
+	}
+	scimJSON(c, http.StatusOK, scimListResponse{
+		Schemas:      []string{scimListSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// Функция возвращает пользователя
+func (h *ScimHandler) GetUser(c *gin.Context) {
+	user, err := h.scimService.GetUser(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	scimJSON(c, http.StatusOK, toScimUser(c, user))
+}
+
+// Функция заводит пользователя
+func (h *ScimHandler) CreateUser(c *gin.Context) {
+	var req scimUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	user, err := h.scimService.CreateUser(c.Request.Context(), models.User{
+		UserID:   req.UserName,
+		Username: req.username(),
+		IsActive: active,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("Location", scimLocation(c, "Users", user.UserID))
+	scimJSON(c, http.StatusCreated, toScimUser(c, user))
+}
+
+// Функция заменяет пользователя. userName менять нельзя, он же идентификатор
+func (h *ScimHandler) ReplaceUser(c *gin.Context) {
+	userID := c.Param("id")
+	var req scimUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+	if req.UserName != "" && req.UserName != userID {
+		c.Error(service.NewValidationError("userName is immutable"))
+		return
+	}
+
+	change := service.ScimUserChange{IsActive: req.Active}
+	if username := req.username(); username != "" {
+		change.Username = &username
+	}
+	h.updateUser(c, userID, change)
+}
+
+// Функция частично изменяет пользователя, в том числе деактивирует его
+func (h *ScimHandler) PatchUser(c *gin.Context) {
+	userID := c.Param("id")
+	var req scimPatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+	change, err := parseUserPatch(userID, req.Operations)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	h.updateUser(c, userID, change)
+}
+
+// Функция деактивирует пользователя: историю ревью удалять нельзя
+func (h *ScimHandler) DeleteUser(c *gin.Context) {
+	if err := h.scimService.DeactivateUser(c.Request.Context(), c.Param("id")); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ScimHandler) updateUser(c *gin.Context, userID string, change service.ScimUserChange) {
+	user, err := h.scimService.UpdateUser(c.Request.Context(), userID, change)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	scimJSON(c, http.StatusOK, toScimUser(c, user))
+}
+
+// Функция возвращает команды, поддерживается фильтр displayName eq "..."
+func (h *ScimHandler) ListGroups(c *gin.Context) {
+	ctx := c.Request.Context()
+	teamName, err := parseScimFilter(c.Query("filter"), "displayName")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	startIndex, count, err := scimPagination(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	teams, total, err := h.scimService.ListGroups(ctx, teamName, startIndex, count)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	withMembers := !excludesMembers(c)
+	resources := make([]scimGroup, 0, len(teams))
+	for i := range teams {
+		resources = append(resources, toScimGroup(c, &teams[i], withMembers))
+	}
+	scimJSON(c, http.StatusOK, scimListResponse{
+		Schemas:      []string{scimListSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// Функция возвращает команду
+func (h *ScimHandler) GetGroup(c *gin.Context) {
+	team, err := h.scimService.GetGroup(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	scimJSON(c, http.StatusOK, toScimGroup(c, team, !excludesMembers(c)))
+}
+
+// Функция создает команду
+func (h *ScimHandler) CreateGroup(c *gin.Context) {
+	var req scimGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+
+	team, err := h.scimService.CreateGroup(c.Request.Context(), req.DisplayName, scimRefValues(req.Members))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("Location", scimLocation(c, "Groups", team.TeamName))
+	scimJSON(c, http.StatusCreated, toScimGroup(c, team, true))
+}
+
+// Функция заменяет команду: имя и полный состав
+func (h *ScimHandler) ReplaceGroup(c *gin.Context) {
+	var req scimGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+	h.patchGroup(c, c.Param("id"), []service.ScimGroupPatch{
+		{Action: service.ScimGroupSet, Members: scimRefValues(req.Members)},
+		{Action: service.ScimGroupRename, Name: req.DisplayName},
+	})
+}
+
+// Функция частично изменяет команду: имя и состав участников
+func (h *ScimHandler) PatchGroup(c *gin.Context) {
+	var req scimPatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+	patches, err := parseGroupPatch(req.Operations)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	h.patchGroup(c, c.Param("id"), patches)
+}
+
+// Функция удаляет команду
+func (h *ScimHandler) DeleteGroup(c *gin.Context) {
+	if err := h.scimService.DeleteGroup(c.Request.Context(), c.Param("id")); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Функция применяет изменения команды одной транзакцией, переименование выполняется последним
+func (h *ScimHandler) patchGroup(c *gin.Context, teamName string, patches []service.ScimGroupPatch) {
+	team, err := h.scimService.PatchGroup(c.Request.Context(), teamName, patches)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	scimJSON(c, http.StatusOK, toScimGroup(c, team, true))
+}
+
+// Функция собирает изменения пользователя из операций PATCH. Поддерживаются оба
+// распространенных вида: с path и значением и без path с объектом атрибутов
+func parseUserPatch(userID string, ops []scimPatchOperation) (service.ScimUserChange, error) {
+	var change service.ScimUserChange
+	for _, op := range ops {
+		opName := strings.ToLower(op.Op)
+		if opName != "add" && opName != "replace" {
+			return change, service.NewValidationError(fmt.Sprintf("unsupported user patch op %q", op.Op))
+		}
+
+		attrs := map[string]json.RawMessage{}
+		if op.Path == "" {
+			if err := json.Unmarshal(op.Value, &attrs); err != nil {
+				return change, service.NewValidationError("patch value must be an object when path is empty")
+			}
+		} else {
+			attrs[op.Path] = op.Value
+		}
+
+		for path, value := range attrs {
+			switch strings.ToLower(path) {
+			case "active":
+				active, err := parseScimBool(value)
+				if err != nil {
+					return change, err
+				}
+				change.IsActive = &active
+			case "displayname", "name.formatted":
+				var username string
+				if err := json.Unmarshal(value, &username); err != nil {
+					return change, service.NewValidationError(path + " must be a string")
+				}
+				change.Username = &username
+			case "name":
+				var name scimName
+				if err := json.Unmarshal(value, &name); err != nil {
+					return change, service.NewValidationError("name must be an object")
+				}
+				if username := name.String(); username != "" {
+					change.Username = &username
+				}
+			case "username":
+				var userName string
+				if err := json.Unmarshal(value, &userName); err != nil || userName != userID {
+					return change, service.NewValidationError("userName is immutable")
+				}
+			default:
+				// Атрибуты, которых нет в модели сервиса (emails, title и т.п.), пропускаются
+			}
+		}
+	}
+	return change, nil
+}
+
+// Фильтр участника в path вида members[value eq "u1"]
+var scimMemberPathRe = regexp.MustCompile(`(?i)^members\[\s*value\s+eq\s+"([^"]*)"\s*\]$`)
+
+// Функция переводит операции PATCH группы в изменения состава и имени
+func parseGroupPatch(ops []scimPatchOperation) ([]service.ScimGroupPatch, error) {
+	var patches []service.ScimGroupPatch
+	for _, op := range ops {
+		opName := strings.ToLower(op.Op)
+		path := strings.TrimSpace(op.Path)
+
+		if match := scimMemberPathRe.FindStringSubmatch(path); match != nil {
+			if opName != "remove" {
+				return nil, service.NewValidationError(fmt.Sprintf("unsupported op %q for path %q", op.Op, op.Path))
+			}
+			patches = append(patches, service.ScimGroupPatch{Action: service.ScimGroupRemove, Members: []string{match[1]}})
+			continue
+		}
+
+		switch strings.ToLower(path) {
+		case "members":
+			members, err := parseScimRefs(op.Value)
+			if err != nil {
+				return nil, err
+			}
+			switch opName {
+			case "add":
+				patches = append(patches, service.ScimGroupPatch{Action: service.ScimGroupAdd, Members: members})
+			case "remove":
+				if len(op.Value) == 0 || string(op.Value) == "null" {
+					// Без значения убираются все участники
+					patches = append(patches, service.ScimGroupPatch{Action: service.ScimGroupSet, Members: []string{}})
+				} else {
+					patches = append(patches, service.ScimGroupPatch{Action: service.ScimGroupRemove, Members: members})
+				}
+			case "replace":
+				patches = append(patches, service.ScimGroupPatch{Action: service.ScimGroupSet, Members: members})
+			default:
+				return nil, service.NewValidationError(fmt.Sprintf("unsupported group patch op %q", op.Op))
+			}
+		case "displayname":
+			if opName != "add" && opName != "replace" {
+				return nil, service.NewValidationError(fmt.Sprintf("unsupported op %q for displayName", op.Op))
+			}
+			var name string
+			if err := json.Unmarshal(op.Value, &name); err != nil {
+				return nil, service.NewValidationError("displayName must be a string")
+			}
+			patches = append(patches, service.ScimGroupPatch{Action: service.ScimGroupRename, Name: name})
+		case "":
+			if opName != "add" && opName != "replace" {
+				return nil, service.NewValidationError(fmt.Sprintf("unsupported group patch op %q", op.Op))
+			}
+			var req scimGroupRequest
+			if err := json.Unmarshal(op.Value, &req); err != nil {
+				return nil, service.NewValidationError("patch value must be an object when path is empty")
+			}
+			if req.Members != nil {
+				action := service.ScimGroupSet
+				if opName == "add" {
+					action = service.ScimGroupAdd
+				}
+				patches = append(patches, service.ScimGroupPatch{Action: action, Members: scimRefValues(req.Members)})
+			}
+			if req.DisplayName != "" {
+				patches = append(patches, service.ScimGroupPatch{Action: service.ScimGroupRename, Name: req.DisplayName})
+			}
+		default:
+			return nil, service.NewValidationError(fmt.Sprintf("unsupported group patch path %q", op.Path))
+		}
+	}
+	return patches, nil
+}
+
+// Фильтр вида attr eq "value"
+var scimFilterRe = regexp.MustCompile(`^(\w+)\s+(?i:eq)\s+"([^"]*)"$`)
+
+// Функция разбирает фильтр списка; поддерживается только сравнение атрибута attr на равенство
+func parseScimFilter(filter, attr string) (string, error) {
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return "", nil
+	}
+	match := scimFilterRe.FindStringSubmatch(filter)
+	if match == nil || !strings.EqualFold(match[1], attr) {
+		return "", service.NewValidationError(fmt.Sprintf("unsupported filter, only %s eq \"value\" is supported", attr))
+	}
+	return match[2], nil
+}
+
+// Функция читает startIndex и count, по умолчанию первая страница из 100 записей
+func scimPagination(c *gin.Context) (int, int, error) {
+	startIndex, count := 1, scimDefaultPageCount
+	var err error
+	if value := c.Query("startIndex"); value != "" {
+		if startIndex, err = strconv.Atoi(value); err != nil {
+			return 0, 0, service.NewValidationError("startIndex must be an integer")
+		}
+		if startIndex < 1 {
+			startIndex = 1
+		}
+	}
+	if value := c.Query("count"); value != "" {
+		if count, err = strconv.Atoi(value); err != nil {
+			return 0, 0, service.NewValidationError("count must be an integer")
+		}
+	}
+	return startIndex, count, nil
+}
+
+// Функция проверяет, просил ли клиент не возвращать участников групп
+func excludesMembers(c *gin.Context) bool {
+	for _, attr := range strings.Split(c.Query("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(attr), "members") {
+			return true
+		}
+	}
+	return false
+}
+
+// Функция разбирает булево значение; некоторые провайдеры присылают его строкой "True"/"False"
+func parseScimBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b, nil
+		}
+	}
+	return false, service.NewValidationError("active must be a boolean")
+}
+
+// Функция разбирает список ссылок на участников, одиночный объект тоже допускается
+func parseScimRefs(value json.RawMessage) ([]string, error) {
+	if len(value) == 0 || string(value) == "null" {
+		return nil, nil
+	}
+	var refs []scimRef
+	if err := json.Unmarshal(value, &refs); err != nil {
+		var ref scimRef
+		if err := json.Unmarshal(value, &ref); err != nil {
+			return nil, service.NewValidationError("members must be a list of {\"value\": ...}")
+		}
+		refs = []scimRef{ref}
+	}
+	return scimRefValues(refs), nil
+}
+
+func scimRefValues(refs []scimRef) []string {
+	values := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if ref.Value != "" {
+			values = append(values, ref.Value)
+		}
+	}
+	return values
+}
+
+// Функция выбирает имя пользователя из displayName или name
+func (r *scimUserRequest) username() string {
+	if r.DisplayName != "" {
+		return r.DisplayName
+	}
+	if r.Name != nil {
+		return r.Name.String()
+	}
+	return ""
+}
+
+func (n scimName) String() string {
+	if n.Formatted != "" {
+		return n.Formatted
+	}
+	return strings.TrimSpace(n.GivenName + " " + n.FamilyName)
+}
+
+func toScimUser(c *gin.Context, user *models.User) scimUser {
+	groups := make([]scimRef, 0, len(user.Teams))
+	for _, team := range user.Teams {
+		groups = append(groups, scimRef{Value: team, Display: team})
+	}
+	return scimUser{
+		Schemas:     []string{scimUserSchema},
+		ID:          user.UserID,
+		UserName:    user.UserID,
+		DisplayName: user.Username,
+		Active:      user.IsActive,
+		Groups:      groups,
+		Meta:        scimMeta{ResourceType: "User", Location: scimLocation(c, "Users", user.UserID)},
+	}
+}
+
+func toScimGroup(c *gin.Context, team *models.Team, withMembers bool) scimGroup {
+	group := scimGroup{
+		Schemas:     []string{scimGroupSchema},
+		ID:          team.TeamName,
+		DisplayName: team.TeamName,
+		Meta:        scimMeta{ResourceType: "Group", Location: scimLocation(c, "Groups", team.TeamName)},
+	}
+	if withMembers {
+		group.Members = make([]scimRef, 0, len(team.Members))
+		for _, member := range team.Members {
+			group.Members = append(group.Members, scimRef{Value: member.UserID, Display: member.Username})
+		}
+	}
+	return group
+}
+
+// Функция строит абсолютный адрес ресурса
+func scimLocation(c *gin.Context, resource, id string) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/scim/v2/%s/%s", scheme, c.Request.Host, resource, url.PathEscape(id))
+}
+
+func scimJSON(c *gin.Context, status int, body interface{}) {
+	c.Header("Content-Type", scimContentType)
+	c.JSON(status, body)
+}
+
+func scimError(c *gin.Context, status int, scimType, detail string) {
+	body := gin.H{
+		"schemas": []string{scimErrorSchema},
+		"status":  strconv.Itoa(status),
+		"detail":  detail,
+	}
+	if scimType != "" {
+		body["scimType"] = scimType
+	}
+	scimJSON(c, status, body)
+}
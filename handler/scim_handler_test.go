@@ -0,0 +1,211 @@
+package handler
+
+import (
+	service "Backend-trainee-assignment/services"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseScimFilter(t *testing.T) {
+	tests := []struct {
+		filter  string
+		want    string
+		wantErr bool
+	}{
+		{filter: "", want: ""},
+		{filter: `userName eq "u1"`, want: "u1"},
+		{filter: `  USERNAME EQ "alice@example.com"  `, want: "alice@example.com"},
+		{filter: `userName eq ""`, want: ""},
+		{filter: `displayName eq "backend"`, wantErr: true},
+		{filter: `userName ne "u1"`, wantErr: true},
+		{filter: `userName eq u1`, wantErr: true},
+		{filter: `userName eq "u1" and active eq true`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseScimFilter(tt.filter, "userName")
+		if tt.wantErr {
+			if _, ok := err.(*service.ValidationError); !ok {
+				t.Errorf("parseScimFilter(%q): error %v, want validation error", tt.filter, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseScimFilter(%q) = %q, %v, want %q", tt.filter, got, err, tt.want)
+		}
+	}
+}
+
+// Функция собирает операции PATCH из JSON-массива Operations
+func patchOps(t *testing.T, ops string) []scimPatchOperation {
+	t.Helper()
+	var req scimPatchRequest
+	if err := json.Unmarshal([]byte(`{"Operations": `+ops+`}`), &req); err != nil {
+		t.Fatal(err)
+	}
+	return req.Operations
+}
+
+func TestParseUserPatch(t *testing.T) {
+	tests := []struct {
+		name         string
+		ops          string
+		wantUsername string
+		wantActive   string
+		wantErr      string
+	}{
+		{name: "deactivate by path", ops: `[{"op": "replace", "path": "active", "value": false}]`, wantActive: "false"},
+		{name: "active as string", ops: `[{"op": "Replace", "path": "active", "value": "True"}]`, wantActive: "true"},
+		{
+			name:         "attributes without path",
+			ops:          `[{"op": "replace", "value": {"active": false, "displayName": "Alice", "emails": []}}]`,
+			wantUsername: "Alice", wantActive: "false",
+		},
+		{
+			name:         "name object",
+			ops:          `[{"op": "add", "path": "name", "value": {"givenName": "Alice", "familyName": "Smith"}}]`,
+			wantUsername: "Alice Smith",
+		},
+		{
+			name:         "later op wins",
+			ops:          `[{"op": "replace", "path": "displayName", "value": "A"}, {"op": "replace", "path": "name.formatted", "value": "B"}]`,
+			wantUsername: "B",
+		},
+		{name: "same userName", ops: `[{"op": "replace", "path": "userName", "value": "u1"}]`},
+		{name: "unknown attribute skipped", ops: `[{"op": "replace", "path": "title", "value": "Engineer"}]`},
+		{name: "userName changed", ops: `[{"op": "replace", "path": "userName", "value": "u2"}]`, wantErr: "userName is immutable"},
+		{name: "remove op", ops: `[{"op": "remove", "path": "active"}]`, wantErr: `unsupported user patch op "remove"`},
+		{name: "invalid active", ops: `[{"op": "replace", "path": "active", "value": "maybe"}]`, wantErr: "active must be a boolean"},
+		{name: "value not object", ops: `[{"op": "replace", "value": false}]`, wantErr: "patch value must be an object when path is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change, err := parseUserPatch("u1", patchOps(t, tt.ops))
+			if tt.wantErr != "" {
+				if _, ok := err.(*service.ValidationError); !ok || err.Error() != tt.wantErr {
+					t.Fatalf("error %v, want validation error %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			username, active := "", ""
+			if change.Username != nil {
+				username = *change.Username
+			}
+			if change.IsActive != nil {
+				active = fmt.Sprint(*change.IsActive)
+			}
+			if username != tt.wantUsername || active != tt.wantActive {
+				t.Fatalf("username %q, active %q, want %q, %q", username, active, tt.wantUsername, tt.wantActive)
+			}
+		})
+	}
+}
+
+func TestParseGroupPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		ops     string
+		want    []service.ScimGroupPatch
+		wantErr string
+	}{
+		{
+			name: "add members",
+			ops:  `[{"op": "add", "path": "members", "value": [{"value": "u1"}, {"value": "u2"}]}]`,
+			want: []service.ScimGroupPatch{{Action: service.ScimGroupAdd, Members: []string{"u1", "u2"}}},
+		},
+		{
+			name: "single member object",
+			ops:  `[{"op": "add", "path": "members", "value": {"value": "u1"}}]`,
+			want: []service.ScimGroupPatch{{Action: service.ScimGroupAdd, Members: []string{"u1"}}},
+		},
+		{
+			name: "remove by filter",
+			ops:  `[{"op": "remove", "path": "members[value eq \"u1\"]"}]`,
+			want: []service.ScimGroupPatch{{Action: service.ScimGroupRemove, Members: []string{"u1"}}},
+		},
+		{
+			name: "remove all members",
+			ops:  `[{"op": "remove", "path": "members"}]`,
+			want: []service.ScimGroupPatch{{Action: service.ScimGroupSet, Members: []string{}}},
+		},
+		{
+			name: "replace members and rename",
+			ops:  `[{"op": "replace", "path": "members", "value": [{"value": "u3"}]}, {"op": "replace", "path": "displayName", "value": "platform"}]`,
+			want: []service.ScimGroupPatch{
+				{Action: service.ScimGroupSet, Members: []string{"u3"}},
+				{Action: service.ScimGroupRename, Name: "platform"},
+			},
+		},
+		{
+			name: "object without path",
+			ops:  `[{"op": "add", "value": {"displayName": "platform", "members": [{"value": "u1"}]}}]`,
+			want: []service.ScimGroupPatch{
+				{Action: service.ScimGroupAdd, Members: []string{"u1"}},
+				{Action: service.ScimGroupRename, Name: "platform"},
+			},
+		},
+		{name: "replace by filter", ops: `[{"op": "replace", "path": "members[value eq \"u1\"]", "value": {}}]`, wantErr: `unsupported op "replace" for path "members[value eq \"u1\"]"`},
+		{name: "remove displayName", ops: `[{"op": "remove", "path": "displayName"}]`, wantErr: `unsupported op "remove" for displayName`},
+		{name: "unknown path", ops: `[{"op": "add", "path": "owners", "value": []}]`, wantErr: `unsupported group patch path "owners"`},
+		{name: "invalid members", ops: `[{"op": "add", "path": "members", "value": "u1"}]`, wantErr: `members must be a list of {"value": ...}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGroupPatch(patchOps(t, tt.ops))
+			if tt.wantErr != "" {
+				if _, ok := err.(*service.ValidationError); !ok || err.Error() != tt.wantErr {
+					t.Fatalf("error %v, want validation error %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprintf("%+v", got) != fmt.Sprintf("%+v", tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Проверки длины выполняются до обращения к БД
+func TestScimCreateUserTooLong(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewScimHandler(service.NewScimService(nil, nil, nil, nil, nil))
+	router := gin.New()
+	router.Use(ScimErrorMiddleware())
+	router.POST("/Users", h.CreateUser)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantDetail string
+	}{
+		{name: "email userName", body: `{"userName": "alice.longname@engineering.example.com"}`, wantDetail: "userName must be at most 36 characters"},
+		{name: "long displayName", body: `{"userName": "u1", "displayName": "` + strings.Repeat("a", 256) + `"}`, wantDetail: "displayName must be at most 255 characters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/Users", strings.NewReader(tt.body)))
+			var body struct {
+				ScimType string `json:"scimType"`
+				Detail   string `json:"detail"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid body %q: %v", rec.Body.String(), err)
+			}
+			if rec.Code != http.StatusBadRequest || body.ScimType != "invalidValue" || body.Detail != tt.wantDetail {
+				t.Fatalf("%d %s, want 400 invalidValue %q", rec.Code, rec.Body.String(), tt.wantDetail)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"Backend-trainee-assignment/models"
+	service "Backend-trainee-assignment/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SLAHandler struct {
+	slaService *service.SLAService
+}
+
+func NewSLAHandler(slaService *service.SLAService) *SLAHandler {
+	return &SLAHandler{slaService: slaService}
+}
+
+// Функция фиксирует решение ревьюера по PR
+func (h *SLAHandler) SubmitReview(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req struct {
+		PullRequestID string `json:"pull_request_id" binding:"required"`
+		ReviewerID    string `json:"reviewer_id" binding:"required"`
+		Decision      string `json:"decision" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+
+	review, err := h.slaService.RecordDecision(ctx, req.PullRequestID, req.ReviewerID, req.Decision)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"review": review})
+}
+
+// Функция возвращает назначения без решения, превысившие SLA
+func (h *SLAHandler) GetOverdue(c *gin.Context) {
+	ctx := c.Request.Context()
+	overdue, err := h.slaService.Overdue(ctx, c.Query("team_name"), time.Now())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"overdue": overdue})
+}
+
+// Функция возвращает порог SLA команды
+func (h *SLAHandler) GetTeamSLA(c *gin.Context) {
+	ctx := c.Request.Context()
+	teamName := c.Query("team_name")
+	if teamName == "" {
+		c.Error(service.NewValidationError("team_name is required"))
+		return
+	}
+
+	sla, err := h.slaService.GetTeamSLA(ctx, teamName)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, sla)
+}
+
+// Функция задает порог SLA команды
+func (h *SLAHandler) SetTeamSLA(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req struct {
+		TeamName       string `json:"team_name" binding:"required"`
+		ThresholdHours int    `json:"threshold_hours" binding:"required"`
+		AutoReassign   bool   `json:"auto_reassign"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+
+	sla, err := h.slaService.SetTeamSLA(ctx, models.TeamSLA{
+		TeamName:       req.TeamName,
+		ThresholdHours: req.ThresholdHours,
+		AutoReassign:   req.AutoReassign,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, sla)
+}
@@ -1,137 +1,106 @@
 package handler
 
 import (
-	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/models"
+	service "Backend-trainee-assignment/services"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type StatsHandler struct {
-	prRepo *repository.PRRepository
+	statsService *service.StatsService
 }
 
-func NewStatsHandler(prRepo *repository.PRRepository) *StatsHandler {
-	return &StatsHandler{prRepo: prRepo}
+func NewStatsHandler(statsService *service.StatsService) *StatsHandler {
+	return &StatsHandler{statsService: statsService}
 }
 
-type StatsResponse struct {
-	UserAssignments  map[string]int `json:"user_assignments"`
-	PRAssignments    map[string]int `json:"pr_assignments"`
-	TotalAssignments int            `json:"total_assignments"`
-	ActivePRs        int            `json:"active_prs"`
-	MergedPRs        int            `json:"merged_prs"`
-}
-
-// Функция возвращает статистику
+// Функция возвращает статистику за период
 func (h *StatsHandler) GetStats(c *gin.Context) {
-	userStats, err := h.getUserAssignmentStats()
+	ctx := c.Request.Context()
+	from, to, err := queryTimeRange(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
+		c.Error(err)
 		return
 	}
+	q := models.StatsQuery{
+		From:     from,
+		To:       to,
+		TeamName: c.Query("team"),
+		GroupBy:  c.Query("group_by"),
+	}
+	if value := c.Query("top"); value != "" {
+		top, err := strconv.Atoi(value)
+		if err != nil || top <= 0 {
+			c.Error(service.NewValidationError("top must be a positive integer"))
+			return
+		}
+		q.Top = top
+	}
 
-	prStats, totalAssignments, activePRs, mergedPRs, err := h.getPRAssignmentStats()
+	stats, err := h.statsService.GetStats(ctx, q)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
+		c.Error(err)
 		return
 	}
-
-	response := StatsResponse{
-		UserAssignments:  userStats,
-		PRAssignments:    prStats,
-		TotalAssignments: totalAssignments,
-		ActivePRs:        activePRs,
-		MergedPRs:        mergedPRs,
-	}
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, stats)
 }
 
-// Функция возвращает статистику по пользователям
-func (h *StatsHandler) getUserAssignmentStats() (map[string]int, error) {
-	query := `
-		SELECT reviewer_user_id, COUNT(*) as assignment_count
-		FROM pr_reviewers
-		GROUP BY reviewer_user_id
-		ORDER BY assignment_count DESC
-	`
-	rows, err := h.prRepo.DB().Query(query)
+// Функция возвращает отчет о равномерности нагрузки ревьюеров; по умолчанию за последние 30 дней
+func (h *StatsHandler) GetFairness(c *gin.Context) {
+	ctx := c.Request.Context()
+	from, to, err := queryTimeRange(c)
 	if err != nil {
-		return nil, err
+		c.Error(err)
+		return
 	}
-	defer rows.Close()
-
-	stats := make(map[string]int)
-	for rows.Next() {
-		var userID string
-		var count int
-		if err := rows.Scan(&userID, &count); err != nil {
-			return nil, err
-		}
-		stats[userID] = count
+	q := models.FairnessQuery{
+		TeamName: c.Query("team"),
+		GroupBy:  c.Query("group_by"),
 	}
-	return stats, nil
-}
-
-// Функция возвращает статистику по PR
-func (h *StatsHandler) getPRAssignmentStats() (map[string]int, int, int, int, error) {
-	prQuery := `
-		SELECT pr_id, COUNT(*) as reviewer_count
-		FROM pr_reviewers
-		GROUP BY pr_id
-	`
-	prRows, err := h.prRepo.DB().Query(prQuery)
-	if err != nil {
-		return nil, 0, 0, 0, err
+	if from != nil {
+		q.From = *from
 	}
-	defer prRows.Close()
-
-	prStats := make(map[string]int)
-	totalAssignments := 0
-	for prRows.Next() {
-		var prID string
-		var count int
-		if err := prRows.Scan(&prID, &count); err != nil {
-			return nil, 0, 0, 0, err
+	if to != nil {
+		q.To = *to
+	}
+	if value := c.Query("tolerance"); value != "" {
+		tolerance, err := strconv.ParseFloat(value, 64)
+		if err != nil || tolerance <= 0 {
+			c.Error(service.NewValidationError("tolerance must be a positive number"))
+			return
 		}
-		prStats[prID] = count
-		totalAssignments += count
+		q.Tolerance = tolerance
 	}
 
-	statusQuery := `
-		SELECT status, COUNT(*) 
-		FROM pull_requests 
-		GROUP BY status
-	`
-	statusRows, err := h.prRepo.DB().Query(statusQuery)
+	report, err := h.statsService.GetFairness(ctx, q, time.Now())
 	if err != nil {
-		return nil, 0, 0, 0, err
+		c.Error(err)
+		return
 	}
-	defer statusRows.Close()
+	c.JSON(http.StatusOK, report)
+}
 
-	activePRs, mergedPRs := 0, 0
-	for statusRows.Next() {
-		var status string
-		var count int
-		if err := statusRows.Scan(&status, &count); err != nil {
-			return nil, 0, 0, 0, err
+// Функция разбирает границы периода from и to в RFC 3339 или как дату YYYY-MM-DD; nil - граница не задана
+func queryTimeRange(c *gin.Context) (from, to *time.Time, err error) {
+	for _, bound := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &from}, {"to", &to}} {
+		value := c.Query(bound.name)
+		if value == "" {
+			continue
 		}
-		switch status {
-		case "OPEN":
-			activePRs = count
-		case "MERGED":
-			mergedPRs = count
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			if t, err = time.Parse(time.DateOnly, value); err != nil {
+				return nil, nil, service.NewValidationError(bound.name + " must be RFC 3339 time or YYYY-MM-DD date")
+			}
 		}
+		*bound.dst = &t
 	}
-	return prStats, totalAssignments, activePRs, mergedPRs, nil
+	return from, to, nil
 }
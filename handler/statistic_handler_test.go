@@ -0,0 +1,97 @@
+package handler
+
+import (
+	service "Backend-trainee-assignment/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestQueryTimeRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	moscow := time.FixedZone("", 3*60*60)
+	tests := []struct {
+		name     string
+		query    string
+		wantFrom *time.Time
+		wantTo   *time.Time
+		wantErr  string
+	}{
+		{name: "no bounds"},
+		{
+			name:     "date only is UTC midnight",
+			query:    "from=2024-03-01&to=2024-03-02",
+			wantFrom: ptrTime(time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)),
+			wantTo:   ptrTime(time.Date(2024, time.March, 2, 0, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:     "RFC 3339 keeps offset",
+			query:    "from=2024-03-01T10:00:00%2B03:00",
+			wantFrom: ptrTime(time.Date(2024, time.March, 1, 10, 0, 0, 0, moscow)),
+		},
+		{name: "invalid from", query: "from=01.03.2024", wantErr: "from must be RFC 3339 time or YYYY-MM-DD date"},
+		{name: "invalid to", query: "from=2024-03-01&to=2024-03-01T10:00", wantErr: "to must be RFC 3339 time or YYYY-MM-DD date"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/stats?"+tt.query, nil)
+			from, to, err := queryTimeRange(c)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, bound := range []struct {
+				got, want *time.Time
+			}{{from, tt.wantFrom}, {to, tt.wantTo}} {
+				if (bound.got == nil) != (bound.want == nil) || bound.got != nil && !bound.got.Equal(*bound.want) {
+					t.Fatalf("from %v, to %v, want %v, %v", from, to, tt.wantFrom, tt.wantTo)
+				}
+			}
+		})
+	}
+}
+
+// Запросы с неверными параметрами отклоняются до обращения к БД
+func TestGetStatsValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorMiddleware())
+	router.GET("/", NewStatsHandler(service.NewStatsService(nil, nil)).GetStats)
+
+	tests := []struct {
+		query       string
+		wantMessage string
+	}{
+		{query: "from=2024-03-02&to=2024-03-01", wantMessage: "from must be before to"},
+		{query: "from=2024-03-01&to=2024-03-01", wantMessage: "from must be before to"},
+		{query: "from=2024-03-01T03:00:00%2B03:00&to=2024-03-01", wantMessage: "from must be before to"},
+		{query: "from=yesterday", wantMessage: "from must be RFC 3339 time or YYYY-MM-DD date"},
+		{query: "group_by=year", wantMessage: "group_by must be day, week or month"},
+		{query: "top=0", wantMessage: "top must be a positive integer"},
+		{query: "top=ten", wantMessage: "top must be a positive integer"},
+		{query: "top=101", wantMessage: "top must be between 1 and 100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil))
+			status, code, message := decodeError(t, rec)
+			if status != http.StatusBadRequest || code != service.CodeValidation || message != tt.wantMessage {
+				t.Fatalf("%d %s %q, want 400 %q", status, code, message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
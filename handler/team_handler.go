@@ -3,85 +3,41 @@ package handler
 import (
 	repository "Backend-trainee-assignment/database"
 	"Backend-trainee-assignment/models"
+	service "Backend-trainee-assignment/services"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
 type TeamHandler struct {
-	teamRepo *repository.TeamRepository
-	userRepo *repository.UserRepository
+	teamRepo    *repository.TeamRepository
+	userRepo    *repository.UserRepository
+	teamService *service.TeamService
+	reconciler  *service.Reconciler
 }
 
-func NewTeamHandler(teamRepo *repository.TeamRepository, userRepo *repository.UserRepository) *TeamHandler {
+func NewTeamHandler(teamRepo *repository.TeamRepository, userRepo *repository.UserRepository, teamService *service.TeamService, reconciler *service.Reconciler) *TeamHandler {
 	return &TeamHandler{
-		teamRepo: teamRepo,
-		userRepo: userRepo,
+		teamRepo:    teamRepo,
+		userRepo:    userRepo,
+		teamService: teamService,
+		reconciler:  reconciler,
 	}
 }
 
 // Функция создает команду с участниками
 func (h *TeamHandler) AddTeam(c *gin.Context) {
+	ctx := c.Request.Context()
 	var team models.Team
 	if err := c.ShouldBindJSON(&team); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": map[string]interface{}{
-				"code":    "VALIDATION_ERROR",
-				"message": err.Error(),
-			},
-		})
+		c.Error(service.NewValidationError(err.Error()))
 		return
 	}
-	exists, err := h.teamRepo.TeamExists(team.TeamName)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
-		return
-	}
-	if exists {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": map[string]interface{}{
-				"code":    "TEAM_EXISTS",
-				"message": "team_name already exists",
-			},
-		})
+	if err := h.teamService.CreateTeam(ctx, team); err != nil {
+		c.Error(err)
 		return
 	}
-
-	// Создание команды
-	teamToCreate := &models.Team{
-		TeamName: team.TeamName,
-	}
-	if err := h.teamRepo.CreateTeam(teamToCreate); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
-		return
-	}
-	for _, member := range team.Members {
-		user := &models.User{
-			UserID:   member.UserID,
-			Username: member.Username,
-			TeamName: team.TeamName,
-			IsActive: member.IsActive,
-		}
-		if err := h.userRepo.CreateUser(user); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": map[string]interface{}{
-					"code":    "INTERNAL_ERROR",
-					"message": err.Error(),
-				},
-			})
-			return
-		}
-	}
+	h.reconciler.Trigger()
 
 	createdTeam := models.Team{
 		TeamName: team.TeamName,
@@ -92,44 +48,25 @@ func (h *TeamHandler) AddTeam(c *gin.Context) {
 
 // Функция возвращает команду с участниками
 func (h *TeamHandler) GetTeam(c *gin.Context) {
+	ctx := c.Request.Context()
 	teamName := c.Query("team_name")
 	if teamName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": map[string]interface{}{
-				"code":    "VALIDATION_ERROR",
-				"message": "team_name is required",
-			},
-		})
+		c.Error(service.NewValidationError("team_name is required"))
 		return
 	}
-	team, err := h.teamRepo.GetTeamByName(teamName)
+	team, err := h.teamRepo.GetTeamByName(ctx, teamName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
+		c.Error(err)
 		return
 	}
 	if team == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": map[string]interface{}{
-				"code":    "NOT_FOUND",
-				"message": "team not found",
-			},
-		})
+		c.Error(service.ErrTeamNotFound)
 		return
 	}
 
-	members, err := h.userRepo.GetUsersByTeam(teamName)
+	members, err := h.userRepo.GetUsersByTeam(ctx, teamName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
+		c.Error(err)
 		return
 	}
 
@@ -147,3 +84,209 @@ func (h *TeamHandler) GetTeam(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, teamWithMembers)
 }
+
+// Функция добавляет участника в существующую команду
+func (h *TeamHandler) AddMember(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req struct {
+		TeamName string `json:"team_name" binding:"required"`
+		UserID   string `json:"user_id" binding:"required"`
+		Username string `json:"username"`
+		IsActive *bool  `json:"is_active"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+	if !h.requireTeam(c, req.TeamName) {
+		return
+	}
+
+	member := models.TeamMember{UserID: req.UserID, Username: req.Username, IsActive: true}
+	existing, err := h.userRepo.GetUserByID(ctx, req.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if existing != nil {
+		if existing.InTeam(req.TeamName) {
+			c.Error(service.ErrAlreadyMember)
+			return
+		}
+		if member.Username == "" {
+			member.Username = existing.Username
+		}
+		member.IsActive = existing.IsActive
+	}
+	if member.Username == "" {
+		c.Error(service.NewValidationError("username is required for a new user"))
+		return
+	}
+	if req.IsActive != nil {
+		member.IsActive = *req.IsActive
+	}
+
+	report, err := h.teamService.AddMember(ctx, req.TeamName, member)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if member.IsActive {
+		h.reconciler.Trigger()
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+// Функция убирает участника из команды
+func (h *TeamHandler) RemoveMember(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req struct {
+		TeamName string `json:"team_name" binding:"required"`
+		UserID   string `json:"user_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+	if !h.requireTeam(c, req.TeamName) {
+		return
+	}
+	user, ok := h.requireUser(c, req.UserID)
+	if !ok {
+		return
+	}
+	if !user.InTeam(req.TeamName) {
+		c.Error(service.ErrNotMember)
+		return
+	}
+
+	report, err := h.teamService.RemoveMember(ctx, req.TeamName, req.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+// Функция переводит участника в другую команду
+func (h *TeamHandler) MoveMember(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req struct {
+		UserID       string `json:"user_id" binding:"required"`
+		FromTeamName string `json:"from_team_name" binding:"required"`
+		ToTeamName   string `json:"to_team_name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+	if !h.requireTeam(c, req.ToTeamName) {
+		return
+	}
+	user, ok := h.requireUser(c, req.UserID)
+	if !ok {
+		return
+	}
+	if !user.InTeam(req.FromTeamName) {
+		c.Error(service.ErrNotMember)
+		return
+	}
+	if user.InTeam(req.ToTeamName) {
+		c.Error(service.ErrAlreadyMember)
+		return
+	}
+
+	report, err := h.teamService.MoveMember(ctx, req.UserID, req.FromTeamName, req.ToTeamName)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if user.IsActive {
+		h.reconciler.Trigger()
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+// Функция переименовывает команду
+func (h *TeamHandler) RenameTeam(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req struct {
+		TeamName    string `json:"team_name" binding:"required"`
+		NewTeamName string `json:"new_team_name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+	if !h.requireTeam(c, req.TeamName) {
+		return
+	}
+	exists, err := h.teamRepo.TeamExists(ctx, req.NewTeamName)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if exists {
+		c.Error(service.ErrTeamExists)
+		return
+	}
+
+	report, err := h.teamService.RenameTeam(ctx, req.TeamName, req.NewTeamName)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+// Функция удаляет команду
+func (h *TeamHandler) DeleteTeam(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req struct {
+		TeamName string `json:"team_name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+	if !h.requireTeam(c, req.TeamName) {
+		return
+	}
+
+	report, err := h.teamService.DeleteTeam(ctx, req.TeamName)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+// Функция проверяет существование команды, ошибку передает в c.Error
+func (h *TeamHandler) requireTeam(c *gin.Context, teamName string) bool {
+	ctx := c.Request.Context()
+	exists, err := h.teamRepo.TeamExists(ctx, teamName)
+	if err != nil {
+		c.Error(err)
+		return false
+	}
+	if !exists {
+		c.Error(service.ErrTeamNotFound)
+		return false
+	}
+	return true
+}
+
+// Функция возвращает пользователя, ошибку передает в c.Error
+func (h *TeamHandler) requireUser(c *gin.Context, userID string) (*models.User, bool) {
+	ctx := c.Request.Context()
+	user, err := h.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+	if user == nil {
+		c.Error(service.ErrUserNotFound)
+		return nil, false
+	}
+	return user, true
+}
@@ -3,78 +3,41 @@ package handler
 import (
 	repository "Backend-trainee-assignment/database"
 	"Backend-trainee-assignment/models"
+	service "Backend-trainee-assignment/services"
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
 type UserHandler struct {
-	userRepo *repository.UserRepository
-	prRepo   *repository.PRRepository
+	userRepo    *repository.UserRepository
+	prRepo      *repository.PRRepository
+	userService *service.UserService
 }
 
-func NewUserHandler(userRepo *repository.UserRepository, prRepo *repository.PRRepository) *UserHandler {
+func NewUserHandler(userRepo *repository.UserRepository, prRepo *repository.PRRepository, userService *service.UserService) *UserHandler {
 	return &UserHandler{
-		userRepo: userRepo,
-		prRepo:   prRepo,
+		userRepo:    userRepo,
+		prRepo:      prRepo,
+		userService: userService,
 	}
 }
 
 // Функция устанавливает флаг активности пользователя
 func (h *UserHandler) SetIsActive(c *gin.Context) {
+	ctx := c.Request.Context()
 	var req struct {
 		UserID   string `json:"user_id" binding:"required"`
 		IsActive *bool  `json:"is_active" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": map[string]interface{}{
-				"code":    "VALIDATION_ERROR",
-				"message": err.Error(),
-			},
-		})
+		c.Error(service.NewValidationError(err.Error()))
 		return
 	}
-	user, err := h.userRepo.GetUserByID(req.UserID)
+	updatedUser, err := h.userService.SetIsActive(ctx, req.UserID, *req.IsActive)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
-		return
-	}
-	if user == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": map[string]interface{}{
-				"code":    "NOT_FOUND",
-				"message": "user not found",
-			},
-		})
-		return
-	}
-
-	// Обновление активности
-	if err := h.userRepo.UpdateUserActiveStatus(req.UserID, *req.IsActive); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
-		return
-
-	}
-
-	updatedUser, err := h.userRepo.GetUserByID(req.UserID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"user": updatedUser})
@@ -82,44 +45,25 @@ func (h *UserHandler) SetIsActive(c *gin.Context) {
 
 // Функция возвращает PR назначенные пользователю
 func (h *UserHandler) GetReview(c *gin.Context) {
+	ctx := c.Request.Context()
 	userID := c.Query("user_id")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": map[string]interface{}{
-				"code":    "VALIDATION_ERROR",
-				"message": "user_id is required",
-			},
-		})
+		c.Error(service.NewValidationError("user_id is required"))
 		return
 	}
-	user, err := h.userRepo.GetUserByID(userID)
+	user, err := h.userRepo.GetUserByID(ctx, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
+		c.Error(err)
 		return
 	}
 	if user == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": map[string]interface{}{
-				"code":    "NOT_FOUND",
-				"message": "user not found",
-			},
-		})
+		c.Error(service.ErrUserNotFound)
 		return
 	}
 
-	prs, err := h.prRepo.GetPRsByReviewer(userID)
+	prs, err := h.prRepo.GetPRsByReviewer(ctx, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": map[string]interface{}{
-				"code":    "INTERNAL_ERROR",
-				"message": err.Error(),
-			},
-		})
+		c.Error(err)
 		return
 	}
 
@@ -137,3 +81,80 @@ func (h *UserHandler) GetReview(c *gin.Context) {
 		"pull_requests": prShorts,
 	})
 }
+
+type skillsRequest struct {
+	UserID string   `json:"user_id" binding:"required"`
+	Skills []string `json:"skills" binding:"required"`
+}
+
+// Функция возвращает навыки пользователя
+func (h *UserHandler) GetSkills(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		c.Error(service.NewValidationError("user_id is required"))
+		return
+	}
+	user, ok := h.requireUser(c, userID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"user_id": user.UserID,
+		"skills":  nonNil(user.Skills),
+	})
+}
+
+// Функция добавляет навыки пользователю
+func (h *UserHandler) AddSkills(c *gin.Context) {
+	h.updateSkills(c, h.userRepo.AddUserSkills)
+}
+
+// Функция удаляет навыки пользователя
+func (h *UserHandler) RemoveSkills(c *gin.Context) {
+	h.updateSkills(c, h.userRepo.RemoveUserSkills)
+}
+
+// Функция заменяет навыки пользователя
+func (h *UserHandler) SetSkills(c *gin.Context) {
+	h.updateSkills(c, h.userRepo.SetUserSkills)
+}
+
+func (h *UserHandler) updateSkills(c *gin.Context, update func(ctx context.Context, userID string, tags []string) error) {
+	ctx := c.Request.Context()
+	var req skillsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+	if _, ok := h.requireUser(c, req.UserID); !ok {
+		return
+	}
+	if err := update(ctx, req.UserID, req.Skills); err != nil {
+		c.Error(err)
+		return
+	}
+
+	user, ok := h.requireUser(c, req.UserID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"user_id": user.UserID,
+		"skills":  nonNil(user.Skills),
+	})
+}
+
+// Функция возвращает пользователя, ошибку передает в c.Error
+func (h *UserHandler) requireUser(c *gin.Context, userID string) (*models.User, bool) {
+	ctx := c.Request.Context()
+	user, err := h.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+	if user == nil {
+		c.Error(service.ErrUserNotFound)
+		return nil, false
+	}
+	return user, true
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"Backend-trainee-assignment/models"
+	service "Backend-trainee-assignment/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+}
+
+func NewWebhookHandler(webhookService *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// Функция возвращает вебхук команды
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+	teamName := c.Query("team_name")
+	if teamName == "" {
+		c.Error(service.NewValidationError("team_name is required"))
+		return
+	}
+
+	webhook, err := h.webhookService.GetTeamWebhook(ctx, teamName)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+// Функция задает вебхук команды
+func (h *WebhookHandler) SetWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req struct {
+		TeamName  string            `json:"team_name" binding:"required"`
+		URL       string            `json:"url" binding:"required"`
+		Channel   string            `json:"channel"`
+		Username  string            `json:"username"`
+		Templates map[string]string `json:"templates"`
+		Enabled   *bool             `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	webhook, err := h.webhookService.SetTeamWebhook(ctx, models.TeamWebhook{
+		TeamName:  req.TeamName,
+		URL:       req.URL,
+		Channel:   req.Channel,
+		Username:  req.Username,
+		Templates: req.Templates,
+		Enabled:   enabled,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+// Функция удаляет вебхук команды
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req struct {
+		TeamName string `json:"team_name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(service.NewValidationError(err.Error()))
+		return
+	}
+
+	if err := h.webhookService.DeleteTeamWebhook(ctx, req.TeamName); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"team_name": req.TeamName, "deleted": true})
+}
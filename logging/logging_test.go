@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{name: "valid", header: "req-1.2_abc", keep: true},
+		{name: "max length", header: strings.Repeat("a", 128), keep: true},
+		{name: "empty", header: ""},
+		{name: "too long", header: strings.Repeat("a", 129)},
+		{name: "spaces", header: "req 1"},
+		{name: "log injection", header: "req\",\"level\":\"ERROR"},
+		{name: "non ascii", header: "запрос"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromContext string
+			router := gin.New()
+			router.Use(RequestIDMiddleware())
+			router.GET("/", func(c *gin.Context) {
+				fromContext = RequestID(c.Request.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(RequestIDHeader)
+			if got != fromContext {
+				t.Fatalf("response id %q, context id %q", got, fromContext)
+			}
+			if tt.keep && got != tt.header {
+				t.Fatalf("id %q, want client id %q", got, tt.header)
+			}
+			if !tt.keep && (got == tt.header || !ValidRequestID(got)) {
+				t.Fatalf("id %q, want new generated id", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "review_service"
+
+// Ограничение времени на запросы доменных показателей при сборе метрик
+const collectTimeout = 5 * time.Second
+
+// Причины переназначения ревьюеров
+const (
+	ReasonManual       = "manual"
+	ReasonMembership   = "membership"
+	ReasonDeactivation = "deactivation"
+	ReasonSLA          = "sla"
+	ReasonImport       = "import"
+	ReasonSCIM         = "scim"
+)
+
+var (
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Длительность HTTP-запросов по маршруту и статусу.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	ReviewersAssigned = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reviewers_assigned_total",
+		Help:      "Количество назначений ревьюеров на PR.",
+	})
+
+	Reassignments = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reviewer_reassignments_total",
+		Help:      "Количество переназначений ревьюеров по причине.",
+	}, []string{"reason"})
+
+	BulkDeactivationDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "bulk_deactivation_duration_seconds",
+		Help:      "Длительность массовой деактивации с переназначением.",
+		Buckets:   []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+	})
+
+	FirstDecisionLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "review_first_decision_seconds",
+		Help:      "Время от назначения ревьюера до его первого решения по PR.",
+		Buckets:   []float64{600, 1800, 3600, 4 * 3600, 8 * 3600, 24 * 3600, 48 * 3600, 72 * 3600},
+	})
+
+	WebhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Доставка уведомлений в вебхуки команд по результату (sent, failed, dropped).",
+	}, []string{"result"})
+
+	EventsDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_dropped_total",
+		Help:      "Количество событий шины, пропущенных медленными подписчиками.",
+	})
+
+	OverdueAssignments = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "sla_overdue_assignments",
+		Help:      "Назначения без решения ревьюера, превысившие SLA команды, на момент последней проверки.",
+	})
+)
+
+// Источник доменных показателей, считываемых при каждом сборе метрик
+type PRStatsSource interface {
+	CountOpenPRsWithoutReviewers(ctx context.Context) (int, error)
+	CountOpenPRsByTeam(ctx context.Context) (map[string]int, error)
+}
+
+// Функция регистрирует метрики пула соединений и доменные показатели
+func Register(db *sql.DB, source PRStatsSource) {
+	prometheus.MustRegister(
+		collectors.NewDBStatsCollector(db, "review_service"),
+		&domainCollector{source: source},
+	)
+}
+
+// Middleware для сбора длительности запросов
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+var (
+	prsWithoutReviewersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "open_pull_requests_without_reviewers"),
+		"Количество открытых PR без ревьюеров.", nil, nil,
+	)
+	openPRsByTeamDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "open_pull_requests"),
+		"Количество открытых PR по целевой команде.", []string{"team"}, nil,
+	)
+)
+
+type domainCollector struct {
+	source PRStatsSource
+}
+
+func (d *domainCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- prsWithoutReviewersDesc
+	ch <- openPRsByTeamDesc
+}
+
+func (d *domainCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	withoutReviewers, err := d.source.CountOpenPRsWithoutReviewers(ctx)
+	if err != nil {
+		slog.Error("failed to collect domain metrics", "error", err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(prsWithoutReviewersDesc, prometheus.GaugeValue, float64(withoutReviewers))
+	}
+
+	byTeam, err := d.source.CountOpenPRsByTeam(ctx)
+	if err != nil {
+		slog.Error("failed to collect domain metrics", "error", err)
+		return
+	}
+	for team, count := range byTeam {
+		ch <- prometheus.MustNewConstMetric(openPRsByTeamDesc, prometheus.GaugeValue, float64(count), team)
+	}
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddlewareExposesRouteAndStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	router.GET("/items/:id", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	for _, path := range []string{"/items/1", "/items/2", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("/metrics status %d", rec.Code)
+	}
+
+	// Маршрут - шаблон, а не путь запроса, незнакомые пути сводятся к unmatched
+	for _, want := range []string{
+		`review_service_http_request_duration_seconds_count{method="GET",route="/items/:id",status="201"} 2`,
+		`review_service_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Fatalf("/metrics has no %s", want)
+		}
+	}
+}
+
+type fakePRStats struct {
+	withoutReviewers int
+	byTeam           map[string]int
+	err              error
+}
+
+func (f fakePRStats) CountOpenPRsWithoutReviewers(ctx context.Context) (int, error) {
+	return f.withoutReviewers, f.err
+}
+
+func (f fakePRStats) CountOpenPRsByTeam(ctx context.Context) (map[string]int, error) {
+	return f.byTeam, nil
+}
+
+func TestDomainCollector(t *testing.T) {
+	tests := []struct {
+		name   string
+		source fakePRStats
+		want   string
+	}{
+		{
+			name:   "open PRs by team",
+			source: fakePRStats{withoutReviewers: 3, byTeam: map[string]int{"backend": 2, "": 1}},
+			want: `
+# HELP review_service_open_pull_requests Количество открытых PR по целевой команде.
+# TYPE review_service_open_pull_requests gauge
+review_service_open_pull_requests{team=""} 1
+review_service_open_pull_requests{team="backend"} 2
+# HELP review_service_open_pull_requests_without_reviewers Количество открытых PR без ревьюеров.
+# TYPE review_service_open_pull_requests_without_reviewers gauge
+review_service_open_pull_requests_without_reviewers 3
+`,
+		},
+		{
+			name:   "failed query skipped",
+			source: fakePRStats{byTeam: map[string]int{"backend": 2}, err: errors.New("connection refused")},
+			want: `
+# HELP review_service_open_pull_requests Количество открытых PR по целевой команде.
+# TYPE review_service_open_pull_requests gauge
+review_service_open_pull_requests{team="backend"} 2
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := testutil.CollectAndCompare(&domainCollector{source: tt.source}, strings.NewReader(tt.want)); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
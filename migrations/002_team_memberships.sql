@@ -0,0 +1,41 @@
+-- Участие пользователей в нескольких командах
+CREATE TABLE IF NOT EXISTS team_memberships (
+    user_id VARCHAR(36) NOT NULL,
+    team_name VARCHAR(255) NOT NULL,
+    joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, team_name),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+-- Целевая команда PR, из которой выбираются ревьюеры
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS team_name VARCHAR(255)
+    REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE SET NULL;
+
+-- Перенос данных из users.team_name
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'users' AND column_name = 'team_name'
+    ) THEN
+        INSERT INTO teams (team_name)
+        SELECT DISTINCT team_name FROM users WHERE team_name IS NOT NULL
+        ON CONFLICT DO NOTHING;
+
+        INSERT INTO team_memberships (user_id, team_name)
+        SELECT user_id, team_name FROM users WHERE team_name IS NOT NULL
+        ON CONFLICT DO NOTHING;
+
+        UPDATE pull_requests pr
+        SET team_name = u.team_name
+        FROM users u
+        WHERE u.user_id = pr.author_id AND pr.team_name IS NULL;
+
+        DROP INDEX IF EXISTS idx_users_team_name;
+        ALTER TABLE users DROP COLUMN team_name;
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_team_memberships_team_name ON team_memberships(team_name);
+CREATE INDEX IF NOT EXISTS idx_pr_team_name ON pull_requests(team_name);
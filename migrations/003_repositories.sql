@@ -0,0 +1,29 @@
+-- Репозитории и их пулы ревьюеров
+CREATE TABLE IF NOT EXISTS repositories (
+    repository_id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS repository_teams (
+    repository_id VARCHAR(36) NOT NULL,
+    team_name VARCHAR(255) NOT NULL,
+    PRIMARY KEY (repository_id, team_name),
+    FOREIGN KEY (repository_id) REFERENCES repositories(repository_id) ON DELETE CASCADE,
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+-- Дополнительные (EXTRA) и исключенные (EXCLUDED) ревьюеры репозитория
+CREATE TABLE IF NOT EXISTS repository_reviewers (
+    repository_id VARCHAR(36) NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('EXTRA', 'EXCLUDED')),
+    PRIMARY KEY (repository_id, user_id),
+    FOREIGN KEY (repository_id) REFERENCES repositories(repository_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS repository_id VARCHAR(36)
+    REFERENCES repositories(repository_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_pr_repository_id ON pull_requests(repository_id);
@@ -0,0 +1,16 @@
+-- Навыки ревьюеров и метки PR
+CREATE TABLE IF NOT EXISTS user_skills (
+    user_id VARCHAR(36) NOT NULL,
+    tag VARCHAR(64) NOT NULL,
+    PRIMARY KEY (user_id, tag),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS pr_labels (
+    pr_id VARCHAR(36) NOT NULL,
+    label VARCHAR(64) NOT NULL,
+    PRIMARY KEY (pr_id, label),
+    FOREIGN KEY (pr_id) REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_skills_tag ON user_skills(tag);
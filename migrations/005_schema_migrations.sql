@@ -0,0 +1,10 @@
+-- Учет примененных миграций, проверяется в /readyz. Версии 1-5 записываются здесь для баз,
+-- созданных до появления Migrate: в них эти миграции уже применены, дальше версии записывает Migrate
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO schema_migrations (version)
+VALUES (1), (2), (3), (4), (5)
+ON CONFLICT DO NOTHING;
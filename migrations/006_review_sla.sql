@@ -0,0 +1,16 @@
+-- Первое решение ревьюера по PR, от assigned_at до него считается время реакции
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS first_decision_at TIMESTAMP NULL;
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS decision VARCHAR(20) NULL
+    CHECK (decision IN ('APPROVED', 'CHANGES_REQUESTED', 'COMMENTED'));
+
+CREATE INDEX IF NOT EXISTS idx_pr_reviewers_pending ON pr_reviewers(assigned_at)
+    WHERE first_decision_at IS NULL;
+
+-- Порог SLA команды; команды без записи используют значение по умолчанию из конфигурации
+CREATE TABLE IF NOT EXISTS team_sla (
+    team_name VARCHAR(255) PRIMARY KEY,
+    threshold_hours INTEGER NOT NULL CHECK (threshold_hours > 0),
+    auto_reassign BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE
+);
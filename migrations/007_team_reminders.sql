@@ -0,0 +1,13 @@
+-- Расписания напоминаний команды в формате cron: дайджесты ожидающих ревью и эскалации.
+-- last_*_at - время последнего запуска, по нему экземпляры сервиса не отправляют напоминание дважды
+CREATE TABLE IF NOT EXISTS team_reminders (
+    team_name VARCHAR(255) PRIMARY KEY,
+    digest_schedule VARCHAR(100) NOT NULL DEFAULT '',
+    escalation_schedule VARCHAR(100) NOT NULL DEFAULT '',
+    escalation_after_hours INTEGER NOT NULL DEFAULT 24 CHECK (escalation_after_hours > 0),
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    last_digest_at TIMESTAMP NULL,
+    last_escalation_at TIMESTAMP NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE
+);
@@ -0,0 +1,12 @@
+-- Входящий вебхук Slack/Mattermost команды: канал для уведомлений о назначениях
+-- templates - шаблоны сообщений команды по типу события, переопределяют шаблоны по умолчанию
+CREATE TABLE IF NOT EXISTS team_webhooks (
+    team_name VARCHAR(255) PRIMARY KEY,
+    url TEXT NOT NULL,
+    channel VARCHAR(255) NOT NULL DEFAULT '',
+    username VARCHAR(255) NOT NULL DEFAULT '',
+    templates JSONB NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE
+);
@@ -0,0 +1,10 @@
+-- Адрес для уведомлений по почте
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email VARCHAR(320) NULL;
+
+-- Виды писем, от которых пользователь отказался: assignment, digest, escalation
+CREATE TABLE IF NOT EXISTS email_opt_outs (
+    user_id VARCHAR(36) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('assignment', 'digest', 'escalation')),
+    PRIMARY KEY (user_id, kind),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
@@ -0,0 +1,5 @@
+-- Индексы для статистики за период: выборки по времени создания, мержа и назначения
+CREATE INDEX IF NOT EXISTS idx_pr_created_at ON pull_requests(created_at);
+CREATE INDEX IF NOT EXISTS idx_pr_merged_at ON pull_requests(merged_at)
+    WHERE merged_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_pr_reviewers_assigned_at ON pr_reviewers(assigned_at);
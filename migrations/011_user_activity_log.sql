@@ -0,0 +1,34 @@
+-- История активности пользователей: отчет о справедливости не считает время отпуска
+-- и деактивации. Каждая запись действует до следующей записи того же пользователя
+CREATE TABLE IF NOT EXISTS user_activity_log (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    is_active BOOLEAN NOT NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_activity_log_user ON user_activity_log(user_id, changed_at);
+
+-- Изменения is_active записываются триггером, чтобы история не зависела от пути изменения
+CREATE OR REPLACE FUNCTION log_user_activity() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' OR OLD.is_active IS DISTINCT FROM NEW.is_active THEN
+        INSERT INTO user_activity_log (user_id, is_active)
+        VALUES (NEW.user_id, COALESCE(NEW.is_active, FALSE));
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_users_activity_log ON users;
+CREATE TRIGGER trg_users_activity_log
+    AFTER INSERT OR UPDATE OF is_active ON users
+    FOR EACH ROW EXECUTE FUNCTION log_user_activity();
+
+-- Для существующих пользователей момент деактивации неизвестен, текущий статус
+-- считается действующим с момента создания
+INSERT INTO user_activity_log (user_id, is_active, changed_at)
+SELECT user_id, COALESCE(is_active, FALSE), COALESCE(created_at, CURRENT_TIMESTAMP)
+FROM users
+WHERE NOT EXISTS (SELECT 1 FROM user_activity_log);
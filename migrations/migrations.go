@@ -0,0 +1,9 @@
+// Пакет встраивает SQL-миграции в бинарник, их применяет repository.Migrate при старте сервера
+package migrations
+
+import "embed"
+
+// Файлы миграций вида NNN_описание.sql, NNN - версия схемы
+//
+//go:embed *.sql
+var Files embed.FS
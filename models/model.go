@@ -5,10 +5,21 @@ import (
 )
 
 type User struct {
-	UserID   string `json:"user_id" db:"user_id"`
-	Username string `json:"username" db:"username"`
-	TeamName string `json:"team_name" db:"team_name"`
-	IsActive bool   `json:"is_active" db:"is_active"`
+	UserID   string   `json:"user_id" db:"user_id"`
+	Username string   `json:"username" db:"username"`
+	Teams    []string `json:"teams,omitempty" db:"teams"`
+	Skills   []string `json:"skills,omitempty" db:"skills"`
+	IsActive bool     `json:"is_active" db:"is_active"`
+}
+
+// Функция проверяет, состоит ли пользователь в команде
+func (u *User) InTeam(teamName string) bool {
+	for _, team := range u.Teams {
+		if team == teamName {
+			return true
+		}
+	}
+	return false
 }
 
 type Team struct {
@@ -26,6 +37,9 @@ type PullRequest struct {
 	PullRequestID     string     `json:"pull_request_id" db:"pull_request_id"`
 	PullRequestName   string     `json:"pull_request_name" db:"pull_request_name"`
 	AuthorID          string     `json:"author_id" db:"author_id"`
+	TeamName          string     `json:"team_name,omitempty" db:"team_name"`
+	RepositoryID      string     `json:"repository_id,omitempty" db:"repository_id"`
+	Labels            []string   `json:"labels,omitempty" db:"labels"`
 	Status            string     `json:"status" db:"status"`
 	AssignedReviewers []string   `json:"assigned_reviewers" db:"assigned_reviewers"`
 	CreatedAt         time.Time  `json:"createdAt,omitempty" db:"created_at"`
@@ -38,3 +52,351 @@ type PullRequestShort struct {
 	AuthorID        string `json:"author_id"`
 	Status          string `json:"status"`
 }
+
+type Reassignment struct {
+	PullRequestID string `json:"pull_request_id"`
+	OldReviewerID string `json:"old_reviewer_id"`
+	NewReviewerID string `json:"new_reviewer_id,omitempty"`
+}
+
+// Отчет об изменении состава команды
+type MembershipReport struct {
+	Action        string         `json:"action"`
+	TeamName      string         `json:"team_name"`
+	NewTeamName   string         `json:"new_team_name,omitempty"`
+	AffectedUsers []string       `json:"affected_users"`
+	Reassignments []Reassignment `json:"reassignments"`
+}
+
+// План импорта оргструктуры: что изменится в командах и пользователях и какие открытые ревью
+// будут переназначены. При dry run план только рассчитывается
+type ImportPlan struct {
+	DryRun            bool           `json:"dry_run"`
+	Applied           bool           `json:"applied"`
+	TeamsToCreate     []string       `json:"teams_to_create"`
+	UsersToAdd        []ImportUser   `json:"users_to_add"`
+	UsersToMove       []ImportMove   `json:"users_to_move"`
+	UsersToRename     []ImportRename `json:"users_to_rename"`
+	UsersToActivate   []string       `json:"users_to_activate"`
+	UsersToDeactivate []string       `json:"users_to_deactivate"`
+	Reassignments     []Reassignment `json:"reassignments"`
+}
+
+// Новый пользователь из импорта
+type ImportUser struct {
+	UserID   string   `json:"user_id"`
+	Username string   `json:"username"`
+	IsActive bool     `json:"is_active"`
+	Teams    []string `json:"teams"`
+}
+
+// Изменение состава команд существующего пользователя
+type ImportMove struct {
+	UserID      string   `json:"user_id"`
+	AddTeams    []string `json:"add_teams"`
+	RemoveTeams []string `json:"remove_teams"`
+}
+
+// Смена имени существующего пользователя
+type ImportRename struct {
+	UserID      string `json:"user_id"`
+	OldUsername string `json:"old_username"`
+	Username    string `json:"username"`
+}
+
+// Репозиторий с пулом ревьюеров: участники команд и дополнительные ревьюеры без исключенных
+type Repository struct {
+	RepositoryID      string   `json:"repository_id" db:"repository_id"`
+	Name              string   `json:"name" db:"name"`
+	Teams             []string `json:"teams"`
+	ExtraReviewers    []string `json:"extra_reviewers"`
+	ExcludedReviewers []string `json:"excluded_reviewers"`
+}
+
+// Статистика назначений ревьюеров
+type AssignmentStats struct {
+	UserAssignments  map[string]int `json:"user_assignments"`
+	PRAssignments    map[string]int `json:"pr_assignments"`
+	TotalAssignments int            `json:"total_assignments"`
+	ActivePRs        int            `json:"active_prs"`
+	MergedPRs        int            `json:"merged_prs"`
+}
+
+// Параметры статистики: полуинтервал [From, To), команда PR, группировка по периодам и размер топа ревьюеров
+type StatsQuery struct {
+	From     *time.Time
+	To       *time.Time
+	TeamName string
+	GroupBy  string
+	Top      int
+}
+
+// Статистика за период, посчитанная агрегатами в БД
+type StatsReport struct {
+	From         *time.Time     `json:"from,omitempty"`
+	To           *time.Time     `json:"to,omitempty"`
+	TeamName     string         `json:"team_name,omitempty"`
+	GroupBy      string         `json:"group_by,omitempty"`
+	Totals       StatsCounts    `json:"totals"`
+	Periods      []PeriodStats  `json:"periods,omitempty"`
+	Teams        []TeamStats    `json:"teams"`
+	Latency      ReviewLatency  `json:"latency"`
+	TopReviewers []ReviewerLoad `json:"top_reviewers"`
+}
+
+// Счетчики за период: назначения ревьюеров, созданные, смерженные и еще открытые из созданных PR
+type StatsCounts struct {
+	Assignments int `json:"assignments"`
+	CreatedPRs  int `json:"created_prs"`
+	MergedPRs   int `json:"merged_prs"`
+	OpenPRs     int `json:"open_prs"`
+}
+
+// Счетчики одного периода группировки
+type PeriodStats struct {
+	PeriodStart time.Time `json:"period_start"`
+	StatsCounts
+}
+
+// Счетчики PR одной команды; PR без команды попадают в пустое имя
+type TeamStats struct {
+	TeamName string `json:"team_name"`
+	StatsCounts
+}
+
+// Перцентили длительностей в секундах
+type LatencyPercentiles struct {
+	Count int     `json:"count"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P95   float64 `json:"p95"`
+	P99   float64 `json:"p99"`
+}
+
+// Время до мержа PR, смерженных за период: от создания и от назначения каждого ревьюера
+type ReviewLatency struct {
+	TimeToMerge       LatencyPercentiles `json:"time_to_merge"`
+	AssignmentToMerge LatencyPercentiles `json:"assignment_to_merge"`
+}
+
+// Нагрузка ревьюера за период
+type ReviewerLoad struct {
+	UserID          string `json:"user_id"`
+	Username        string `json:"username"`
+	Assignments     int    `json:"assignments"`
+	OpenAssignments int    `json:"open_assignments"`
+}
+
+// Параметры отчета о справедливости назначений: период [From, To), команда, разбивка и допуск отклонения от ожидаемой доли
+type FairnessQuery struct {
+	From      time.Time
+	To        time.Time
+	TeamName  string
+	GroupBy   string
+	Tolerance float64
+}
+
+// Отчет о равномерности нагрузки ревьюеров по командам и периодам
+type FairnessReport struct {
+	From      time.Time      `json:"from"`
+	To        time.Time      `json:"to"`
+	TeamName  string         `json:"team_name,omitempty"`
+	GroupBy   string         `json:"group_by,omitempty"`
+	Tolerance float64        `json:"tolerance"`
+	Teams     []TeamFairness `json:"teams"`
+}
+
+// Нагрузка команды за один период. Gini и StdDev считаются по назначениям на активный день
+// участников, которые были активны в периоде
+type TeamFairness struct {
+	TeamName         string           `json:"team_name"`
+	PeriodStart      time.Time        `json:"period_start"`
+	PeriodEnd        time.Time        `json:"period_end"`
+	TotalAssignments int              `json:"total_assignments"`
+	Gini             float64          `json:"gini"`
+	StdDev           float64          `json:"stddev"`
+	Members          []MemberFairness `json:"members"`
+	Overloaded       []string         `json:"overloaded"`
+	Underloaded      []string         `json:"underloaded"`
+}
+
+// Доля назначений участника против ожидаемой по числу его активных дней
+type MemberFairness struct {
+	UserID              string  `json:"user_id"`
+	Username            string  `json:"username"`
+	ActiveDays          float64 `json:"active_days"`
+	Assignments         int     `json:"assignments"`
+	Share               float64 `json:"share"`
+	ExpectedShare       float64 `json:"expected_share"`
+	ExpectedAssignments float64 `json:"expected_assignments"`
+	// over, under, fair или inactive для не работавших в периоде
+	Status string `json:"status"`
+}
+
+// Фильтры выгрузок; пустые поля не ограничивают выборку
+type ExportFilter struct {
+	TeamName string
+	UserID   string
+	Status   string
+	From     *time.Time
+	To       *time.Time
+}
+
+// Строка выгрузки PR с ревьюерами
+type PRExport struct {
+	PullRequestID   string     `json:"pull_request_id"`
+	PullRequestName string     `json:"pull_request_name"`
+	AuthorID        string     `json:"author_id"`
+	TeamName        string     `json:"team_name"`
+	RepositoryID    string     `json:"repository_id"`
+	Status          string     `json:"status"`
+	CreatedAt       time.Time  `json:"created_at"`
+	MergedAt        *time.Time `json:"merged_at"`
+	Reviewers       []string   `json:"reviewers"`
+}
+
+// Строка выгрузки истории назначений
+type AssignmentExport struct {
+	PullRequestID   string     `json:"pull_request_id"`
+	PullRequestName string     `json:"pull_request_name"`
+	TeamName        string     `json:"team_name"`
+	ReviewerID      string     `json:"reviewer_id"`
+	AssignedAt      time.Time  `json:"assigned_at"`
+	FirstDecisionAt *time.Time `json:"first_decision_at"`
+	Decision        string     `json:"decision"`
+	Status          string     `json:"status"`
+	MergedAt        *time.Time `json:"merged_at"`
+}
+
+// Строка выгрузки статистики пользователя за период
+type UserStatsExport struct {
+	UserID                string   `json:"user_id"`
+	Username              string   `json:"username"`
+	IsActive              bool     `json:"is_active"`
+	Teams                 []string `json:"teams"`
+	Assignments           int      `json:"assignments"`
+	OpenAssignments       int      `json:"open_assignments"`
+	Approved              int      `json:"approved"`
+	ChangesRequested      int      `json:"changes_requested"`
+	Commented             int      `json:"commented"`
+	AvgFirstDecisionHours *float64 `json:"avg_first_decision_hours"`
+}
+
+// Порог SLA на первое решение ревьюера для команды
+type TeamSLA struct {
+	TeamName       string `json:"team_name" db:"team_name"`
+	ThresholdHours int    `json:"threshold_hours" db:"threshold_hours"`
+	AutoReassign   bool   `json:"auto_reassign" db:"auto_reassign"`
+	IsDefault      bool   `json:"is_default"`
+}
+
+// Первое решение ревьюера по PR и время реакции
+type ReviewDecision struct {
+	PullRequestID   string    `json:"pull_request_id" db:"pr_id"`
+	ReviewerID      string    `json:"reviewer_id" db:"reviewer_user_id"`
+	Decision        string    `json:"decision" db:"decision"`
+	AssignedAt      time.Time `json:"assigned_at" db:"assigned_at"`
+	FirstDecisionAt time.Time `json:"first_decision_at" db:"first_decision_at"`
+	ResponseSeconds int64     `json:"response_seconds"`
+}
+
+// Назначение ревьюера без решения, у которого истек срок SLA
+type OverdueAssignment struct {
+	PullRequestID   string    `json:"pull_request_id" db:"pr_id"`
+	PullRequestName string    `json:"pull_request_name" db:"pull_request_name"`
+	TeamName        string    `json:"team_name,omitempty" db:"team_name"`
+	ReviewerID      string    `json:"reviewer_id" db:"reviewer_user_id"`
+	AssignedAt      time.Time `json:"assigned_at" db:"assigned_at"`
+	ThresholdHours  int       `json:"threshold_hours" db:"threshold_hours"`
+	AutoReassign    bool      `json:"auto_reassign" db:"auto_reassign"`
+	Deadline        time.Time `json:"deadline"`
+	OverdueSeconds  int64     `json:"overdue_seconds"`
+}
+
+// Расписания напоминаний команды
+type TeamReminders struct {
+	TeamName             string     `json:"team_name" db:"team_name"`
+	DigestSchedule       string     `json:"digest_schedule" db:"digest_schedule"`
+	EscalationSchedule   string     `json:"escalation_schedule" db:"escalation_schedule"`
+	EscalationAfterHours int        `json:"escalation_after_hours" db:"escalation_after_hours"`
+	Timezone             string     `json:"timezone" db:"timezone"`
+	LastDigestAt         *time.Time `json:"last_digest_at,omitempty" db:"last_digest_at"`
+	LastEscalationAt     *time.Time `json:"last_escalation_at,omitempty" db:"last_escalation_at"`
+	UpdatedAt            time.Time  `json:"-" db:"updated_at"`
+}
+
+// Назначение ревьюера на открытый PR, по которому еще нет решения
+type PendingReview struct {
+	PullRequestID   string    `json:"pull_request_id" db:"pr_id"`
+	PullRequestName string    `json:"pull_request_name" db:"pull_request_name"`
+	AuthorID        string    `json:"author_id" db:"author_id"`
+	TeamName        string    `json:"team_name" db:"team_name"`
+	ReviewerID      string    `json:"reviewer_id" db:"reviewer_user_id"`
+	AssignedAt      time.Time `json:"assigned_at" db:"assigned_at"`
+}
+
+// Входящий вебхук Slack/Mattermost команды
+type TeamWebhook struct {
+	TeamName  string            `json:"team_name" db:"team_name"`
+	URL       string            `json:"url" db:"url"`
+	Channel   string            `json:"channel,omitempty" db:"channel"`
+	Username  string            `json:"username,omitempty" db:"username"`
+	Templates map[string]string `json:"templates,omitempty" db:"templates"`
+	Enabled   bool              `json:"enabled" db:"enabled"`
+}
+
+// Настройки почтовых уведомлений пользователя
+type NotificationPreferences struct {
+	UserID      string   `json:"user_id" db:"user_id"`
+	Email       string   `json:"email" db:"email"`
+	EmailOptOut []string `json:"email_opt_out" db:"email_opt_out"`
+}
+
+// Получатель письма
+type EmailRecipient struct {
+	UserID   string `json:"user_id" db:"user_id"`
+	Username string `json:"username" db:"username"`
+	Email    string `json:"email" db:"email"`
+}
+
+// Отчет массовой деактивации: кто деактивирован и что стало с каждым открытым ревью.
+// При dry run отчет только рассчитывается
+type DeactivationReport struct {
+	TeamName           string                     `json:"team_name,omitempty"`
+	DryRun             bool                       `json:"dry_run"`
+	DeactivatedUsers   int                        `json:"deactivated_users"`
+	DeactivatedUserIDs []string                   `json:"deactivated_user_ids"`
+	SkippedUsers       []SkippedUser              `json:"skipped_users"`
+	ReassignedPRs      []string                   `json:"reassigned_prs"`
+	Reassignments      []DeactivationReassignment `json:"reassignments"`
+}
+
+// Пользователь из запроса, которого не нужно деактивировать
+type SkippedUser struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+// Судьба одного ревью деактивируемого пользователя
+type DeactivationReassignment struct {
+	PullRequestID string `json:"pull_request_id"`
+	OldReviewerID string `json:"old_reviewer_id"`
+	NewReviewerID string `json:"new_reviewer_id,omitempty"`
+	Status        string `json:"status"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// Состояние для массовой деактивации, прочитанное под блокировками
+type DeactivationState struct {
+	Users []User
+	PRs   []PullRequest
+	// Активные кандидаты пулов с числом их открытых ревью, ключ - команда или репозиторий
+	TeamPools       map[string][]ReviewerCandidate
+	RepositoryPools map[string][]ReviewerCandidate
+}
+
+// Кандидат в ревьюеры с текущей нагрузкой
+type ReviewerCandidate struct {
+	UserID      string
+	OpenReviews int
+}
@@ -0,0 +1,251 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"sync"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates/email.txt.tmpl templates/email.html.tmpl
+var emailTemplates embed.FS
+
+// Письмо с текстовой и HTML-версией
+type Email struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Способ отправки писем
+type Mailer interface {
+	Send(ctx context.Context, email Email) error
+}
+
+// Отправка писем через SMTP-сервер
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// Функция создает отправителя; при пустом username авторизация не используется
+func NewSMTPMailer(addr, from, username, password string) *SMTPMailer {
+	mailer := &SMTPMailer{addr: addr, from: from}
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		mailer.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return mailer
+}
+
+// Функция отправляет письмо
+func (m *SMTPMailer) Send(ctx context.Context, email Email) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	msg, err := buildMessage(m.from, email)
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{email.To}, msg)
+}
+
+// Функция собирает письмо multipart/alternative с текстовой и HTML-частью
+func buildMessage(from string, email Email) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", email.Text},
+		{"text/html; charset=utf-8", email.HTML},
+	} {
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", email.To)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// Пункт письма: одно изменение, касающееся получателя
+type EmailItem struct {
+	Kind string
+	PRID string
+	Text string
+}
+
+// Настройки группировки и повторной отправки писем
+type EmailBatchConfig struct {
+	// Сколько ждать остальные изменения после первого, прежде чем отправить письмо
+	Window      time.Duration
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+type emailBatch struct {
+	name  string
+	items []EmailItem
+	timer *time.Timer
+	// Request ID первого изменения для сквозных логов
+	ctx context.Context
+}
+
+// Группировка изменений по получателю: все изменения за окно Window уходят одним письмом,
+// например после массового переназначения
+type EmailBatcher struct {
+	mailer Mailer
+	cfg    EmailBatchConfig
+	text   *texttemplate.Template
+	html   *htmltemplate.Template
+
+	mu      sync.Mutex
+	closed  bool
+	pending map[string]*emailBatch
+	wg      sync.WaitGroup
+}
+
+func NewEmailBatcher(mailer Mailer, cfg EmailBatchConfig) *EmailBatcher {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	return &EmailBatcher{
+		mailer:  mailer,
+		cfg:     cfg,
+		text:    texttemplate.Must(texttemplate.ParseFS(emailTemplates, "templates/email.txt.tmpl")),
+		html:    htmltemplate.Must(htmltemplate.ParseFS(emailTemplates, "templates/email.html.tmpl")),
+		pending: make(map[string]*emailBatch),
+	}
+}
+
+// Функция добавляет изменение в письмо получателю to; name - имя в обращении
+func (b *EmailBatcher) Add(ctx context.Context, to, name string, item EmailItem) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return false
+	}
+	if batch, ok := b.pending[to]; ok {
+		batch.items = append(batch.items, item)
+		return true
+	}
+
+	batch := &emailBatch{name: name, items: []EmailItem{item}, ctx: context.WithoutCancel(ctx)}
+	b.pending[to] = batch
+	b.wg.Add(1)
+	batch.timer = time.AfterFunc(b.cfg.Window, func() { b.flush(to) })
+	return true
+}
+
+// Функция прекращает прием изменений, сразу отправляет накопленные письма и ждет отправки, но не дольше ctx
+func (b *EmailBatcher) Close(ctx context.Context) {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		for to, batch := range b.pending {
+			// Если таймер уже сработал, письмо отправит он
+			if batch.timer.Stop() {
+				go b.flush(to)
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Warn("pending emails were not sent before shutdown")
+	}
+}
+
+// Функция отправляет накопленное письмо, повторяя неудачные попытки
+func (b *EmailBatcher) flush(to string) {
+	b.mu.Lock()
+	batch := b.pending[to]
+	delete(b.pending, to)
+	b.mu.Unlock()
+	if batch == nil {
+		return
+	}
+	defer b.wg.Done()
+
+	email, err := b.render(to, batch)
+	if err != nil {
+		slog.ErrorContext(batch.ctx, "failed to render email", "error", err)
+		return
+	}
+	backoff := b.cfg.Backoff
+	for attempt := 1; ; attempt++ {
+		err := b.mailer.Send(batch.ctx, email)
+		if err == nil {
+			slog.InfoContext(batch.ctx, "email sent", "items", len(batch.items))
+			return
+		}
+		if attempt >= b.cfg.MaxAttempts {
+			slog.ErrorContext(batch.ctx, "email delivery failed", "attempts", attempt, "error", err)
+			return
+		}
+		slog.WarnContext(batch.ctx, "email delivery failed, retrying", "attempt", attempt, "retry_in", backoff, "error", err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (b *EmailBatcher) render(to string, batch *emailBatch) (Email, error) {
+	data := struct {
+		Name  string
+		Items []EmailItem
+	}{batch.name, batch.items}
+
+	var text, html bytes.Buffer
+	if err := b.text.Execute(&text, data); err != nil {
+		return Email{}, err
+	}
+	if err := b.html.Execute(&html, data); err != nil {
+		return Email{}, err
+	}
+
+	subject := fmt.Sprintf("%d review updates", len(batch.items))
+	if len(batch.items) == 1 {
+		subject = batch.items[0].Text
+	}
+	return Email{To: to, Subject: subject, Text: text.String(), HTML: html.String()}, nil
+}
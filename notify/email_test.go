@@ -0,0 +1,247 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Письмо, принятое тестовым SMTP-сервером
+type receivedEmail struct {
+	to      string
+	subject string
+	text    string
+}
+
+// Минимальный SMTP-сервер на net.Listener: принимает письма без авторизации и TLS,
+// первые reject попыток DATA отклоняет временной ошибкой 451
+type fakeSMTP struct {
+	listener net.Listener
+	mu       sync.Mutex
+	reject   int
+	attempts int
+	received []receivedEmail
+	notify   chan struct{}
+}
+
+func newFakeSMTP(t *testing.T, reject int) *fakeSMTP {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTP{listener: listener, reject: reject, notify: make(chan struct{}, 100)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(t, conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *fakeSMTP) serve(t *testing.T, conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 fake ESMTP")
+	var to string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 fake")
+		case strings.HasPrefix(cmd, "MAIL FROM"):
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO"):
+			to = strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>")
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			s.mu.Lock()
+			s.attempts++
+			rejected := s.attempts <= s.reject
+			if !rejected {
+				s.received = append(s.received, parseEmail(t, to, data.String()))
+			}
+			s.mu.Unlock()
+			if rejected {
+				reply("451 try again later")
+			} else {
+				reply("250 queued")
+			}
+			s.notify <- struct{}{}
+		case cmd == "RSET", cmd == "NOOP":
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+// Функция разбирает письмо и возвращает тему и текстовую часть
+func parseEmail(t *testing.T, to, data string) receivedEmail {
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Errorf("read message: %v", err)
+		return receivedEmail{to: to}
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	_, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	part, err := multipart.NewReader(msg.Body, params["boundary"]).NextPart()
+	if err != nil {
+		t.Errorf("read text part: %v", err)
+		return receivedEmail{to: to, subject: subject}
+	}
+	text, _ := io.ReadAll(part)
+	return receivedEmail{to: to, subject: subject, text: string(text)}
+}
+
+func (s *fakeSMTP) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeSMTP) state() (int, []receivedEmail) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts, append([]receivedEmail(nil), s.received...)
+}
+
+// Функция ждет n попыток отправки
+func (s *fakeSMTP) waitAttempts(t *testing.T, n int) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for i := 0; i < n; i++ {
+		select {
+		case <-s.notify:
+		case <-timeout:
+			attempts, _ := s.state()
+			t.Fatalf("attempts %d, want %d", attempts, n)
+		}
+	}
+}
+
+func newTestBatcher(server *fakeSMTP, cfg EmailBatchConfig) *EmailBatcher {
+	return NewEmailBatcher(NewSMTPMailer(server.addr(), "review-service@example.com", "", ""), cfg)
+}
+
+func closeBatcher(t *testing.T, b *EmailBatcher) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	b.Close(ctx)
+}
+
+func TestEmailBatcherGroupsWithinWindow(t *testing.T) {
+	server := newFakeSMTP(t, 0)
+	batcher := newTestBatcher(server, EmailBatchConfig{Window: 100 * time.Millisecond, MaxAttempts: 1})
+	defer closeBatcher(t, batcher)
+	ctx := context.Background()
+
+	for _, text := range []string{"Assigned to pr-1", "Assigned to pr-2", "Removed from pr-3"} {
+		batcher.Add(ctx, "alice@example.com", "Alice", EmailItem{Kind: "assignment", Text: text})
+	}
+	batcher.Add(ctx, "bob@example.com", "Bob", EmailItem{Kind: "assignment", Text: "Assigned to pr-1"})
+	server.waitAttempts(t, 2)
+
+	// Изменение после отправки попадает в новое письмо
+	batcher.Add(ctx, "alice@example.com", "Alice", EmailItem{Kind: "digest", Text: "pr-4 is waiting"})
+	server.waitAttempts(t, 1)
+
+	_, received := server.state()
+	byRecipient := make(map[string][]receivedEmail)
+	for _, email := range received {
+		byRecipient[email.to] = append(byRecipient[email.to], email)
+	}
+	alice, bob := byRecipient["alice@example.com"], byRecipient["bob@example.com"]
+	if len(alice) != 2 || len(bob) != 1 {
+		t.Fatalf("emails to alice %d, bob %d, want 2 and 1", len(alice), len(bob))
+	}
+	if alice[0].subject != "3 review updates" || !strings.Contains(alice[0].text, "Hello, Alice!") {
+		t.Fatalf("batched email %+v", alice[0])
+	}
+	for _, text := range []string{"Assigned to pr-1", "Assigned to pr-2", "Removed from pr-3"} {
+		if !strings.Contains(alice[0].text, text) {
+			t.Fatalf("batched email text %q has no %q", alice[0].text, text)
+		}
+	}
+	if alice[1].subject != "pr-4 is waiting" || bob[0].subject != "Assigned to pr-1" {
+		t.Fatalf("single item subjects %q, %q", alice[1].subject, bob[0].subject)
+	}
+}
+
+func TestEmailBatcherRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		reject       int
+		maxAttempts  int
+		wantAttempts int
+		wantSent     bool
+	}{
+		{name: "sent after temporary errors", reject: 2, maxAttempts: 3, wantAttempts: 3, wantSent: true},
+		{name: "gives up after max attempts", reject: 5, maxAttempts: 2, wantAttempts: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTP(t, tt.reject)
+			batcher := newTestBatcher(server, EmailBatchConfig{Window: time.Millisecond, MaxAttempts: tt.maxAttempts, Backoff: 10 * time.Millisecond})
+			batcher.Add(context.Background(), "alice@example.com", "Alice", EmailItem{Text: "Assigned to pr-1"})
+			server.waitAttempts(t, tt.wantAttempts)
+			closeBatcher(t, batcher)
+
+			attempts, received := server.state()
+			if attempts != tt.wantAttempts {
+				t.Fatalf("attempts %d, want %d", attempts, tt.wantAttempts)
+			}
+			if sent := len(received) == 1; sent != tt.wantSent {
+				t.Fatalf("sent %v, want %v", sent, tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestEmailBatcherCloseFlushesPending(t *testing.T) {
+	server := newFakeSMTP(t, 0)
+	batcher := newTestBatcher(server, EmailBatchConfig{Window: time.Hour, MaxAttempts: 1})
+	ctx := context.Background()
+	batcher.Add(ctx, "alice@example.com", "Alice", EmailItem{Text: "Assigned to pr-1"})
+	batcher.Add(ctx, "bob@example.com", "Bob", EmailItem{Text: "Assigned to pr-2"})
+
+	closeBatcher(t, batcher)
+	if _, received := server.state(); len(received) != 2 {
+		t.Fatalf("sent %d before Close returned, want 2", len(received))
+	}
+	if batcher.Add(ctx, "alice@example.com", "Alice", EmailItem{Text: "late"}) {
+		t.Fatal("item added after Close")
+	}
+}
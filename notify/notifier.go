@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"Backend-trainee-assignment/models"
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// Виды уведомлений
+const (
+	KindDigest     = "digest"
+	KindEscalation = "escalation"
+)
+
+// Уведомление для участников команды
+type Message struct {
+	Kind     string
+	TeamName string
+	// Пользователи, которым адресовано уведомление
+	Recipients []string
+	Subject    string
+	Text       string
+	Reviews    []models.PendingReview
+}
+
+// Способ доставки уведомлений
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// Notifier, который только пишет уведомления в лог
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+// Функция пишет уведомление в лог
+func (n *LogNotifier) Notify(ctx context.Context, msg Message) error {
+	slog.InfoContext(ctx, "notification",
+		"kind", msg.Kind, "team_name", msg.TeamName, "recipients", msg.Recipients,
+		"subject", msg.Subject, "reviews", len(msg.Reviews))
+	return nil
+}
+
+// Notifier, доставляющий уведомление всеми перечисленными способами
+type MultiNotifier []Notifier
+
+// Функция отправляет уведомление каждому способу доставки и объединяет ошибки
+func (m MultiNotifier) Notify(ctx context.Context, msg Message) error {
+	var errs []error
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,39 @@
+package notify
+
+import (
+	"context"
+	"sync"
+)
+
+// Notifier, который запоминает отправленные уведомления; используется для проверки расписаний
+// без внешних каналов доставки
+type RecordingNotifier struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewRecordingNotifier() *RecordingNotifier {
+	return &RecordingNotifier{}
+}
+
+// Функция сохраняет уведомление
+func (n *RecordingNotifier) Notify(_ context.Context, msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = append(n.messages, msg)
+	return nil
+}
+
+// Функция возвращает копию сохраненных уведомлений
+func (n *RecordingNotifier) Messages() []Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Message(nil), n.messages...)
+}
+
+// Функция очищает сохраненные уведомления
+func (n *RecordingNotifier) Reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = nil
+}
@@ -0,0 +1,178 @@
+package notify
+
+import (
+	"Backend-trainee-assignment/metrics"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Тело входящего вебхука, совместимое со Slack и Mattermost
+type WebhookPayload struct {
+	Text     string `json:"text"`
+	Channel  string `json:"channel,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// Настройки доставки вебхуков
+type WebhookConfig struct {
+	QueueSize   int
+	Workers     int
+	MaxAttempts int
+	// Пауза перед повтором, удваивается с каждой попыткой
+	Backoff time.Duration
+}
+
+type webhookJob struct {
+	url     string
+	payload WebhookPayload
+	// Request ID запроса, породившего уведомление, для сквозных логов
+	ctx context.Context
+}
+
+// Асинхронная доставка вебхуков: отправка не блокирует вызывающего,
+// неудачные попытки повторяются в фоне
+type WebhookSender struct {
+	client *http.Client
+	cfg    WebhookConfig
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan webhookJob
+	wg     sync.WaitGroup
+}
+
+func NewWebhookSender(client *http.Client, cfg WebhookConfig) *WebhookSender {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	return &WebhookSender{
+		client: client,
+		cfg:    cfg,
+		queue:  make(chan webhookJob, cfg.QueueSize),
+	}
+}
+
+// Функция запускает обработчиков очереди; ctx обрывает текущие попытки при остановке сервиса
+func (s *WebhookSender) Start(ctx context.Context) {
+	for i := 0; i < s.cfg.Workers; i++ {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			for job := range s.queue {
+				s.deliver(ctx, job)
+			}
+		}()
+	}
+}
+
+// Функция ставит вебхук в очередь, при переполненной очереди уведомление отбрасывается
+func (s *WebhookSender) Enqueue(ctx context.Context, url string, payload WebhookPayload) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return false
+	}
+	select {
+	case s.queue <- webhookJob{url: url, payload: payload, ctx: context.WithoutCancel(ctx)}:
+		return true
+	default:
+		metrics.WebhookDeliveries.WithLabelValues("dropped").Inc()
+		slog.WarnContext(ctx, "webhook queue is full, notification dropped")
+		return false
+	}
+}
+
+// Функция прекращает прием уведомлений и ждет отправки очереди, но не дольше ctx
+func (s *WebhookSender) Close(ctx context.Context) {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Warn("webhook queue was not drained before shutdown")
+	}
+}
+
+// Функция отправляет вебхук, повторяя сетевые ошибки, 429 и 5xx
+func (s *WebhookSender) deliver(ctx context.Context, job webhookJob) {
+	body, err := json.Marshal(job.payload)
+	if err != nil {
+		slog.ErrorContext(job.ctx, "failed to encode webhook payload", "error", err)
+		return
+	}
+
+	backoff := s.cfg.Backoff
+	for attempt := 1; ; attempt++ {
+		retryAfter, err := s.post(ctx, job.url, body)
+		if err == nil {
+			metrics.WebhookDeliveries.WithLabelValues("sent").Inc()
+			return
+		}
+		if retryAfter < 0 || attempt >= s.cfg.MaxAttempts {
+			metrics.WebhookDeliveries.WithLabelValues("failed").Inc()
+			slog.ErrorContext(job.ctx, "webhook delivery failed", "attempts", attempt, "error", err)
+			return
+		}
+
+		wait := backoff
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		slog.WarnContext(job.ctx, "webhook delivery failed, retrying", "attempt", attempt, "retry_in", wait, "error", err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			metrics.WebhookDeliveries.WithLabelValues("failed").Inc()
+			slog.ErrorContext(job.ctx, "webhook delivery aborted by shutdown", "attempts", attempt, "error", err)
+			return
+		}
+		backoff *= 2
+	}
+}
+
+// Функция выполняет одну попытку. retryAfter < 0 означает, что повтор бессмыслен
+func (s *WebhookSender) post(ctx context.Context, url string, body []byte) (retryAfter time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return -1, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 300:
+		return 0, nil
+	case resp.StatusCode == http.StatusTooManyRequests:
+		seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return time.Duration(seconds) * time.Second, fmt.Errorf("webhook responded %d", resp.StatusCode)
+	case resp.StatusCode >= 500:
+		return 0, fmt.Errorf("webhook responded %d", resp.StatusCode)
+	default:
+		return -1, fmt.Errorf("webhook responded %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,151 @@
+package notify
+
+import (
+	"Backend-trainee-assignment/metrics"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// Вебхук, отвечающий статусами по порядку и запоминающий время и тело попыток
+type webhookServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	times    []time.Time
+	payloads []WebhookPayload
+}
+
+func newWebhookServer(t *testing.T, statuses ...int) *webhookServer {
+	t.Helper()
+	s := &webhookServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+		s.mu.Lock()
+		attempt := len(s.times)
+		s.times = append(s.times, time.Now())
+		s.payloads = append(s.payloads, payload)
+		s.mu.Unlock()
+
+		status := http.StatusOK
+		if attempt < len(s.statuses) {
+			status = s.statuses[attempt]
+		}
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "0")
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *webhookServer) attempts() ([]time.Time, []WebhookPayload) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]time.Time(nil), s.times...), append([]WebhookPayload(nil), s.payloads...)
+}
+
+func deliveries(result string) float64 {
+	return testutil.ToFloat64(metrics.WebhookDeliveries.WithLabelValues(result))
+}
+
+// Функция отправляет одно уведомление и ждет, пока очередь будет обработана
+func deliverOne(t *testing.T, sender *WebhookSender, url string) {
+	t.Helper()
+	sender.Start(context.Background())
+	if !sender.Enqueue(context.Background(), url, WebhookPayload{Text: "hello", Channel: "#reviews"}) {
+		t.Fatal("notification was not queued")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sender.Close(ctx)
+}
+
+func TestWebhookSenderRetries(t *testing.T) {
+	const backoff = 20 * time.Millisecond
+	tests := []struct {
+		name         string
+		statuses     []int
+		maxAttempts  int
+		wantAttempts int
+		wantResult   string
+	}{
+		{name: "success", statuses: []int{http.StatusNoContent}, maxAttempts: 3, wantAttempts: 1, wantResult: "sent"},
+		{name: "5xx and 429 retried", statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}, maxAttempts: 5, wantAttempts: 3, wantResult: "sent"},
+		{name: "attempts exhausted", statuses: []int{500, 502, 503, 504}, maxAttempts: 3, wantAttempts: 3, wantResult: "failed"},
+		{name: "4xx not retried", statuses: []int{http.StatusNotFound}, maxAttempts: 5, wantAttempts: 1, wantResult: "failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newWebhookServer(t, tt.statuses...)
+			sender := NewWebhookSender(server.Client(), WebhookConfig{QueueSize: 1, Workers: 1, MaxAttempts: tt.maxAttempts, Backoff: backoff})
+			before := deliveries(tt.wantResult)
+
+			deliverOne(t, sender, server.URL)
+
+			times, payloads := server.attempts()
+			if len(times) != tt.wantAttempts {
+				t.Fatalf("attempts %d, want %d", len(times), tt.wantAttempts)
+			}
+			// Пауза удваивается с каждой попыткой
+			for i := 1; i < len(times); i++ {
+				if gap, want := times[i].Sub(times[i-1]), backoff<<(i-1); gap < want {
+					t.Fatalf("pause before attempt %d is %s, want at least %s", i+1, gap, want)
+				}
+			}
+			if got := deliveries(tt.wantResult) - before; got != 1 {
+				t.Fatalf("%s deliveries %v, want 1", tt.wantResult, got)
+			}
+			if payload := payloads[0]; payload.Text != "hello" || payload.Channel != "#reviews" {
+				t.Fatalf("payload %+v", payload)
+			}
+		})
+	}
+}
+
+func TestWebhookSenderDropsWhenQueueFull(t *testing.T) {
+	sender := NewWebhookSender(http.DefaultClient, WebhookConfig{QueueSize: 1, Workers: 1, MaxAttempts: 1})
+	before := deliveries("dropped")
+
+	// Обработчики не запущены, очередь не разбирается
+	if !sender.Enqueue(context.Background(), "http://127.0.0.1:0", WebhookPayload{Text: "first"}) {
+		t.Fatal("first notification was not queued")
+	}
+	if sender.Enqueue(context.Background(), "http://127.0.0.1:0", WebhookPayload{Text: "second"}) {
+		t.Fatal("second notification queued into a full queue")
+	}
+	if got := deliveries("dropped") - before; got != 1 {
+		t.Fatalf("dropped %v, want 1", got)
+	}
+}
+
+func TestWebhookSenderCloseDrainsQueue(t *testing.T) {
+	server := newWebhookServer(t)
+	sender := NewWebhookSender(server.Client(), WebhookConfig{QueueSize: 10, Workers: 2, MaxAttempts: 1})
+	for i := 0; i < 5; i++ {
+		if !sender.Enqueue(context.Background(), server.URL, WebhookPayload{Text: "queued"}) {
+			t.Fatalf("notification %d was not queued", i)
+		}
+	}
+	sender.Start(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sender.Close(ctx)
+	if times, _ := server.attempts(); len(times) != 5 {
+		t.Fatalf("delivered %d before Close returned, want 5", len(times))
+	}
+	if sender.Enqueue(context.Background(), server.URL, WebhookPayload{Text: "late"}) {
+		t.Fatal("notification queued after Close")
+	}
+}
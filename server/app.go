@@ -0,0 +1,292 @@
+package main
+
+import (
+	"Backend-trainee-assignment/api"
+	"Backend-trainee-assignment/config"
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/events"
+	"Backend-trainee-assignment/grpcserver"
+	"Backend-trainee-assignment/handler"
+	"Backend-trainee-assignment/logging"
+	"Backend-trainee-assignment/metrics"
+	"Backend-trainee-assignment/notify"
+	service "Backend-trainee-assignment/services"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+)
+
+// Собранное приложение: репозитории, сервисы, фоновые процессы и обработчики HTTP API
+type app struct {
+	cfg *config.Config
+
+	prRepo *repository.PRRepository
+	bus    *events.Bus
+
+	reconciler        *service.Reconciler
+	slaMonitor        *service.SLAMonitor
+	reminderScheduler *service.ReminderScheduler
+	chatNotifier      *service.ChatNotifier
+	emailNotifier     *service.EmailNotifier
+	webhookSender     *notify.WebhookSender
+	emailBatcher      *notify.EmailBatcher
+
+	handlers   handlers
+	grpcServer *grpc.Server
+}
+
+// Обработчики HTTP API
+type handlers struct {
+	team         *handler.TeamHandler
+	user         *handler.UserHandler
+	pr           *handler.PRHandler
+	repository   *handler.RepositoryHandler
+	stats        *handler.StatsHandler
+	export       *handler.ExportHandler
+	importer     *handler.ImportHandler
+	deactivation *handler.BulkHandler
+	scim         *handler.ScimHandler
+	sla          *handler.SLAHandler
+	reminder     *handler.ReminderHandler
+	webhook      *handler.WebhookHandler
+	notification *handler.NotificationHandler
+	events       *handler.EventsHandler
+	health       *handler.HealthHandler
+	openAPI      *handler.OpenAPIHandler
+	idempotency  *handler.IdempotencyStore
+}
+
+// Функция собирает приложение, фоновые процессы не запускаются
+func newApp(cfg *config.Config, db *sqlx.DB) (*app, error) {
+	userRepo := repository.NewUserRepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+	prRepo := repository.NewPRRepository(db)
+	repoRepo := repository.NewRepositoryRepository(db)
+	slaRepo := repository.NewSLARepository(db)
+	reminderRepo := repository.NewReminderRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	exportRepo := repository.NewExportRepository(db)
+	importRepo := repository.NewImportRepository(db)
+	deactivationRepo := repository.NewDeactivationRepository(db)
+
+	eventBus := events.NewBus(cfg.EventHistorySize)
+	reviewerService := service.NewReviewerService(userRepo, teamRepo, prRepo, repoRepo, eventBus, cfg.MinMatchedReviewers)
+
+	// Фоновое дополнение ревьюеров
+	reconciler := service.NewReconciler(reviewerService, prRepo, eventBus, cfg.ReconcileInterval, cfg.ReconcileBatchSize)
+
+	teamService := service.NewTeamService(teamRepo, reviewerService)
+	prService := service.NewPRService(prRepo, userRepo, teamRepo, repoRepo, reviewerService, eventBus)
+	userService := service.NewUserService(userRepo, reconciler, eventBus)
+	statsService := service.NewStatsService(prRepo, teamRepo)
+	deactivationService := service.NewDeactivationService(deactivationRepo, teamRepo, eventBus)
+	exportService := service.NewExportService(exportRepo)
+	importService := service.NewImportService(importRepo, reviewerService, reconciler, eventBus)
+	scimService := service.NewScimService(userRepo, teamRepo, teamService, deactivationService, reconciler)
+
+	// Отслеживание SLA и переназначение просрочивших ревьюеров
+	slaLocation, err := time.LoadLocation(cfg.SLATimezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SLA_TIMEZONE %q: %w", cfg.SLATimezone, err)
+	}
+	slaService := service.NewSLAService(slaRepo, prRepo, teamRepo, service.SLAConfig{
+		DefaultThresholdHours: cfg.SLADefaultThresholdHours,
+		DefaultAutoReassign:   cfg.SLAAutoReassign,
+		BusinessDays:          cfg.SLABusinessDays,
+		Location:              slaLocation,
+	})
+	slaMonitor := service.NewSLAMonitor(slaService, reviewerService, cfg.SLACheckInterval)
+
+	// Уведомления в каналы команд через вебхуки Slack/Mattermost
+	webhookSender := notify.NewWebhookSender(&http.Client{Timeout: cfg.WebhookTimeout}, notify.WebhookConfig{
+		QueueSize:   cfg.WebhookQueueSize,
+		Workers:     cfg.WebhookWorkers,
+		MaxAttempts: cfg.WebhookMaxAttempts,
+		Backoff:     cfg.WebhookBackoff,
+	})
+	chatNotifier := service.NewChatNotifier(webhookRepo, prRepo, webhookSender)
+	webhookService := service.NewWebhookService(webhookRepo, teamRepo)
+
+	notifiers := notify.MultiNotifier{notify.NewLogNotifier(), chatNotifier}
+
+	// Письма ревьюерам, если задан SMTP-сервер
+	var emailBatcher *notify.EmailBatcher
+	var emailNotifier *service.EmailNotifier
+	if cfg.SMTPAddr != "" {
+		emailBatcher = notify.NewEmailBatcher(
+			notify.NewSMTPMailer(cfg.SMTPAddr, cfg.SMTPFrom, cfg.SMTPUsername, cfg.SMTPPassword),
+			notify.EmailBatchConfig{Window: cfg.EmailBatchWindow, MaxAttempts: cfg.EmailMaxAttempts, Backoff: cfg.EmailRetryBackoff},
+		)
+		emailNotifier = service.NewEmailNotifier(notificationRepo, prRepo, emailBatcher)
+		notifiers = append(notifiers, emailNotifier)
+	}
+	notificationService := service.NewNotificationService(notificationRepo)
+
+	// Напоминания о ревью по расписаниям команд
+	reminderService := service.NewReminderService(reminderRepo, teamRepo, notifiers)
+	reminderScheduler := service.NewReminderScheduler(reminderService, cfg.ReminderInterval)
+
+	spec, err := api.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load openapi spec: %w", err)
+	}
+
+	return &app{
+		cfg:               cfg,
+		prRepo:            prRepo,
+		bus:               eventBus,
+		reconciler:        reconciler,
+		slaMonitor:        slaMonitor,
+		reminderScheduler: reminderScheduler,
+		chatNotifier:      chatNotifier,
+		emailNotifier:     emailNotifier,
+		webhookSender:     webhookSender,
+		emailBatcher:      emailBatcher,
+		handlers: handlers{
+			team:         handler.NewTeamHandler(teamRepo, userRepo, teamService, reconciler),
+			user:         handler.NewUserHandler(userRepo, prRepo, userService),
+			pr:           handler.NewPRHandler(prRepo, userRepo, prService, reviewerService, eventBus),
+			repository:   handler.NewRepositoryHandler(repoRepo, teamRepo, userRepo),
+			stats:        handler.NewStatsHandler(statsService),
+			export:       handler.NewExportHandler(exportService, cfg.ExportTimeout),
+			importer:     handler.NewImportHandler(importService),
+			deactivation: handler.NewBulkHandler(deactivationService),
+			scim:         handler.NewScimHandler(scimService),
+			sla:          handler.NewSLAHandler(slaService),
+			reminder:     handler.NewReminderHandler(reminderService),
+			webhook:      handler.NewWebhookHandler(webhookService),
+			notification: handler.NewNotificationHandler(notificationService),
+			events:       handler.NewEventsHandler(eventBus, cfg.EventHeartbeat),
+			health:       handler.NewHealthHandler(db),
+			openAPI:      handler.NewOpenAPIHandler(spec),
+			idempotency:  handler.NewIdempotencyStore(cfg.IdempotencyTTL),
+		},
+		// gRPC API на отдельном порту
+		grpcServer: grpcserver.NewGRPCServer(
+			grpcserver.NewServer(teamRepo, userRepo, prRepo, teamService, prService, userService, reviewerService, reconciler),
+			cfg.RequestTimeout,
+		),
+	}, nil
+}
+
+// Функция запускает фоновые процессы. Очередь вебхуков отправляется после остановки
+// остальных процессов, поэтому у нее свой контекст senderCtx
+func (a *app) start(appCtx, senderCtx context.Context) {
+	a.reconciler.Start(appCtx)
+	a.slaMonitor.Start(appCtx)
+	a.webhookSender.Start(senderCtx)
+	a.chatNotifier.Start(appCtx, a.bus)
+	if a.emailNotifier != nil {
+		a.emailNotifier.Start(appCtx, a.bus)
+	}
+	a.reminderScheduler.Start(appCtx)
+}
+
+// Функция дожидается фоновых процессов после отмены appCtx и отправляет оставшиеся уведомления до истечения ctx
+func (a *app) wait(ctx context.Context, stopSender context.CancelFunc) {
+	a.reconciler.Wait()
+	a.slaMonitor.Wait()
+	a.reminderScheduler.Wait()
+	a.chatNotifier.Wait()
+	a.webhookSender.Close(ctx)
+	stopSender()
+	if a.emailNotifier != nil {
+		a.emailNotifier.Wait()
+		a.emailBatcher.Close(ctx)
+	}
+}
+
+// Функция собирает маршрутизатор HTTP API
+func (a *app) router() *gin.Engine {
+	cfg, h := a.cfg, a.handlers
+
+	router := gin.New()
+	router.Use(gin.Recovery(), logging.RequestIDMiddleware(), logging.AccessLogMiddleware())
+	router.Use(metrics.Middleware())
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	router.GET("/healthz", h.health.Liveness)
+	router.GET("/readyz", h.health.Readiness)
+	router.GET("/openapi.json", h.openAPI.Spec)
+	router.GET("/docs", h.openAPI.SwaggerUI)
+	router.GET("/events/stream", h.events.Stream)
+	// Выгрузки пишутся потоком: без общего таймаута запроса и проверки ответа по схеме
+	exports := router.Group("/export", handler.ErrorMiddleware())
+	exports.GET("/pull-requests", h.export.ExportPullRequests)
+	exports.GET("/assignments", h.export.ExportAssignments)
+	exports.GET("/user-stats", h.export.ExportUserStats)
+	// SCIM 2.0 для провайдера учетных записей: свой формат ошибок и авторизация по токену
+	if cfg.ScimToken != "" {
+		scim := router.Group("/scim/v2", handler.ScimErrorMiddleware(), handler.ScimAuthMiddleware(cfg.ScimToken), handler.TimeoutMiddleware(cfg.RequestTimeout))
+		scim.GET("/ServiceProviderConfig", h.scim.ServiceProviderConfig)
+		scim.GET("/Users", h.scim.ListUsers)
+		scim.POST("/Users", h.scim.CreateUser)
+		scim.GET("/Users/:id", h.scim.GetUser)
+		scim.PUT("/Users/:id", h.scim.ReplaceUser)
+		scim.PATCH("/Users/:id", h.scim.PatchUser)
+		scim.DELETE("/Users/:id", h.scim.DeleteUser)
+		scim.GET("/Groups", h.scim.ListGroups)
+		scim.POST("/Groups", h.scim.CreateGroup)
+		scim.GET("/Groups/:id", h.scim.GetGroup)
+		scim.PUT("/Groups/:id", h.scim.ReplaceGroup)
+		scim.PATCH("/Groups/:id", h.scim.PatchGroup)
+		scim.DELETE("/Groups/:id", h.scim.DeleteGroup)
+	}
+	if cfg.ValidateResponses {
+		router.Use(h.openAPI.ResponseValidationMiddleware())
+	}
+	router.Use(handler.ErrorMiddleware(), handler.TimeoutMiddleware(cfg.RequestTimeout), h.idempotency.Middleware())
+	if cfg.ValidateRequests {
+		router.Use(h.openAPI.RequestValidationMiddleware())
+	}
+
+	// Openapi
+	router.POST("/team/add", h.team.AddTeam)
+	router.GET("/team/get", h.team.GetTeam)
+	router.POST("/team/addMember", h.team.AddMember)
+	router.POST("/team/removeMember", h.team.RemoveMember)
+	router.POST("/team/moveMember", h.team.MoveMember)
+	router.POST("/team/rename", h.team.RenameTeam)
+	router.POST("/team/delete", h.team.DeleteTeam)
+	router.POST("/team/import", h.importer.ImportOrgChart)
+	router.POST("/users/setIsActive", h.user.SetIsActive)
+	router.GET("/users/getReview", h.user.GetReview)
+	router.GET("/users/skills", h.user.GetSkills)
+	router.POST("/users/skills/add", h.user.AddSkills)
+	router.POST("/users/skills/remove", h.user.RemoveSkills)
+	router.POST("/users/skills/set", h.user.SetSkills)
+	router.GET("/users/notifications", h.notification.GetPreferences)
+	router.POST("/users/notifications/set", h.notification.SetPreferences)
+	router.POST("/repository/add", h.repository.AddRepository)
+	router.POST("/repository/update", h.repository.UpdateRepository)
+	router.GET("/repository/get", h.repository.GetRepository)
+	router.POST("/pullRequest/create", h.pr.CreatePR)
+	router.GET("/pullRequest/get", h.pr.GetPR)
+	router.POST("/pullRequest/merge", h.pr.MergePR)
+	router.POST("/pullRequest/reassign", h.pr.ReassignReviewer)
+	router.POST("/pullRequest/review", h.sla.SubmitReview)
+	router.GET("/pullRequest/labels", h.pr.GetLabels)
+	router.POST("/pullRequest/labels/add", h.pr.AddLabels)
+	router.POST("/pullRequest/labels/remove", h.pr.RemoveLabels)
+	router.POST("/pullRequest/labels/set", h.pr.SetLabels)
+	router.POST("/api/pull-requests/:id/reviewers", h.pr.AddReviewer)
+	router.GET("/api/stats", h.stats.GetStats)
+	router.GET("/api/stats/fairness", h.stats.GetFairness)
+	router.POST("/team/massDeactivate", h.deactivation.BulkDeactivate)
+	router.GET("/team/sla", h.sla.GetTeamSLA)
+	router.POST("/team/sla/set", h.sla.SetTeamSLA)
+	router.GET("/sla/overdue", h.sla.GetOverdue)
+	router.GET("/team/reminders", h.reminder.GetReminders)
+	router.POST("/team/reminders/set", h.reminder.SetReminders)
+	router.POST("/team/reminders/run", h.reminder.RunReminders)
+	router.GET("/team/webhook", h.webhook.GetWebhook)
+	router.POST("/team/webhook/set", h.webhook.SetWebhook)
+	router.POST("/team/webhook/delete", h.webhook.DeleteWebhook)
+	return router
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"Backend-trainee-assignment/api"
+	"Backend-trainee-assignment/config"
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/migrations"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// Маршруты инфраструктуры, которые не описываются в спецификации
+var undocumentedRoutes = map[string]bool{
+	"GET /metrics":      true,
+	"GET /openapi.json": true,
+	"GET /docs":         true,
+}
+
+const contractScimToken = "contract-token"
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	openapi3filter.RegisterBodyDecoder("application/scim+json", openapi3filter.JSONBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("application/x-ndjson", ndjsonBodyDecoder)
+}
+
+// Функция проверяет, что каждая строка NDJSON - JSON-объект, и отдает тело строкой, как в схеме
+func ndjsonBodyDecoder(body io.Reader, _ http.Header, _ *openapi3.SchemaRef, _ openapi3filter.EncodingFn) (any, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	for i, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var record map[string]any
+		if line != "" && json.Unmarshal([]byte(line), &record) != nil {
+			return nil, fmt.Errorf("line %d is not a JSON object: %q", i+1, line)
+		}
+	}
+	return string(data), nil
+}
+
+func contractConfig() *config.Config {
+	cfg := config.Load()
+	cfg.ScimToken = contractScimToken
+	cfg.SMTPAddr = ""
+	return cfg
+}
+
+func specOperations(t *testing.T, doc *openapi3.T) map[string]bool {
+	t.Helper()
+	operations := make(map[string]bool)
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			operations[method+" "+path] = true
+		}
+	}
+	return operations
+}
+
+// Каждый маршрут сервера описан в спецификации, и каждая операция спецификации зарегистрирована
+func TestRoutesMatchSpec(t *testing.T) {
+	application, err := newApp(contractConfig(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := api.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	documented := specOperations(t, doc)
+
+	registered := make(map[string]bool)
+	for _, route := range application.router().Routes() {
+		key := route.Method + " " + ginToSpecPath(route.Path)
+		if undocumentedRoutes[key] {
+			continue
+		}
+		registered[key] = true
+		if !documented[key] {
+			t.Errorf("route %s is not documented in api/openapi.json", key)
+		}
+	}
+	for key := range documented {
+		if !registered[key] {
+			t.Errorf("operation %s is documented but not registered", key)
+		}
+	}
+}
+
+func ginToSpecPath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") {
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// Клиент контрактного теста: каждый запрос и ответ проверяются по спецификации
+type contractClient struct {
+	t       *testing.T
+	handler http.Handler
+	router  routers.Router
+	covered map[string]bool
+}
+
+func (c *contractClient) request(method, target string, body any) *http.Request {
+	c.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, "http://localhost:8080"+target, reader)
+	if strings.HasPrefix(target, "/scim/") {
+		req.Header.Set("Authorization", "Bearer "+contractScimToken)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/scim+json")
+		}
+	} else if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req
+}
+
+// Функция находит операцию спецификации и проверяет по ней запрос
+func (c *contractClient) validateRequest(req *http.Request) *openapi3filter.RequestValidationInput {
+	c.t.Helper()
+	route, params, err := c.router.FindRoute(req)
+	if err != nil {
+		c.t.Fatalf("%s %s: no documented operation: %v", req.Method, req.URL.RequestURI(), err)
+	}
+	c.covered[req.Method+" "+route.Path] = true
+	input := &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: params,
+		Route:      route,
+		Options: &openapi3filter.Options{
+			AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+			IncludeResponseStatus: true,
+		},
+	}
+	if err := openapi3filter.ValidateRequest(req.Context(), input); err != nil {
+		c.t.Fatalf("%s %s: request does not match spec: %v", req.Method, req.URL.RequestURI(), err)
+	}
+	return input
+}
+
+// Функция выполняет запрос, проверяет статус и ответ по спецификации и возвращает JSON-тело
+func (c *contractClient) do(method, target string, body any, wantStatus int) map[string]any {
+	c.t.Helper()
+	req := c.request(method, target, body)
+	input := c.validateRequest(req)
+
+	rec := httptest.NewRecorder()
+	c.handler.ServeHTTP(rec, c.request(method, target, body))
+	if rec.Code != wantStatus {
+		c.t.Fatalf("%s %s: status %d, want %d: %s", method, target, rec.Code, wantStatus, rec.Body.String())
+	}
+	if err := openapi3filter.ValidateResponse(req.Context(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 rec.Code,
+		Header:                 rec.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(rec.Body.Bytes())),
+		Options:                input.Options,
+	}); err != nil {
+		c.t.Fatalf("%s %s: response does not match spec: %v\n%s", method, target, err, rec.Body.String())
+	}
+
+	var result map[string]any
+	if strings.Contains(rec.Header().Get("Content-Type"), "json") && rec.Body.Len() > 0 &&
+		!strings.Contains(rec.Header().Get("Content-Type"), "ndjson") {
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			c.t.Fatalf("%s %s: invalid JSON: %v", method, target, err)
+		}
+	}
+	return result
+}
+
+// Функция читает поток событий в течение window и проверяет data каждого события по схеме Event
+func (c *contractClient) stream(target string, window time.Duration) int {
+	c.t.Helper()
+	req := c.request(http.MethodGet, target, nil)
+	input := c.validateRequest(req)
+
+	ctx, cancel := context.WithTimeout(context.Background(), window)
+	defer cancel()
+	rec := httptest.NewRecorder()
+	c.handler.ServeHTTP(rec, c.request(http.MethodGet, target, nil).WithContext(ctx))
+	if rec.Code != http.StatusOK {
+		c.t.Fatalf("GET %s: status %d", target, rec.Code)
+	}
+	response := input.Route.Operation.Responses.Status(http.StatusOK)
+	media := response.Value.Content.Get(strings.Split(rec.Header().Get("Content-Type"), ";")[0])
+	if media == nil {
+		c.t.Fatalf("GET %s: undocumented content type %q", target, rec.Header().Get("Content-Type"))
+	}
+
+	events := 0
+	eventType := ""
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			eventType = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:") && eventType != "resync":
+			var value any
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &value); err != nil {
+				c.t.Fatalf("GET %s: invalid event data %q", target, line)
+			}
+			if err := media.Schema.Value.VisitJSON(value); err != nil {
+				c.t.Fatalf("GET %s: event does not match spec: %v", target, err)
+			}
+			events++
+		case line == "":
+			eventType = ""
+		}
+	}
+	return events
+}
+
+func contractDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := repository.Migrate(context.Background(), db, migrations.Files); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// Все документированные операции проходят через маршрутизатор сервера с проверкой
+// запросов и ответов по спецификации. Нужна БД PostgreSQL в TEST_DATABASE_URL
+func TestContract(t *testing.T) {
+	db := contractDB(t)
+	application, err := newApp(contractConfig(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := api.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	specRouter, err := legacy.NewRouter(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &contractClient{t: t, handler: application.router(), router: specRouter, covered: make(map[string]bool)}
+
+	sfx := strconv.FormatInt(time.Now().UnixNano()%1e12, 36)
+	team, team2 := "ct-"+sfx, "ct2-"+sfx
+	u := func(n int) string { return fmt.Sprintf("ct-%s-u%d", sfx, n) }
+	member := func(n int) map[string]any {
+		return map[string]any{"user_id": u(n), "username": u(n), "is_active": true}
+	}
+	repo, pr1, pr2 := "ct-"+sfx+"-r", "ct-"+sfx+"-p1", "ct-"+sfx+"-p2"
+
+	c.do("GET", "/healthz", nil, 200)
+	c.do("GET", "/readyz", nil, 200)
+
+	// Команды и пользователи
+	c.do("POST", "/team/add", map[string]any{"team_name": team, "members": []any{member(1), member(2), member(3), member(4)}}, 201)
+	c.do("POST", "/team/add", map[string]any{"team_name": team2, "members": []any{member(5), member(6)}}, 201)
+	c.do("GET", "/team/get?team_name="+team, nil, 200)
+	c.do("POST", "/team/addMember", map[string]any{"team_name": team2, "user_id": u(7), "username": u(7), "is_active": true}, 200)
+	c.do("POST", "/team/removeMember", map[string]any{"team_name": team2, "user_id": u(7)}, 200)
+	c.do("GET", "/users/skills?user_id="+u(2), nil, 200)
+	c.do("POST", "/users/skills/add", map[string]any{"user_id": u(2), "skills": []string{"go"}}, 200)
+	c.do("POST", "/users/skills/remove", map[string]any{"user_id": u(2), "skills": []string{"go"}}, 200)
+	c.do("POST", "/users/skills/set", map[string]any{"user_id": u(2), "skills": []string{"go", "sql"}}, 200)
+	c.do("GET", "/users/notifications?user_id="+u(2), nil, 200)
+	c.do("POST", "/users/notifications/set", map[string]any{"user_id": u(2), "email": u(2) + "@example.com", "email_opt_out": []string{"digest"}}, 200)
+
+	// Репозитории
+	c.do("POST", "/repository/add", map[string]any{"repository_id": repo, "name": repo, "teams": []string{team}}, 201)
+	c.do("POST", "/repository/update", map[string]any{"repository_id": repo, "name": repo + "-x", "teams": []string{team}, "extra_reviewers": []string{u(5)}}, 200)
+	c.do("GET", "/repository/get?repository_id="+repo, nil, 200)
+
+	// PR: два ревьюера из u2..u4, третий остается свободным для переназначения
+	created := c.do("POST", "/pullRequest/create", map[string]any{"pull_request_id": pr1, "pull_request_name": "contract", "author_id": u(1), "team_name": team, "labels": []string{"go"}}, 201)
+	reviewers := assignedReviewers(t, created)
+	if len(reviewers) != 2 {
+		t.Fatalf("assigned reviewers %v, want 2", reviewers)
+	}
+	c.do("GET", "/pullRequest/get?pull_request_id="+pr1, nil, 200)
+	c.do("GET", "/pullRequest/labels?pull_request_id="+pr1, nil, 200)
+	c.do("POST", "/pullRequest/labels/add", map[string]any{"pull_request_id": pr1, "labels": []string{"db"}}, 200)
+	c.do("POST", "/pullRequest/labels/remove", map[string]any{"pull_request_id": pr1, "labels": []string{"db"}}, 200)
+	c.do("POST", "/pullRequest/labels/set", map[string]any{"pull_request_id": pr1, "labels": []string{"go", "api"}}, 200)
+	c.do("POST", "/pullRequest/review", map[string]any{"pull_request_id": pr1, "reviewer_id": reviewers[0], "decision": "APPROVED"}, 200)
+	c.do("POST", "/pullRequest/reassign", map[string]any{"pull_request_id": pr1, "old_user_id": reviewers[0]}, 200)
+	c.do("GET", "/users/getReview?user_id="+reviewers[1], nil, 200)
+
+	// Во второй команде у PR один ревьюер, второго добавляют вручную
+	c.do("POST", "/pullRequest/create", map[string]any{"pull_request_id": pr2, "pull_request_name": "contract", "author_id": u(5), "team_name": team2}, 201)
+	c.do("POST", "/api/pull-requests/"+pr2+"/reviewers", map[string]any{"reviewer_id": u(2)}, 200)
+	c.do("POST", "/team/moveMember", map[string]any{"user_id": u(6), "from_team_name": team2, "to_team_name": team}, 200)
+
+	// Статистика, SLA, напоминания и вебхуки
+	c.do("GET", "/api/stats?team="+team+"&group_by=day", nil, 200)
+	c.do("GET", "/api/stats/fairness?team="+team, nil, 200)
+	c.do("POST", "/team/sla/set", map[string]any{"team_name": team, "threshold_hours": 24, "auto_reassign": false}, 200)
+	c.do("GET", "/team/sla?team_name="+team, nil, 200)
+	c.do("GET", "/sla/overdue?team_name="+team, nil, 200)
+	c.do("POST", "/team/reminders/set", map[string]any{"team_name": team, "digest_schedule": "0 9 * * 1-5", "timezone": "UTC"}, 200)
+	c.do("GET", "/team/reminders?team_name="+team, nil, 200)
+	c.do("POST", "/team/reminders/run", map[string]any{"team_name": team, "kind": "digest"}, 200)
+	c.do("POST", "/team/webhook/set", map[string]any{"team_name": team, "url": "https://hooks.example.com/services/contract", "enabled": false}, 200)
+	c.do("GET", "/team/webhook?team_name="+team, nil, 200)
+	c.do("POST", "/team/webhook/delete", map[string]any{"team_name": team}, 200)
+
+	// Импорт и деактивация без изменений
+	c.do("POST", "/team/import", map[string]any{"format": "csv", "content": "team_name,user_id,username\n" + team + "," + u(1) + "," + u(1) + "\n", "dry_run": true}, 200)
+	c.do("POST", "/team/massDeactivate", map[string]any{"team_name": team2, "user_ids": []string{u(5)}, "dry_run": true}, 200)
+
+	c.do("POST", "/pullRequest/merge", map[string]any{"pull_request_id": pr1}, 200)
+	c.do("POST", "/users/setIsActive", map[string]any{"user_id": u(4), "is_active": false}, 200)
+
+	// Выгрузки и поток событий
+	c.do("GET", "/export/pull-requests?format=csv&team_name="+team, nil, 200)
+	c.do("GET", "/export/assignments?format=ndjson&team_name="+team, nil, 200)
+	c.do("GET", "/export/user-stats?format=csv&team_name="+team, nil, 200)
+	if events := c.stream("/events/stream?last_event_id=0&team_name="+team, 300*time.Millisecond); events == 0 {
+		t.Fatal("event stream returned no events")
+	}
+
+	// SCIM
+	c.do("GET", "/scim/v2/ServiceProviderConfig", nil, 200)
+	scimUser := "ct-" + sfx + "-scim"
+	c.do("POST", "/scim/v2/Users", map[string]any{"schemas": []string{"urn:ietf:params:scim:schemas:core:2.0:User"}, "userName": scimUser, "active": true}, 201)
+	c.do("GET", "/scim/v2/Users?filter="+url.QueryEscape(`userName eq "`+scimUser+`"`)+"&startIndex=1&count=10", nil, 200)
+	c.do("GET", "/scim/v2/Users/"+scimUser, nil, 200)
+	c.do("PUT", "/scim/v2/Users/"+scimUser, map[string]any{"schemas": []string{"urn:ietf:params:scim:schemas:core:2.0:User"}, "userName": scimUser, "displayName": "Contract", "active": true}, 200)
+	c.do("PATCH", "/scim/v2/Users/"+scimUser, map[string]any{"schemas": []string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"}, "Operations": []any{map[string]any{"op": "replace", "path": "active", "value": false}}}, 200)
+	scimGroup := "ct-" + sfx + "-g"
+	c.do("POST", "/scim/v2/Groups", map[string]any{"schemas": []string{"urn:ietf:params:scim:schemas:core:2.0:Group"}, "displayName": scimGroup, "members": []any{map[string]any{"value": u(1)}}}, 201)
+	c.do("GET", "/scim/v2/Groups?filter="+url.QueryEscape(`displayName eq "`+scimGroup+`"`), nil, 200)
+	c.do("GET", "/scim/v2/Groups/"+scimGroup, nil, 200)
+	c.do("PATCH", "/scim/v2/Groups/"+scimGroup, map[string]any{"schemas": []string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"}, "Operations": []any{map[string]any{"op": "add", "path": "members", "value": []any{map[string]any{"value": u(2)}}}}}, 200)
+	c.do("PUT", "/scim/v2/Groups/"+scimGroup, map[string]any{"schemas": []string{"urn:ietf:params:scim:schemas:core:2.0:Group"}, "displayName": scimGroup, "members": []any{map[string]any{"value": u(1)}}}, 200)
+	c.do("DELETE", "/scim/v2/Groups/"+scimGroup, nil, 204)
+	c.do("DELETE", "/scim/v2/Users/"+scimUser, nil, 204)
+
+	// Переименование и удаление команд в конце, чтобы не мешать остальным шагам
+	c.do("POST", "/team/rename", map[string]any{"team_name": team, "new_team_name": team + "-r"}, 200)
+	c.do("POST", "/team/delete", map[string]any{"team_name": team + "-r"}, 200)
+
+	var missing []string
+	for key := range specOperations(t, doc) {
+		if !c.covered[key] {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Fatalf("operations not covered by contract test: %v", missing)
+	}
+}
+
+func assignedReviewers(t *testing.T, body map[string]any) []string {
+	t.Helper()
+	pr, _ := body["pr"].(map[string]any)
+	raw, _ := pr["assigned_reviewers"].([]any)
+	reviewers := make([]string, 0, len(raw))
+	for _, r := range raw {
+		reviewers = append(reviewers, r.(string))
+	}
+	return reviewers
+}
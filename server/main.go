@@ -3,12 +3,20 @@ package main
 import (
 	"Backend-trainee-assignment/config"
 	repository "Backend-trainee-assignment/database"
-	"Backend-trainee-assignment/handler"
-	service "Backend-trainee-assignment/services"
-	"log"
+	"Backend-trainee-assignment/logging"
+	"Backend-trainee-assignment/metrics"
+	"Backend-trainee-assignment/migrations"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -18,43 +26,104 @@ func main() {
 	}
 
 	cfg := config.Load()
+	logging.Setup(os.Stdout, cfg.LogLevel, cfg.LogFormat)
 
 	// Подключение БД
 	db, err := repository.NewDB(cfg)
 	if err != nil {
-		log.Fatalf("Ошибка: %v", err)
+		slog.Error("failed to connect to database", "error", err)
+		os.Exit(1)
 	}
 	defer db.Close()
 
+	// Применение новых миграций до приема запросов
+	if _, err := repository.Migrate(context.Background(), db, migrations.Files); err != nil {
+		slog.Error("failed to apply migrations", "error", err)
+		os.Exit(1)
+	}
+
 	// Инициализация
-	userRepo := repository.NewUserRepository(db)
-	teamRepo := repository.NewTeamRepository(db)
-	prRepo := repository.NewPRRepository(db)
-
-	reviewerService := service.NewReviewerService(userRepo, teamRepo, prRepo)
-
-	teamHandler := handler.NewTeamHandler(teamRepo, userRepo)
-	userHandler := handler.NewUserHandler(userRepo, prRepo)
-	prHandler := handler.NewPRHandler(prRepo, userRepo, reviewerService)
-	statsHandler := handler.NewStatsHandler(prRepo)
-	delHandler := handler.NewBulkHandler(userRepo, prRepo)
-	router := gin.Default()
-
-	// Openapi
-	router.POST("/team/add", teamHandler.AddTeam)
-	router.GET("/team/get", teamHandler.GetTeam)
-	router.POST("/users/setIsActive", userHandler.SetIsActive)
-	router.GET("/users/getReview", userHandler.GetReview)
-	router.POST("/pullRequest/create", prHandler.CreatePR)
-	router.POST("/pullRequest/merge", prHandler.MergePR)
-	router.POST("/pullRequest/reassign", prHandler.ReassignReviewer)
-	router.POST("/api/pull-requests/:id/reviewers", prHandler.AddReviewer)
-	router.GET("/api/stats", statsHandler.GetStats)
-	router.POST("/team/massDeactivate", delHandler.BulkDeactivate)
-
-	log.Printf("Сервер запущен, порт: %s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatalf("Ошибка при запуске: %v", err)
+	application, err := newApp(cfg, db)
+	if err != nil {
+		slog.Error("failed to initialize", "error", err)
+		os.Exit(1)
+	}
+	metrics.Register(db.DB, application.prRepo)
+
+	// Контекст фоновых процессов, отменяется при остановке
+	appCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	// Очередь уведомлений отправляется после остановки фоновых процессов, поэтому у нее свой контекст
+	senderCtx, stopSender := context.WithCancel(context.Background())
+	defer stopSender()
+	application.start(appCtx, senderCtx)
+
+	srv := &http.Server{
+		Addr:    ":" + port,
+		Handler: application.router(),
+	}
+	srv.RegisterOnShutdown(application.handlers.events.Close)
+	serverErr := make(chan error, 2)
+	go func() {
+		slog.Info("server started", "port", port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	// gRPC API на отдельном порту
+	grpcServer := application.grpcServer
+	grpcListener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		slog.Error("failed to listen grpc port", "port", cfg.GRPCPort, "error", err)
+		os.Exit(1)
 	}
+	go func() {
+		slog.Info("grpc server started", "port", cfg.GRPCPort)
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			serverErr <- err
+		}
+	}()
 
+	// Ожидание сигнала остановки
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+	select {
+	case <-signalCtx.Done():
+		slog.Info("shutdown started")
+	case err := <-serverErr:
+		slog.Error("server stopped", "error", err)
+		os.Exit(1)
+	}
+
+	// Снятие с балансировки: /readyz отвечает ошибкой, пока сервер еще принимает запросы
+	application.handlers.health.SetShuttingDown()
+	time.Sleep(cfg.ReadinessDelay)
+
+	// Ожидание текущих запросов
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("graceful shutdown failed", "error", err)
+	}
+	stopGRPC(shutdownCtx, grpcServer)
+
+	stopBackground()
+	application.wait(shutdownCtx, stopSender)
+	slog.Info("shutdown completed")
+}
+
+// Функция дожидается завершения текущих gRPC-вызовов, по истечении ctx обрывает их
+func stopGRPC(ctx context.Context, gs *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		gs.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Error("grpc graceful shutdown timed out")
+		gs.Stop()
+	}
 }
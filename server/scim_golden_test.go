@@ -0,0 +1,140 @@
+package main
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/migrations"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Шаг golden-сценария SCIM: запрос и ожидаемый ответ
+type scimGoldenStep struct {
+	Name     string          `json:"name"`
+	Method   string          `json:"method"`
+	Path     string          `json:"path"`
+	Body     json.RawMessage `json:"body"`
+	Status   int             `json:"status"`
+	Location string          `json:"location"`
+	Response json.RawMessage `json:"response"`
+}
+
+// Функция создает пустую схему с примененными миграциями, чтобы списки без фильтра видели
+// только данные сценария. Схема удаляется после теста
+func scimGoldenDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	admin, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	schema := "scim_golden_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		admin.Close()
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`)
+		admin.Close()
+	})
+
+	if strings.Contains(dsn, "://") {
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		dsn += separator + "search_path=" + schema
+	} else {
+		dsn += " search_path=" + schema
+	}
+	db, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatalf("connect to schema: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := repository.Migrate(context.Background(), db, migrations.Files); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// Сценарии из testdata/scim проходят через маршрутизатор сервера, каждый ответ сравнивается
+// с зафиксированным. Нужна БД PostgreSQL в TEST_DATABASE_URL
+func TestScimGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "scim", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no SCIM golden files")
+	}
+	for _, file := range files {
+		t.Run(strings.TrimSuffix(filepath.Base(file), ".json"), func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var steps []scimGoldenStep
+			if err := json.Unmarshal(data, &steps); err != nil {
+				t.Fatalf("parse %s: %v", file, err)
+			}
+
+			application, err := newApp(contractConfig(), scimGoldenDB(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			router := application.router()
+			for i, step := range steps {
+				req := httptest.NewRequest(step.Method, "http://localhost:8080"+step.Path, bytes.NewReader(step.Body))
+				req.Header.Set("Authorization", "Bearer "+contractScimToken)
+				if len(step.Body) > 0 {
+					req.Header.Set("Content-Type", "application/scim+json")
+				}
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+
+				where := strconv.Itoa(i+1) + " " + step.Name
+				if rec.Code != step.Status {
+					t.Fatalf("step %s: status %d, want %d: %s", where, rec.Code, step.Status, rec.Body.String())
+				}
+				if location := rec.Header().Get("Location"); location != step.Location {
+					t.Fatalf("step %s: Location %q, want %q", where, location, step.Location)
+				}
+				if len(step.Response) == 0 {
+					if rec.Body.Len() > 0 {
+						t.Fatalf("step %s: unexpected body %s", where, rec.Body.String())
+					}
+					continue
+				}
+				if contentType := rec.Header().Get("Content-Type"); contentType != "application/scim+json" {
+					t.Fatalf("step %s: Content-Type %q", where, contentType)
+				}
+				var got, want any
+				if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+					t.Fatalf("step %s: invalid JSON: %v", where, err)
+				}
+				if err := json.Unmarshal(step.Response, &want); err != nil {
+					t.Fatalf("step %s: invalid golden response: %v", where, err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					gotJSON, _ := json.MarshalIndent(got, "", "  ")
+					wantJSON, _ := json.MarshalIndent(want, "", "  ")
+					t.Fatalf("step %s: response\n%s\nwant\n%s", where, gotJSON, wantJSON)
+				}
+			}
+		})
+	}
+}
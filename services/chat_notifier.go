@@ -0,0 +1,190 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/events"
+	"Backend-trainee-assignment/logging"
+	"Backend-trainee-assignment/notify"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Ограничение времени на запросы к БД при обработке одного события уведомлениями
+const notificationLookupTimeout = 5 * time.Second
+
+// Шаблоны сообщений по умолчанию по типу события. В шаблоне доступны поля события
+// (.PRID, .UserID, .TeamName, .Reviewers, .Reason), название PR .PRName, для эскалаций - .Subject и .Text
+var defaultChatTemplates = map[string]string{
+	string(events.ReviewersAssigned):   `Reviewers {{join .Reviewers ", "}} assigned to PR {{.PRID}}{{with .PRName}} "{{.}}"{{end}} by {{.UserID}}`,
+	string(events.ReviewersBackfilled): `Reviewers {{join .Reviewers ", "}} added to understaffed PR {{.PRID}}{{with .PRName}} "{{.}}"{{end}}`,
+	string(events.ReviewerReassigned):  `{{if .Reviewers}}{{join .Reviewers ", "}} replaces {{.UserID}}{{else}}{{.UserID}} removed without replacement{{end}} on PR {{.PRID}}{{with .PRName}} "{{.}}"{{end}}{{with .Reason}} ({{.}}){{end}}`,
+	string(events.PRMerged):            `PR {{.PRID}}{{with .PRName}} "{{.}}"{{end}} by {{.UserID}} merged`,
+	string(events.UserDeactivated):     `{{.UserID}} deactivated{{with .Reason}} ({{.}}){{end}}`,
+	notify.KindEscalation:              `{{.Text}}`,
+}
+
+var chatTemplateFuncs = template.FuncMap{"join": strings.Join}
+
+// Данные шаблона сообщения
+type chatTemplateData struct {
+	events.Event
+	PRName  string
+	Subject string
+	Text    string
+}
+
+// Уведомления в канал команды через входящий вебхук Slack/Mattermost: события назначений
+// и эскалации напоминаний. Отправка асинхронная и не задерживает ответ API
+type ChatNotifier struct {
+	webhookRepo *repository.WebhookRepository
+	prRepo      *repository.PRRepository
+	sender      *notify.WebhookSender
+	stopped     chan struct{}
+}
+
+func NewChatNotifier(webhookRepo *repository.WebhookRepository, prRepo *repository.PRRepository, sender *notify.WebhookSender) *ChatNotifier {
+	return &ChatNotifier{
+		webhookRepo: webhookRepo,
+		prRepo:      prRepo,
+		sender:      sender,
+		stopped:     make(chan struct{}),
+	}
+}
+
+// Функция подписывается на события шины и пересылает их в каналы команд до отмены контекста
+func (n *ChatNotifier) Start(ctx context.Context, bus *events.Bus) {
+	ch, unsubscribe := bus.Subscribe(1024)
+	go func() {
+		defer close(n.stopped)
+		defer unsubscribe()
+		baseCtx := logging.WithRequestID(context.WithoutCancel(ctx), "chat-"+logging.NewRequestID())
+		for {
+			select {
+			case <-ctx.Done():
+				// События, опубликованные до остановки, еще отправляются
+				for {
+					select {
+					case event := <-ch:
+						n.handleEventWithTimeout(baseCtx, event)
+					default:
+						return
+					}
+				}
+			case event := <-ch:
+				n.handleEventWithTimeout(baseCtx, event)
+			}
+		}
+	}()
+}
+
+func (n *ChatNotifier) handleEventWithTimeout(ctx context.Context, event events.Event) {
+	ctx, cancel := context.WithTimeout(ctx, notificationLookupTimeout)
+	defer cancel()
+	n.handleEvent(ctx, event)
+}
+
+// Функция ждет остановки подписки после отмены контекста
+func (n *ChatNotifier) Wait() {
+	<-n.stopped
+}
+
+// Функция отправляет эскалацию в канал команды; дайджесты адресованы отдельным людям и пропускаются
+func (n *ChatNotifier) Notify(ctx context.Context, msg notify.Message) error {
+	if msg.Kind != notify.KindEscalation {
+		return nil
+	}
+	n.send(ctx, msg.TeamName, msg.Kind, chatTemplateData{
+		Event:   events.Event{TeamName: msg.TeamName},
+		Subject: msg.Subject,
+		Text:    msg.Text,
+	})
+	return nil
+}
+
+// Функция определяет команду события и ставит сообщение в очередь.
+// Если у события нет команды, берется целевая команда PR
+func (n *ChatNotifier) handleEvent(ctx context.Context, event events.Event) {
+	if event.Type == events.Resync {
+		slog.WarnContext(ctx, "chat notifier missed events: subscriber buffer overflowed")
+		return
+	}
+	data := chatTemplateData{Event: event}
+	teamName := event.TeamName
+	if event.PRID != "" {
+		pr, err := n.prRepo.GetPRByID(ctx, event.PRID)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to load PR for chat notification", "pull_request_id", event.PRID, "error", err)
+		} else if pr != nil {
+			data.PRName = pr.PullRequestName
+			if teamName == "" {
+				teamName = pr.TeamName
+			}
+		}
+	}
+	if teamName == "" {
+		return
+	}
+	n.send(ctx, teamName, string(event.Type), data)
+}
+
+func (n *ChatNotifier) send(ctx context.Context, teamName, kind string, data chatTemplateData) {
+	webhook, err := n.webhookRepo.GetTeamWebhook(ctx, teamName)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to load team webhook", "team_name", teamName, "error", err)
+		return
+	}
+	if webhook == nil || !webhook.Enabled {
+		return
+	}
+
+	text, err := renderChatTemplate(kind, webhook.Templates[kind], data)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to render chat message", "team_name", teamName, "kind", kind, "error", err)
+		return
+	}
+	if text == "" {
+		return
+	}
+	n.sender.Enqueue(ctx, webhook.URL, notify.WebhookPayload{
+		Text:     text,
+		Channel:  webhook.Channel,
+		Username: webhook.Username,
+	})
+}
+
+// Функция заполняет шаблон команды или шаблон по умолчанию
+func renderChatTemplate(kind, custom string, data chatTemplateData) (string, error) {
+	text := custom
+	if text == "" {
+		text = defaultChatTemplates[kind]
+	}
+	if text == "" {
+		return "", nil
+	}
+	tmpl, err := template.New(kind).Funcs(chatTemplateFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Функция проверяет, что шаблоны заданы для известных типов событий и разбираются
+func validateChatTemplates(templates map[string]string) error {
+	for kind, text := range templates {
+		if _, ok := defaultChatTemplates[kind]; !ok {
+			return NewValidationError(fmt.Sprintf("unknown template %q", kind))
+		}
+		if _, err := renderChatTemplate(kind, text, chatTemplateData{}); err != nil {
+			return NewValidationError(fmt.Sprintf("template %q: %v", kind, err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/events"
+	"Backend-trainee-assignment/models"
+	"Backend-trainee-assignment/notify"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRenderChatTemplate(t *testing.T) {
+	tests := []struct {
+		name   string
+		kind   string
+		custom string
+		data   chatTemplateData
+		want   string
+	}{
+		{
+			name: "assigned",
+			kind: string(events.ReviewersAssigned),
+			data: chatTemplateData{Event: events.Event{PRID: "pr-1", UserID: "u1", Reviewers: []string{"u2", "u3"}}, PRName: "Add search"},
+			want: `Reviewers u2, u3 assigned to PR pr-1 "Add search" by u1`,
+		},
+		{
+			name: "reassigned with reason",
+			kind: string(events.ReviewerReassigned),
+			data: chatTemplateData{Event: events.Event{PRID: "pr-1", UserID: "u2", Reviewers: []string{"u4"}, Reason: "sla"}},
+			want: `u4 replaces u2 on PR pr-1 (sla)`,
+		},
+		{
+			name: "reassigned without replacement",
+			kind: string(events.ReviewerReassigned),
+			data: chatTemplateData{Event: events.Event{PRID: "pr-1", UserID: "u2"}},
+			want: `u2 removed without replacement on PR pr-1`,
+		},
+		{
+			// /users/setIsActive деактивирует без переназначения, сообщение его не обещает
+			name: "deactivated",
+			kind: string(events.UserDeactivated),
+			data: chatTemplateData{Event: events.Event{UserID: "u2", Reason: "manual"}},
+			want: `u2 deactivated (manual)`,
+		},
+		{
+			name:   "custom template",
+			kind:   string(events.PRMerged),
+			custom: `:tada: {{.PRName}} merged`,
+			data:   chatTemplateData{Event: events.Event{PRID: "pr-1"}, PRName: "Add search"},
+			want:   `:tada: Add search merged`,
+		},
+		{
+			name: "escalation",
+			kind: notify.KindEscalation,
+			data: chatTemplateData{Text: "2 reviews waiting"},
+			want: `2 reviews waiting`,
+		},
+		{name: "unknown kind", kind: string(events.Resync)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderChatTemplate(tt.kind, tt.custom, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// События шины доходят до вебхука своей команды; выключенный вебхук и отметка resync не отправляются
+func TestChatNotifierDeliversBusEvents(t *testing.T) {
+	db := testDB(t)
+	f := newReminderFixture(t, db)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var payloads []notify.WebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload notify.WebhookPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		payloads = append(payloads, payload)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	webhookRepo := repository.NewWebhookRepository(db)
+	if err := webhookRepo.SetTeamWebhook(ctx, &models.TeamWebhook{
+		TeamName: f.team, URL: server.URL, Channel: "#reviews", Enabled: true,
+		Templates: map[string]string{string(events.PRMerged): "merged {{.PRID}}"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	disabled := newReminderFixture(t, db)
+	if err := webhookRepo.SetTeamWebhook(ctx, &models.TeamWebhook{TeamName: disabled.team, URL: server.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	sender := notify.NewWebhookSender(server.Client(), notify.WebhookConfig{QueueSize: 10, Workers: 1, MaxAttempts: 1})
+	sender.Start(ctx)
+	notifier := NewChatNotifier(webhookRepo, repository.NewPRRepository(db), sender)
+	bus := events.NewBus(0)
+	notifierCtx, stop := context.WithCancel(ctx)
+	notifier.Start(notifierCtx, bus)
+
+	// Команда события определяется по PR
+	bus.Publish(events.Event{Type: events.ReviewersAssigned, PRID: f.p1, UserID: "author", Reviewers: []string{f.r1}})
+	bus.Publish(events.Event{Type: events.PRMerged, PRID: f.p2, TeamName: f.team})
+	bus.Publish(events.Event{Type: events.Resync})
+	bus.Publish(events.Event{Type: events.PRMerged, PRID: disabled.p1})
+	stop()
+	notifier.Wait()
+	closeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	sender.Close(closeCtx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{
+		`Reviewers ` + f.r1 + ` assigned to PR ` + f.p1 + ` "` + f.p1 + `" by author`,
+		`merged ` + f.p2,
+	}
+	if len(payloads) != len(want) {
+		t.Fatalf("payloads %+v, want %d messages", payloads, len(want))
+	}
+	for i, payload := range payloads {
+		if payload.Text != want[i] || payload.Channel != "#reviews" {
+			t.Fatalf("payload %d: %+v, want %q", i, payload, want[i])
+		}
+	}
+}
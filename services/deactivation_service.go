@@ -0,0 +1,258 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/events"
+	"Backend-trainee-assignment/metrics"
+	"Backend-trainee-assignment/models"
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+)
+
+// Итог для ревью деактивируемого пользователя
+const (
+	DeactivationReassigned    = "reassigned"
+	DeactivationLeftUnstaffed = "left_unstaffed"
+)
+
+// Причины, по которым ревью осталось без замены или пользователь пропущен
+const (
+	UnstaffedNoReviewerPool    = "no_reviewer_pool"
+	UnstaffedNoActiveCandidate = "no_active_candidates"
+	UnstaffedBatchDeactivated  = "all_candidates_deactivated"
+	UnstaffedNoEligible        = "no_eligible_candidates"
+
+	SkippedNotFound        = "not_found"
+	SkippedNotMember       = "not_member"
+	SkippedAlreadyInactive = "already_inactive"
+)
+
+// Деактивация пользователей с переназначением их открытых ревью, общая для
+// /team/massDeactivate и синхронизации каталога через SCIM
+type DeactivationService struct {
+	deactivationRepo *repository.DeactivationRepository
+	teamRepo         *repository.TeamRepository
+	bus              *events.Bus
+}
+
+func NewDeactivationService(deactivationRepo *repository.DeactivationRepository, teamRepo *repository.TeamRepository, bus *events.Bus) *DeactivationService {
+	return &DeactivationService{
+		deactivationRepo: deactivationRepo,
+		teamRepo:         teamRepo,
+		bus:              bus,
+	}
+}
+
+// Функция деактивирует участников команды teamName и переназначает их открытые ревью
+func (s *DeactivationService) BulkDeactivate(ctx context.Context, teamName string, userIDs []string, dryRun bool) (*models.DeactivationReport, error) {
+	exists, err := s.teamRepo.TeamExists(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrTeamNotFound
+	}
+	return s.deactivate(ctx, teamName, userIDs, dryRun, metrics.ReasonDeactivation, nil)
+}
+
+// Функция деактивирует пользователей независимо от команд и переназначает их открытые ревью.
+// reason попадает в события деактивации и переназначения и в метрику переназначений
+func (s *DeactivationService) DeactivateUsers(ctx context.Context, userIDs []string, reason string) (*models.DeactivationReport, error) {
+	return s.deactivate(ctx, "", userIDs, false, reason, nil)
+}
+
+// Функция деактивирует пользователя и в той же транзакции меняет его имя на username
+func (s *DeactivationService) DeactivateRenamedUser(ctx context.Context, userID, username, reason string) (*models.DeactivationReport, error) {
+	return s.deactivate(ctx, "", []string{userID}, false, reason, map[string]string{userID: username})
+}
+
+// Функция выполняет деактивацию одной транзакцией: пустой teamName - без привязки к команде.
+// renames - новые имена пользователей, записываемые в той же транзакции
+func (s *DeactivationService) deactivate(ctx context.Context, teamName string, userIDs []string, dryRun bool, reason string, renames map[string]string) (*models.DeactivationReport, error) {
+	userIDs = uniqueStrings(userIDs)
+	tx, state, err := s.deactivationRepo.Begin(ctx, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении состояния деактивации: %w", err)
+	}
+	defer tx.Rollback()
+
+	report, batch := planDeactivation(teamName, userIDs, state)
+	report.DryRun = dryRun
+	if dryRun {
+		return report, nil
+	}
+	users := make(map[string]*models.User, len(state.Users))
+	for i := range state.Users {
+		users[state.Users[i].UserID] = &state.Users[i]
+	}
+	for userID, username := range renames {
+		if users[userID] == nil {
+			continue
+		}
+		if err := tx.UpdateUsername(ctx, userID, username); err != nil {
+			return nil, fmt.Errorf("ошибка при изменении пользователя: %w", err)
+		}
+		users[userID].Username = username
+	}
+	if err := tx.Apply(ctx, report.DeactivatedUserIDs, report.Reassignments); err != nil {
+		return nil, fmt.Errorf("ошибка при применении деактивации: %w", err)
+	}
+	for _, userID := range report.DeactivatedUserIDs {
+		publishDeactivated(s.bus, users[userID], reason)
+	}
+	prTeams := make(map[string]string, len(state.PRs))
+	for _, pr := range state.PRs {
+		prTeams[pr.PullRequestID] = pr.TeamName
+	}
+	for _, reassignment := range report.Reassignments {
+		event := events.Event{
+			Type:     events.ReviewerReassigned,
+			PRID:     reassignment.PullRequestID,
+			UserID:   reassignment.OldReviewerID,
+			TeamName: prTeams[reassignment.PullRequestID],
+			Reason:   reason,
+		}
+		if reassignment.NewReviewerID != "" {
+			event.Reviewers = []string{reassignment.NewReviewerID}
+			metrics.ReviewersAssigned.Inc()
+			metrics.Reassignments.WithLabelValues(reason).Inc()
+		}
+		s.bus.Publish(event)
+	}
+	slog.InfoContext(ctx, "users deactivated",
+		"team_name", teamName, "deactivated", report.DeactivatedUsers, "batch", len(batch),
+		"reassignments", len(report.Reassignments), "reason", reason)
+	return report, nil
+}
+
+// Функция строит отчет деактивации по прочитанному состоянию. Ревью пользователей пакета
+// переходят к наименее загруженным активным кандидатам пула PR, не входящим в пакет;
+// нагрузка учитывает уже сделанные в этом пакете назначения. Возвращает также пакет
+func planDeactivation(teamName string, userIDs []string, state *models.DeactivationState) (*models.DeactivationReport, map[string]bool) {
+	report := &models.DeactivationReport{
+		TeamName:           teamName,
+		DeactivatedUserIDs: []string{},
+		SkippedUsers:       []models.SkippedUser{},
+		ReassignedPRs:      []string{},
+		Reassignments:      []models.DeactivationReassignment{},
+	}
+	users := make(map[string]*models.User, len(state.Users))
+	for i := range state.Users {
+		users[state.Users[i].UserID] = &state.Users[i]
+	}
+
+	// В пакет входят и уже неактивные пользователи: их оставшиеся ревью тоже снимаются
+	batch := make(map[string]bool)
+	for _, userID := range userIDs {
+		user := users[userID]
+		switch {
+		case user == nil:
+			report.SkippedUsers = append(report.SkippedUsers, models.SkippedUser{UserID: userID, Reason: SkippedNotFound})
+		case teamName != "" && !user.InTeam(teamName):
+			report.SkippedUsers = append(report.SkippedUsers, models.SkippedUser{UserID: userID, Reason: SkippedNotMember})
+		case !user.IsActive:
+			report.SkippedUsers = append(report.SkippedUsers, models.SkippedUser{UserID: userID, Reason: SkippedAlreadyInactive})
+			batch[userID] = true
+		default:
+			report.DeactivatedUserIDs = append(report.DeactivatedUserIDs, userID)
+			batch[userID] = true
+		}
+	}
+	report.DeactivatedUsers = len(report.DeactivatedUserIDs)
+
+	load := make(map[string]int)
+	for _, pools := range []map[string][]models.ReviewerCandidate{state.TeamPools, state.RepositoryPools} {
+		for _, pool := range pools {
+			for _, candidate := range pool {
+				load[candidate.UserID] = candidate.OpenReviews
+			}
+		}
+	}
+
+	for _, pr := range state.PRs {
+		reviewers := append([]string(nil), pr.AssignedReviewers...)
+		touched := false
+		for _, oldReviewerID := range pr.AssignedReviewers {
+			if !batch[oldReviewerID] {
+				continue
+			}
+			touched = true
+			reassignment := models.DeactivationReassignment{PullRequestID: pr.PullRequestID, OldReviewerID: oldReviewerID}
+			reviewers = removeString(reviewers, oldReviewerID)
+
+			newReviewerID, unstaffedReason := pickLeastLoaded(pr, reviewers, batch, load, state)
+			if newReviewerID == "" {
+				reassignment.Status = DeactivationLeftUnstaffed
+				reassignment.Reason = unstaffedReason
+			} else {
+				reassignment.Status = DeactivationReassigned
+				reassignment.NewReviewerID = newReviewerID
+				reviewers = append(reviewers, newReviewerID)
+				load[newReviewerID]++
+			}
+			load[oldReviewerID]--
+			report.Reassignments = append(report.Reassignments, reassignment)
+		}
+		if touched {
+			report.ReassignedPRs = append(report.ReassignedPRs, pr.PullRequestID)
+		}
+	}
+	return report, batch
+}
+
+// Функция выбирает кандидата с наименьшим числом открытых ревью, при равенстве - по user_id.
+// Без кандидата возвращает причину, по которой ревью остается без замены
+func pickLeastLoaded(pr models.PullRequest, reviewers []string, batch map[string]bool, load map[string]int, state *models.DeactivationState) (string, string) {
+	var pool []models.ReviewerCandidate
+	switch {
+	case pr.RepositoryID != "":
+		pool = state.RepositoryPools[pr.RepositoryID]
+	case pr.TeamName != "":
+		pool = state.TeamPools[pr.TeamName]
+	default:
+		return "", UnstaffedNoReviewerPool
+	}
+	if len(pool) == 0 {
+		return "", UnstaffedNoActiveCandidate
+	}
+
+	var eligible []string
+	inBatch := 0
+	for _, candidate := range pool {
+		switch {
+		case batch[candidate.UserID]:
+			inBatch++
+		case candidate.UserID == pr.AuthorID || contains(reviewers, candidate.UserID):
+		default:
+			eligible = append(eligible, candidate.UserID)
+		}
+	}
+	if len(eligible) == 0 {
+		if inBatch == len(pool) {
+			return "", UnstaffedBatchDeactivated
+		}
+		return "", UnstaffedNoEligible
+	}
+
+	sort.Slice(eligible, func(i, j int) bool {
+		if load[eligible[i]] != load[eligible[j]] {
+			return load[eligible[i]] < load[eligible[j]]
+		}
+		return eligible[i] < eligible[j]
+	})
+	return eligible[0], ""
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" && !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
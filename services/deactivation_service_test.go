@@ -0,0 +1,216 @@
+package service
+
+import (
+	"Backend-trainee-assignment/models"
+	"reflect"
+	"testing"
+)
+
+// Пользователи и пулы ревьюеров, общие для тестов деактивации. В пулах - активные на момент
+// чтения кандидаты с числом открытых ревью
+func deactivationTestState(prs ...models.PullRequest) *models.DeactivationState {
+	user := func(userID string, isActive bool, teams ...string) models.User {
+		return models.User{UserID: userID, Username: userID, IsActive: isActive, Teams: teams}
+	}
+	return &models.DeactivationState{
+		Users: []models.User{
+			user("a", true, "backend"),
+			user("r1", true, "backend"),
+			user("r2", true, "backend"),
+			user("r3", true, "backend"),
+			user("u1", true, "backend"),
+			user("u3", false, "backend"),
+			user("u4", true, "frontend"),
+			user("u5", true, "ops"),
+			user("u6", true, "ops"),
+		},
+		PRs: prs,
+		TeamPools: map[string][]models.ReviewerCandidate{
+			"backend":  {{UserID: "a"}, {UserID: "r1"}, {UserID: "r2"}, {UserID: "r3", OpenReviews: 1}, {UserID: "u1", OpenReviews: 3}},
+			"frontend": {{UserID: "u4", OpenReviews: 1}},
+			"ops":      {{UserID: "u5", OpenReviews: 1}, {UserID: "u6", OpenReviews: 1}},
+		},
+		RepositoryPools: map[string][]models.ReviewerCandidate{
+			"repo": {{UserID: "r3", OpenReviews: 1}, {UserID: "x"}},
+		},
+	}
+}
+
+func TestPlanDeactivation(t *testing.T) {
+	pr := func(prID, teamName, repositoryID, authorID string, reviewers ...string) models.PullRequest {
+		return models.PullRequest{PullRequestID: prID, TeamName: teamName, RepositoryID: repositoryID, AuthorID: authorID, AssignedReviewers: reviewers}
+	}
+	reassigned := func(prID, oldReviewerID, newReviewerID string) models.DeactivationReassignment {
+		return models.DeactivationReassignment{PullRequestID: prID, OldReviewerID: oldReviewerID, NewReviewerID: newReviewerID, Status: DeactivationReassigned}
+	}
+	unstaffed := func(prID, oldReviewerID, reason string) models.DeactivationReassignment {
+		return models.DeactivationReassignment{PullRequestID: prID, OldReviewerID: oldReviewerID, Status: DeactivationLeftUnstaffed, Reason: reason}
+	}
+
+	tests := []struct {
+		name      string
+		teamName  string
+		userIDs   []string
+		prs       []models.PullRequest
+		want      models.DeactivationReport
+		wantBatch map[string]bool
+	}{
+		{
+			name:    "least loaded candidate, load counted across the batch",
+			userIDs: []string{"u1"},
+			prs: []models.PullRequest{
+				pr("p1", "backend", "", "a", "u1"),
+				pr("p2", "backend", "", "a", "u1"),
+				pr("p3", "backend", "", "a", "u1"),
+			},
+			want: models.DeactivationReport{
+				DeactivatedUsers:   1,
+				DeactivatedUserIDs: []string{"u1"},
+				SkippedUsers:       []models.SkippedUser{},
+				ReassignedPRs:      []string{"p1", "p2", "p3"},
+				// r1 и r2 без ревью, при равенстве побеждает меньший user_id; к p3 нагрузка r1, r2 и r3 сравнялась
+				Reassignments: []models.DeactivationReassignment{
+					reassigned("p1", "u1", "r1"),
+					reassigned("p2", "u1", "r2"),
+					reassigned("p3", "u1", "r1"),
+				},
+			},
+			wantBatch: map[string]bool{"u1": true},
+		},
+		{
+			name:     "team filter skips unknown, other team and already inactive users",
+			teamName: "backend",
+			userIDs:  []string{"ghost", "u4", "u3", "u1"},
+			prs: []models.PullRequest{
+				pr("p1", "backend", "", "a", "u3", "r1"),
+				pr("p2", "frontend", "", "a", "u4"),
+			},
+			want: models.DeactivationReport{
+				TeamName:           "backend",
+				DeactivatedUsers:   1,
+				DeactivatedUserIDs: []string{"u1"},
+				SkippedUsers: []models.SkippedUser{
+					{UserID: "ghost", Reason: SkippedNotFound},
+					{UserID: "u4", Reason: SkippedNotMember},
+					{UserID: "u3", Reason: SkippedAlreadyInactive},
+				},
+				// Оставшееся ревью неактивного u3 тоже снимается, ревью u4 из другой команды - нет
+				ReassignedPRs: []string{"p1"},
+				Reassignments: []models.DeactivationReassignment{reassigned("p1", "u3", "r2")},
+			},
+			wantBatch: map[string]bool{"u1": true, "u3": true},
+		},
+		{
+			name:    "no replacement",
+			userIDs: []string{"u5", "u6", "u4"},
+			prs: []models.PullRequest{
+				pr("p1", "ops", "", "a", "u5", "u6"),
+				pr("p2", "", "", "a", "u4"),
+				pr("p3", "mobile", "", "a", "u4"),
+				pr("p4", "frontend", "repo", "x", "u4", "r3"),
+			},
+			want: models.DeactivationReport{
+				DeactivatedUsers:   3,
+				DeactivatedUserIDs: []string{"u5", "u6", "u4"},
+				SkippedUsers:       []models.SkippedUser{},
+				ReassignedPRs:      []string{"p1", "p2", "p3", "p4"},
+				Reassignments: []models.DeactivationReassignment{
+					unstaffed("p1", "u5", UnstaffedBatchDeactivated),
+					unstaffed("p1", "u6", UnstaffedBatchDeactivated),
+					unstaffed("p2", "u4", UnstaffedNoReviewerPool),
+					unstaffed("p3", "u4", UnstaffedNoActiveCandidate),
+					unstaffed("p4", "u4", UnstaffedNoEligible),
+				},
+			},
+			wantBatch: map[string]bool{"u4": true, "u5": true, "u6": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, batch := planDeactivation(tt.teamName, tt.userIDs, deactivationTestState(tt.prs...))
+			if !reflect.DeepEqual(*report, tt.want) {
+				t.Fatalf("report %+v, want %+v", *report, tt.want)
+			}
+			if !reflect.DeepEqual(batch, tt.wantBatch) {
+				t.Fatalf("batch %v, want %v", batch, tt.wantBatch)
+			}
+		})
+	}
+}
+
+func TestPickLeastLoaded(t *testing.T) {
+	state := deactivationTestState()
+	tests := []struct {
+		name       string
+		pr         models.PullRequest
+		reviewers  []string
+		batch      map[string]bool
+		load       map[string]int
+		want       string
+		wantReason string
+	}{
+		{
+			name: "least loaded",
+			pr:   models.PullRequest{TeamName: "backend", AuthorID: "a"},
+			load: map[string]int{"r1": 2, "r2": 1, "r3": 3, "u1": 0},
+			want: "u1",
+		},
+		{
+			name: "tie broken by user_id",
+			pr:   models.PullRequest{TeamName: "backend", AuthorID: "a"},
+			load: map[string]int{"r1": 1, "r2": 0, "r3": 0, "u1": 0},
+			want: "r2",
+		},
+		{
+			name:      "author, reviewers and batch skipped",
+			pr:        models.PullRequest{TeamName: "backend", AuthorID: "r1"},
+			reviewers: []string{"r2"},
+			batch:     map[string]bool{"a": true},
+			load:      map[string]int{"u1": 5, "r3": 4},
+			want:      "r3",
+		},
+		{
+			name: "repository pool instead of team",
+			pr:   models.PullRequest{TeamName: "backend", RepositoryID: "repo", AuthorID: "a"},
+			load: map[string]int{"r3": 1},
+			want: "x",
+		},
+		{
+			name:       "no reviewer pool",
+			pr:         models.PullRequest{AuthorID: "a"},
+			wantReason: UnstaffedNoReviewerPool,
+		},
+		{
+			name:       "no active candidates",
+			pr:         models.PullRequest{TeamName: "mobile", AuthorID: "a"},
+			wantReason: UnstaffedNoActiveCandidate,
+		},
+		{
+			name:       "all candidates deactivated",
+			pr:         models.PullRequest{RepositoryID: "repo", AuthorID: "a"},
+			batch:      map[string]bool{"r3": true, "x": true},
+			wantReason: UnstaffedBatchDeactivated,
+		},
+		{
+			name:       "no eligible candidates",
+			pr:         models.PullRequest{RepositoryID: "repo", AuthorID: "x"},
+			reviewers:  []string{"r3"},
+			wantReason: UnstaffedNoEligible,
+		},
+		{
+			name:       "partly deactivated pool without eligible candidates",
+			pr:         models.PullRequest{RepositoryID: "repo", AuthorID: "a"},
+			reviewers:  []string{"r3"},
+			batch:      map[string]bool{"x": true},
+			wantReason: UnstaffedNoEligible,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := pickLeastLoaded(tt.pr, tt.reviewers, tt.batch, tt.load, state)
+			if got != tt.want || reason != tt.wantReason {
+				t.Fatalf("got %q (%q), want %q (%q)", got, reason, tt.want, tt.wantReason)
+			}
+		})
+	}
+}
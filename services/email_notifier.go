@@ -0,0 +1,137 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/events"
+	"Backend-trainee-assignment/logging"
+	"Backend-trainee-assignment/notify"
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Виды писем, от которых можно отказаться
+const (
+	EmailKindAssignment = "assignment"
+	EmailKindDigest     = "digest"
+	EmailKindEscalation = "escalation"
+)
+
+// Письма ревьюерам о назначениях, снятии с ревью, мерже и напоминаниях.
+// Изменения одного получателя группируются EmailBatcher в одно письмо
+type EmailNotifier struct {
+	notificationRepo *repository.NotificationRepository
+	prRepo           *repository.PRRepository
+	batcher          *notify.EmailBatcher
+	stopped          chan struct{}
+}
+
+func NewEmailNotifier(notificationRepo *repository.NotificationRepository, prRepo *repository.PRRepository, batcher *notify.EmailBatcher) *EmailNotifier {
+	return &EmailNotifier{
+		notificationRepo: notificationRepo,
+		prRepo:           prRepo,
+		batcher:          batcher,
+		stopped:          make(chan struct{}),
+	}
+}
+
+// Функция подписывается на события шины до отмены контекста
+func (n *EmailNotifier) Start(ctx context.Context, bus *events.Bus) {
+	ch, unsubscribe := bus.Subscribe(1024)
+	go func() {
+		defer close(n.stopped)
+		defer unsubscribe()
+		baseCtx := logging.WithRequestID(context.WithoutCancel(ctx), "email-"+logging.NewRequestID())
+		for {
+			select {
+			case <-ctx.Done():
+				// События, опубликованные до остановки, еще попадают в письма
+				for {
+					select {
+					case event := <-ch:
+						n.handleEventWithTimeout(baseCtx, event)
+					default:
+						return
+					}
+				}
+			case event := <-ch:
+				n.handleEventWithTimeout(baseCtx, event)
+			}
+		}
+	}()
+}
+
+// Функция ждет остановки подписки после отмены контекста
+func (n *EmailNotifier) Wait() {
+	<-n.stopped
+}
+
+func (n *EmailNotifier) handleEventWithTimeout(ctx context.Context, event events.Event) {
+	ctx, cancel := context.WithTimeout(ctx, notificationLookupTimeout)
+	defer cancel()
+	n.handleEvent(ctx, event)
+}
+
+// Функция превращает событие в пункты писем затронутым ревьюерам
+func (n *EmailNotifier) handleEvent(ctx context.Context, event events.Event) {
+	if event.Type == events.Resync {
+		slog.WarnContext(ctx, "email notifier missed events: subscriber buffer overflowed")
+		return
+	}
+	pr := n.describePR(ctx, event.PRID)
+	switch event.Type {
+	case events.ReviewersAssigned, events.ReviewersBackfilled:
+		n.add(ctx, event.Reviewers, EmailKindAssignment, event.PRID, "You were assigned to review "+pr)
+	case events.ReviewerReassigned:
+		n.add(ctx, event.Reviewers, EmailKindAssignment, event.PRID, "You were assigned to review "+pr+" instead of "+event.UserID)
+		n.add(ctx, []string{event.UserID}, EmailKindAssignment, event.PRID, "You were removed from reviewers of "+pr)
+	case events.PRMerged:
+		n.add(ctx, event.Reviewers, EmailKindAssignment, event.PRID, pr+" was merged, no review needed")
+	}
+}
+
+// Функция добавляет дайджесты и эскалации напоминаний в письма их адресатам
+func (n *EmailNotifier) Notify(ctx context.Context, msg notify.Message) error {
+	var kind string
+	switch msg.Kind {
+	case notify.KindDigest:
+		kind = EmailKindDigest
+	case notify.KindEscalation:
+		kind = EmailKindEscalation
+	default:
+		return nil
+	}
+	for _, review := range msg.Reviews {
+		text := fmt.Sprintf("PR %s %q by %s is waiting for your review since %s",
+			review.PullRequestID, review.PullRequestName, review.AuthorID, review.AssignedAt.Format("2006-01-02 15:04"))
+		n.add(ctx, []string{review.ReviewerID}, kind, review.PullRequestID, text)
+	}
+	return nil
+}
+
+// Функция добавляет пункт в письма пользователей, у которых есть адрес и нет отказа от вида писем
+func (n *EmailNotifier) add(ctx context.Context, userIDs []string, kind, prID, text string) {
+	if len(userIDs) == 0 {
+		return
+	}
+	recipients, err := n.notificationRepo.GetEmailRecipients(ctx, userIDs, kind)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to load email recipients", "kind", kind, "error", err)
+		return
+	}
+	for _, recipient := range recipients {
+		n.batcher.Add(ctx, recipient.Email, recipient.Username, notify.EmailItem{Kind: kind, PRID: prID, Text: text})
+	}
+}
+
+// Функция возвращает описание PR для текста письма
+func (n *EmailNotifier) describePR(ctx context.Context, prID string) string {
+	if prID == "" {
+		return ""
+	}
+	pr, err := n.prRepo.GetPRByID(ctx, prID)
+	if err != nil || pr == nil {
+		return "PR " + prID
+	}
+	return fmt.Sprintf("PR %s %q by %s", pr.PullRequestID, pr.PullRequestName, pr.AuthorID)
+}
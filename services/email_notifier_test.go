@@ -0,0 +1,76 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/events"
+	"Backend-trainee-assignment/models"
+	"Backend-trainee-assignment/notify"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Mailer, запоминающий письма вместо отправки
+type recordingMailer struct {
+	mu     sync.Mutex
+	emails []notify.Email
+}
+
+func (m *recordingMailer) Send(_ context.Context, email notify.Email) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.emails = append(m.emails, email)
+	return nil
+}
+
+// Письма получают только ревьюеры с адресом, не отказавшиеся от вида писем
+func TestEmailNotifierSkipsOptedOutRecipients(t *testing.T) {
+	db := testDB(t)
+	f := newReminderFixture(t, db)
+	ctx := context.Background()
+	notificationRepo := repository.NewNotificationRepository(db)
+	for _, prefs := range []models.NotificationPreferences{
+		{UserID: f.r1, Email: f.r1 + "@example.com"},
+		{UserID: f.r2, Email: f.r2 + "@example.com", EmailOptOut: []string{EmailKindAssignment}},
+	} {
+		if err := notificationRepo.SetPreferences(ctx, &prefs); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mailer := &recordingMailer{}
+	batcher := notify.NewEmailBatcher(mailer, notify.EmailBatchConfig{Window: time.Hour, MaxAttempts: 1})
+	notifier := NewEmailNotifier(notificationRepo, repository.NewPRRepository(db), batcher)
+	bus := events.NewBus(0)
+	notifierCtx, stop := context.WithCancel(ctx)
+	notifier.Start(notifierCtx, bus)
+
+	bus.Publish(events.Event{Type: events.ReviewersAssigned, PRID: f.p1, Reviewers: []string{f.r1, f.r2}})
+	bus.Publish(events.Event{Type: events.PRMerged, PRID: f.p2, Reviewers: []string{f.r1, f.r2}})
+	// Дайджесты r2 не отключены
+	if err := notifier.Notify(ctx, notify.Message{Kind: notify.KindDigest, Reviews: []models.PendingReview{{PullRequestID: f.p1, ReviewerID: f.r2}}}); err != nil {
+		t.Fatal(err)
+	}
+	stop()
+	notifier.Wait()
+	closeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	batcher.Close(closeCtx)
+
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+	subjects := make(map[string]string)
+	for _, email := range mailer.emails {
+		subjects[email.To] = email.Subject
+	}
+	if len(mailer.emails) != 2 {
+		t.Fatalf("emails %+v, want one per recipient", mailer.emails)
+	}
+	if subject := subjects[f.r1+"@example.com"]; subject != "2 review updates" {
+		t.Fatalf("r1 subject %q, want both assignment updates in one email", subject)
+	}
+	if subject := subjects[f.r2+"@example.com"]; subject == "" || subject == "2 review updates" {
+		t.Fatalf("r2 subject %q, want only the digest", subject)
+	}
+}
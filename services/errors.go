@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Коды ошибок в ответах API
+const (
+	CodeValidation       = "VALIDATION_ERROR"
+	CodeNotFound         = "NOT_FOUND"
+	CodeTeamExists       = "TEAM_EXISTS"
+	CodeUserExists       = "USER_EXISTS"
+	CodePRExists         = "PR_EXISTS"
+	CodeRepositoryExists = "REPOSITORY_EXISTS"
+	CodePRMerged         = "PR_MERGED"
+	CodeNotAssigned      = "NOT_ASSIGNED"
+	CodeNoCandidate      = "NO_CANDIDATE"
+	CodeAlreadyMember    = "ALREADY_MEMBER"
+	CodeNotMember        = "NOT_MEMBER"
+	CodeMaxReviewers     = "MAX_REVIEWERS"
+	CodeUserInactive     = "USER_INACTIVE"
+	CodeAuthorSelfReview = "AUTHOR_SELF_REVIEW"
+	CodeAlreadyAssigned  = "ALREADY_ASSIGNED"
+	CodeNoTargetTeam     = "NO_TARGET_TEAM"
+	CodeInProgress       = "REQUEST_IN_PROGRESS"
+	CodeKeyReused        = "IDEMPOTENCY_KEY_REUSED"
+	CodeTimeout          = "TIMEOUT"
+	CodeInternal         = "INTERNAL_ERROR"
+)
+
+// Доменная ошибка с кодом API и HTTP-статусом
+type Error struct {
+	Code    string
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+var (
+	ErrPRNotFound         = &Error{Code: CodeNotFound, Status: http.StatusNotFound, Message: "PR not found"}
+	ErrUserNotFound       = &Error{Code: CodeNotFound, Status: http.StatusNotFound, Message: "user not found"}
+	ErrAuthorNotFound     = &Error{Code: CodeNotFound, Status: http.StatusNotFound, Message: "author not found"}
+	ErrReviewerNotFound   = &Error{Code: CodeNotFound, Status: http.StatusNotFound, Message: "reviewer not found"}
+	ErrTeamNotFound       = &Error{Code: CodeNotFound, Status: http.StatusNotFound, Message: "team not found"}
+	ErrRepositoryNotFound = &Error{Code: CodeNotFound, Status: http.StatusNotFound, Message: "repository not found"}
+	ErrWebhookNotFound    = &Error{Code: CodeNotFound, Status: http.StatusNotFound, Message: "team webhook not found"}
+
+	ErrTeamExists           = &Error{Code: CodeTeamExists, Status: http.StatusBadRequest, Message: "team_name already exists"}
+	ErrUserExists           = &Error{Code: CodeUserExists, Status: http.StatusConflict, Message: "user_id already exists"}
+	ErrPRExists             = &Error{Code: CodePRExists, Status: http.StatusConflict, Message: "PR id already exists"}
+	ErrRepositoryExists     = &Error{Code: CodeRepositoryExists, Status: http.StatusConflict, Message: "repository_id already exists"}
+	ErrRepositoryNameExists = &Error{Code: CodeRepositoryExists, Status: http.StatusConflict, Message: "repository name already exists"}
+
+	ErrPRMerged            = &Error{Code: CodePRMerged, Status: http.StatusConflict, Message: "cannot change reviewers of merged PR"}
+	ErrReviewerNotAssigned = &Error{Code: CodeNotAssigned, Status: http.StatusConflict, Message: "reviewer is not assigned to this PR"}
+	ErrNoCandidate         = &Error{Code: CodeNoCandidate, Status: http.StatusConflict, Message: "no active replacement candidate"}
+	ErrNoTargetTeam        = &Error{Code: CodeNoTargetTeam, Status: http.StatusConflict, Message: "PR has no target team or repository"}
+
+	ErrAlreadyMember = &Error{Code: CodeAlreadyMember, Status: http.StatusConflict, Message: "user is already a member of the team"}
+	ErrNotMember     = &Error{Code: CodeNotMember, Status: http.StatusConflict, Message: "user is not a member of the team"}
+
+	// Ручное добавление ревьюера в смерженный PR - ошибка запроса (400), как и остальные проверки добавления
+	ErrAddToMergedPR    = &Error{Code: CodePRMerged, Status: http.StatusBadRequest, Message: "cannot add reviewers to merged PR"}
+	ErrMaxReviewers     = &Error{Code: CodeMaxReviewers, Status: http.StatusBadRequest, Message: "PR already has maximum reviewers (2)"}
+	ErrUserInactive     = &Error{Code: CodeUserInactive, Status: http.StatusBadRequest, Message: "cannot assign inactive user as reviewer"}
+	ErrAuthorSelfReview = &Error{Code: CodeAuthorSelfReview, Status: http.StatusBadRequest, Message: "cannot assign author as reviewer"}
+	ErrAlreadyAssigned  = &Error{Code: CodeAlreadyAssigned, Status: http.StatusBadRequest, Message: "user is already assigned as reviewer"}
+
+	ErrRequestInProgress    = &Error{Code: CodeInProgress, Status: http.StatusConflict, Message: "request with this idempotency key is in progress"}
+	ErrIdempotencyKeyReused = &Error{Code: CodeKeyReused, Status: http.StatusUnprocessableEntity, Message: "idempotency key was used with a different request"}
+)
+
+// Ошибка проверки входных данных
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func NewValidationError(message string) *ValidationError {
+	return &ValidationError{Message: message}
+}
+
+// Функция проверяет, является ли ошибка доменной ошибкой с указанным кодом
+func HasCode(err error, code string) bool {
+	var domainErr *Error
+	return errors.As(err, &domainErr) && domainErr.Code == code
+}
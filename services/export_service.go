@@ -0,0 +1,66 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/models"
+	"context"
+)
+
+type ExportService struct {
+	exportRepo *repository.ExportRepository
+}
+
+func NewExportService(exportRepo *repository.ExportRepository) *ExportService {
+	return &ExportService{exportRepo: exportRepo}
+}
+
+// Функция выгружает PR с ревьюерами, передавая строки в fn по мере чтения
+func (s *ExportService) ExportPullRequests(ctx context.Context, f models.ExportFilter, fn func(models.PRExport) error) error {
+	f, err := normalizeExportFilter(f)
+	if err != nil {
+		return err
+	}
+	return s.exportRepo.StreamPullRequests(ctx, f, fn)
+}
+
+// Функция выгружает историю назначений, передавая строки в fn по мере чтения
+func (s *ExportService) ExportAssignments(ctx context.Context, f models.ExportFilter, fn func(models.AssignmentExport) error) error {
+	f, err := normalizeExportFilter(f)
+	if err != nil {
+		return err
+	}
+	return s.exportRepo.StreamAssignments(ctx, f, fn)
+}
+
+// Функция выгружает статистику пользователей, передавая строки в fn по мере чтения
+func (s *ExportService) ExportUserStats(ctx context.Context, f models.ExportFilter, fn func(models.UserStatsExport) error) error {
+	f, err := normalizeExportFilter(f)
+	if err != nil {
+		return err
+	}
+	if f.Status != "" {
+		return NewValidationError("status filter is not supported for user stats")
+	}
+	return s.exportRepo.StreamUserStats(ctx, f, fn)
+}
+
+// Функция проверяет фильтры и переводит границы периода в UTC, в котором время хранится в БД
+func normalizeExportFilter(f models.ExportFilter) (models.ExportFilter, error) {
+	switch f.Status {
+	case "", "OPEN", "MERGED":
+	default:
+		return f, NewValidationError("status must be OPEN or MERGED")
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return f, NewValidationError("from must be before to")
+	}
+	if f.From != nil {
+		from := f.From.UTC()
+		f.From = &from
+	}
+	if f.To != nil {
+		to := f.To.UTC()
+		f.To = &to
+	}
+	return f, nil
+}
@@ -0,0 +1,517 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/events"
+	"Backend-trainee-assignment/metrics"
+	"Backend-trainee-assignment/models"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// Форматы файла оргструктуры
+const (
+	OrgChartCSV  = "csv"
+	OrgChartYAML = "yaml"
+)
+
+// Импорт оргструктуры: файл считается полным составом перечисленных в нем команд
+type ImportService struct {
+	importRepo      *repository.ImportRepository
+	reviewerService *ReviewerService
+	reconciler      *Reconciler
+	bus             *events.Bus
+}
+
+func NewImportService(importRepo *repository.ImportRepository, reviewerService *ReviewerService, reconciler *Reconciler, bus *events.Bus) *ImportService {
+	return &ImportService{
+		importRepo:      importRepo,
+		reviewerService: reviewerService,
+		reconciler:      reconciler,
+		bus:             bus,
+	}
+}
+
+// Функция сравнивает оргструктуру с текущим состоянием и, если это не dry run, применяет план.
+// Команды из файла создаются, пользователи добавляются, переименовываются, включаются и выключаются
+// по is_active, теряют участие в перечисленных командах, где их больше нет. Участники перечисленных
+// команд, которых нет в файле, деактивируются. Их открытые ревью переназначаются в пуле ревьюеров PR.
+// План строится по состоянию, прочитанному под блокировкой, и применяется в той же транзакции
+func (s *ImportService) Import(ctx context.Context, chart []models.Team, dryRun bool) (*models.ImportPlan, error) {
+	if len(chart) == 0 {
+		return nil, NewValidationError("org chart has no teams")
+	}
+	tx, err := s.importRepo.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	teams, err := tx.GetTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users, err := tx.LockUsersWithTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, state := diffOrgChart(chart, teams, users)
+	plan.DryRun = dryRun
+	if err := s.planReassignments(ctx, tx, plan, state); err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return plan, nil
+	}
+
+	planned := len(plan.Reassignments)
+	applied, err := tx.Apply(ctx, plan)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при применении импорта: %w", err)
+	}
+	plan.Reassignments = applied
+	plan.Applied = true
+	if skipped := planned - len(applied); skipped > 0 {
+		slog.WarnContext(ctx, "import reassignments skipped: PR closed or reviewer already removed", "skipped", skipped)
+	}
+	slog.InfoContext(ctx, "org chart imported",
+		"teams_created", len(plan.TeamsToCreate), "users_added", len(plan.UsersToAdd),
+		"users_moved", len(plan.UsersToMove), "users_deactivated", len(plan.UsersToDeactivate),
+		"reassignments", len(plan.Reassignments))
+
+	for _, userID := range plan.UsersToDeactivate {
+		publishDeactivated(s.bus, state.users[userID], metrics.ReasonImport)
+	}
+	prs := make(map[string]*models.PullRequest, len(state.prs))
+	for i := range state.prs {
+		prs[state.prs[i].PullRequestID] = &state.prs[i]
+	}
+	for _, reassignment := range plan.Reassignments {
+		if reassignment.NewReviewerID != "" {
+			metrics.ReviewersAssigned.Inc()
+			metrics.Reassignments.WithLabelValues(metrics.ReasonImport).Inc()
+		}
+		s.reviewerService.publishReassigned(prs[reassignment.PullRequestID], reassignment.OldReviewerID, reassignment.NewReviewerID, metrics.ReasonImport)
+	}
+	if len(plan.UsersToAdd) > 0 || len(plan.UsersToActivate) > 0 || len(plan.UsersToMove) > 0 {
+		// Новые активные участники могут дополнить PR без ревьюеров
+		s.reconciler.Trigger()
+	}
+	return plan, nil
+}
+
+// Состояние после импорта, по которому подбираются замены ревьюерам
+type importState struct {
+	users   map[string]*models.User
+	members map[string][]string
+	// Ревью пользователя снимаются: деактивация - на всех PR, уход из команды - на PR, из пула которых
+	// пользователь выпал
+	deactivated map[string]bool
+	leftTeams   map[string]map[string]bool
+	prs         []models.PullRequest
+	// Правила пулов репозиториев затронутых PR
+	repositories map[string]*models.Repository
+}
+
+// Функция возвращает команды пула ревьюеров PR и его участников после импорта, как
+// ReviewerService.candidatePool: для PR репозитория - участники команд репозитория и дополнительные
+// ревьюеры без исключенных, иначе - участники команды PR
+func (s *importState) pool(pr *models.PullRequest) ([]string, []string) {
+	if pr.RepositoryID != "" {
+		repo := s.repositories[pr.RepositoryID]
+		if repo == nil {
+			return nil, nil
+		}
+		var members []string
+		for _, team := range repo.Teams {
+			for _, userID := range s.members[team] {
+				if !contains(members, userID) {
+					members = append(members, userID)
+				}
+			}
+		}
+		for _, userID := range repo.ExtraReviewers {
+			if !contains(members, userID) {
+				members = append(members, userID)
+			}
+		}
+		result := members[:0]
+		for _, userID := range members {
+			if !contains(repo.ExcludedReviewers, userID) {
+				result = append(result, userID)
+			}
+		}
+		return repo.Teams, result
+	}
+	if pr.TeamName != "" {
+		return []string{pr.TeamName}, s.members[pr.TeamName]
+	}
+	return nil, nil
+}
+
+// Функция проверяет, снимается ли ревьюер с PR: деактивирован или ушел из команды пула PR
+// и больше не входит в пул
+func (s *importState) losesReview(pr *models.PullRequest, userID string) bool {
+	if s.deactivated[userID] {
+		return true
+	}
+	teams, members := s.pool(pr)
+	for _, team := range teams {
+		if s.leftTeams[userID][team] {
+			return !contains(members, userID)
+		}
+	}
+	return false
+}
+
+// Функция возвращает кандидатов на замену: активные участники пула PR после импорта,
+// кроме автора и текущих ревьюеров
+func (s *importState) candidates(pr *models.PullRequest, reviewers []string) []models.User {
+	_, members := s.pool(pr)
+	var candidates []models.User
+	for _, userID := range members {
+		user := s.users[userID]
+		if user != nil && user.IsActive && userID != pr.AuthorID && !contains(reviewers, userID) {
+			candidates = append(candidates, *user)
+		}
+	}
+	return candidates
+}
+
+// Функция строит план изменений команд и пользователей без переназначений
+func diffOrgChart(chart []models.Team, teams []models.Team, users []models.User) (*models.ImportPlan, *importState) {
+	plan := &models.ImportPlan{
+		TeamsToCreate:     []string{},
+		UsersToAdd:        []models.ImportUser{},
+		UsersToMove:       []models.ImportMove{},
+		UsersToRename:     []models.ImportRename{},
+		UsersToActivate:   []string{},
+		UsersToDeactivate: []string{},
+		Reassignments:     []models.Reassignment{},
+	}
+	state := &importState{
+		users:       make(map[string]*models.User),
+		members:     make(map[string][]string),
+		deactivated: make(map[string]bool),
+		leftTeams:   make(map[string]map[string]bool),
+	}
+	existingTeams := make(map[string]bool, len(teams))
+	for _, team := range teams {
+		existingTeams[team.TeamName] = true
+	}
+	for i := range users {
+		state.users[users[i].UserID] = &users[i]
+	}
+
+	chartTeams := make(map[string]bool, len(chart))
+	var chartUsers []string
+	userTeams := make(map[string][]string)
+	chartMember := make(map[string]models.TeamMember)
+	for _, team := range chart {
+		chartTeams[team.TeamName] = true
+		if !existingTeams[team.TeamName] {
+			plan.TeamsToCreate = append(plan.TeamsToCreate, team.TeamName)
+		}
+		for _, member := range team.Members {
+			if _, seen := chartMember[member.UserID]; !seen {
+				chartUsers = append(chartUsers, member.UserID)
+			}
+			chartMember[member.UserID] = member
+			userTeams[member.UserID] = append(userTeams[member.UserID], team.TeamName)
+		}
+	}
+
+	for _, userID := range chartUsers {
+		member := chartMember[userID]
+		newTeams := userTeams[userID]
+		sort.Strings(newTeams)
+		current, exists := state.users[userID]
+		if !exists {
+			plan.UsersToAdd = append(plan.UsersToAdd, models.ImportUser{
+				UserID:   userID,
+				Username: member.Username,
+				IsActive: member.IsActive,
+				Teams:    newTeams,
+			})
+			state.users[userID] = &models.User{UserID: userID, Username: member.Username, IsActive: member.IsActive, Teams: newTeams}
+			continue
+		}
+
+		if current.Username != member.Username {
+			plan.UsersToRename = append(plan.UsersToRename, models.ImportRename{
+				UserID: userID, OldUsername: current.Username, Username: member.Username,
+			})
+		}
+		switch {
+		case member.IsActive && !current.IsActive:
+			plan.UsersToActivate = append(plan.UsersToActivate, userID)
+		case !member.IsActive && current.IsActive:
+			plan.UsersToDeactivate = append(plan.UsersToDeactivate, userID)
+			state.deactivated[userID] = true
+		}
+
+		move := models.ImportMove{UserID: userID, AddTeams: []string{}, RemoveTeams: []string{}}
+		for _, team := range newTeams {
+			if !contains(current.Teams, team) {
+				move.AddTeams = append(move.AddTeams, team)
+			}
+		}
+		for _, team := range current.Teams {
+			if chartTeams[team] && !contains(newTeams, team) {
+				move.RemoveTeams = append(move.RemoveTeams, team)
+				if state.leftTeams[userID] == nil {
+					state.leftTeams[userID] = make(map[string]bool)
+				}
+				state.leftTeams[userID][team] = true
+			}
+		}
+		if len(move.AddTeams) > 0 || len(move.RemoveTeams) > 0 {
+			plan.UsersToMove = append(plan.UsersToMove, move)
+		}
+	}
+
+	// Участники перечисленных команд, которых нет в файле
+	for _, user := range users {
+		if _, listed := chartMember[user.UserID]; listed || !user.IsActive {
+			continue
+		}
+		for _, team := range user.Teams {
+			if chartTeams[team] {
+				plan.UsersToDeactivate = append(plan.UsersToDeactivate, user.UserID)
+				state.deactivated[user.UserID] = true
+				break
+			}
+		}
+	}
+
+	// Состав и активность после импорта
+	for _, user := range state.users {
+		if member, listed := chartMember[user.UserID]; listed {
+			user.IsActive = member.IsActive
+		} else if state.deactivated[user.UserID] {
+			user.IsActive = false
+		}
+	}
+	for _, team := range chart {
+		for _, member := range team.Members {
+			state.members[team.TeamName] = append(state.members[team.TeamName], member.UserID)
+		}
+	}
+	for _, user := range users {
+		for _, team := range user.Teams {
+			if !chartTeams[team] {
+				state.members[team] = append(state.members[team], user.UserID)
+			}
+		}
+	}
+	return plan, state
+}
+
+// Функция блокирует открытые PR деактивируемых и уходящих из команд пользователей и подбирает
+// замены их ревью среди активных участников пула PR после импорта
+func (s *ImportService) planReassignments(ctx context.Context, tx *repository.ImportTx, plan *models.ImportPlan, state *importState) error {
+	var affected []string
+	for userID := range state.deactivated {
+		affected = append(affected, userID)
+	}
+	for userID := range state.leftTeams {
+		if !state.deactivated[userID] {
+			affected = append(affected, userID)
+		}
+	}
+	if len(affected) == 0 {
+		return nil
+	}
+	prs, err := tx.LockOpenPRsByReviewers(ctx, affected)
+	if err != nil {
+		return fmt.Errorf("ошибка при поиске PR ревьюеров: %w", err)
+	}
+	state.prs = prs
+	var repositoryIDs []string
+	for _, pr := range prs {
+		if pr.RepositoryID != "" && !contains(repositoryIDs, pr.RepositoryID) {
+			repositoryIDs = append(repositoryIDs, pr.RepositoryID)
+		}
+	}
+	if state.repositories, err = tx.GetRepositoryPools(ctx, repositoryIDs); err != nil {
+		return fmt.Errorf("ошибка при чтении пулов репозиториев: %w", err)
+	}
+
+	for i := range prs {
+		pr := &prs[i]
+		reviewers := append([]string(nil), pr.AssignedReviewers...)
+		for _, oldReviewerID := range pr.AssignedReviewers {
+			if !state.losesReview(pr, oldReviewerID) {
+				continue
+			}
+			candidates := state.candidates(pr, reviewers)
+			reassignment := models.Reassignment{PullRequestID: pr.PullRequestID, OldReviewerID: oldReviewerID}
+			reviewers = removeString(reviewers, oldReviewerID)
+			if len(candidates) > 0 {
+				selected, err := s.reviewerService.selectReviewers(ctx, pr, candidates, 1)
+				if err != nil {
+					return fmt.Errorf("ошибка при выборе ревьюера: %w", err)
+				}
+				reassignment.NewReviewerID = selected[0].UserID
+				reviewers = append(reviewers, selected[0].UserID)
+			}
+			plan.Reassignments = append(plan.Reassignments, reassignment)
+		}
+	}
+	return nil
+}
+
+func removeString(slice []string, item string) []string {
+	result := slice[:0]
+	for _, s := range slice {
+		if s != item {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// Описание команды в YAML-файле оргструктуры, is_active по умолчанию true
+type orgChartFile struct {
+	Teams []struct {
+		TeamName string `yaml:"team_name"`
+		Members  []struct {
+			UserID   string `yaml:"user_id"`
+			Username string `yaml:"username"`
+			IsActive *bool  `yaml:"is_active"`
+		} `yaml:"members"`
+	} `yaml:"teams"`
+}
+
+// Функция разбирает оргструктуру. YAML: teams со списками members, как в /team/add.
+// CSV: заголовок team_name,user_id,username[,is_active], строка на участие пользователя в команде;
+// строка без user_id задает команду без участников
+func ParseOrgChart(format string, content []byte) ([]models.Team, error) {
+	chart := &orgChartBuilder{index: make(map[string]int), users: make(map[string]models.TeamMember)}
+	switch format {
+	case OrgChartYAML:
+		var file orgChartFile
+		if err := yaml.Unmarshal(content, &file); err != nil {
+			return nil, NewValidationError("invalid YAML: " + err.Error())
+		}
+		for i, team := range file.Teams {
+			where := fmt.Sprintf("team %d", i+1)
+			if err := chart.addTeam(where, team.TeamName); err != nil {
+				return nil, err
+			}
+			for j, m := range team.Members {
+				isActive := m.IsActive == nil || *m.IsActive
+				where := fmt.Sprintf("team %d member %d", i+1, j+1)
+				if err := chart.addMember(where, team.TeamName, m.UserID, m.Username, isActive); err != nil {
+					return nil, err
+				}
+			}
+		}
+	case OrgChartCSV:
+		if err := chart.parseCSV(content); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, NewValidationError("format must be csv or yaml")
+	}
+	return chart.teams, nil
+}
+
+type orgChartBuilder struct {
+	teams []models.Team
+	index map[string]int
+	users map[string]models.TeamMember
+}
+
+func (b *orgChartBuilder) parseCSV(content []byte) error {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return NewValidationError("invalid CSV: missing header")
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"team_name", "user_id", "username"} {
+		if _, ok := columns[name]; !ok {
+			return NewValidationError("invalid CSV: column " + name + " is required")
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return NewValidationError("invalid CSV: " + err.Error())
+		}
+		where := fmt.Sprintf("line %d", line)
+		teamName := field(record, "team_name")
+		if err := b.addTeam(where, teamName); err != nil {
+			return err
+		}
+		userID := field(record, "user_id")
+		if userID == "" && field(record, "username") == "" {
+			continue
+		}
+		isActive := true
+		if value := field(record, "is_active"); value != "" {
+			if isActive, err = strconv.ParseBool(value); err != nil {
+				return NewValidationError(where + ": is_active must be true or false")
+			}
+		}
+		if err := b.addMember(where, teamName, userID, field(record, "username"), isActive); err != nil {
+			return err
+		}
+	}
+}
+
+func (b *orgChartBuilder) addTeam(where, teamName string) error {
+	if teamName == "" {
+		return NewValidationError(where + ": team_name is required")
+	}
+	if _, ok := b.index[teamName]; !ok {
+		b.index[teamName] = len(b.teams)
+		b.teams = append(b.teams, models.Team{TeamName: teamName, Members: []models.TeamMember{}})
+	}
+	return nil
+}
+
+// Функция добавляет участника; у пользователя в разных командах имя и is_active должны совпадать
+func (b *orgChartBuilder) addMember(where, teamName, userID, username string, isActive bool) error {
+	if userID == "" || username == "" {
+		return NewValidationError(where + ": user_id and username are required")
+	}
+	member := models.TeamMember{UserID: userID, Username: username, IsActive: isActive}
+	if previous, ok := b.users[userID]; ok && previous != member {
+		return NewValidationError(where + ": conflicting username or is_active for user " + userID)
+	}
+	b.users[userID] = member
+	team := &b.teams[b.index[teamName]]
+	for _, m := range team.Members {
+		if m.UserID == userID {
+			return nil
+		}
+	}
+	team.Members = append(team.Members, member)
+	return nil
+}
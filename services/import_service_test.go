@@ -0,0 +1,324 @@
+package service
+
+import (
+	"Backend-trainee-assignment/models"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOrgChart(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		content string
+		want    []models.Team
+		wantErr string
+	}{
+		{
+			name:   "yaml",
+			format: OrgChartYAML,
+			content: `
+teams:
+  - team_name: backend
+    members:
+      - {user_id: u1, username: Alice}
+      - {user_id: u2, username: Bob, is_active: false}
+  - team_name: empty
+`,
+			want: []models.Team{
+				{TeamName: "backend", Members: []models.TeamMember{
+					{UserID: "u1", Username: "Alice", IsActive: true},
+					{UserID: "u2", Username: "Bob", IsActive: false},
+				}},
+				{TeamName: "empty", Members: []models.TeamMember{}},
+			},
+		},
+		{
+			name:   "csv",
+			format: OrgChartCSV,
+			content: "team_name,user_id,username,is_active\n" +
+				"backend,u1,Alice,\n" +
+				"backend,u2,Bob,false\n" +
+				"frontend, u1, Alice, true\n" +
+				"backend,u1,Alice,true\n" +
+				"ops,,,\n",
+			want: []models.Team{
+				{TeamName: "backend", Members: []models.TeamMember{
+					{UserID: "u1", Username: "Alice", IsActive: true},
+					{UserID: "u2", Username: "Bob", IsActive: false},
+				}},
+				{TeamName: "frontend", Members: []models.TeamMember{{UserID: "u1", Username: "Alice", IsActive: true}}},
+				{TeamName: "ops", Members: []models.TeamMember{}},
+			},
+		},
+		{
+			name:    "csv without is_active column",
+			format:  OrgChartCSV,
+			content: "user_id,username,team_name\nu1,Alice,backend\n",
+			want: []models.Team{
+				{TeamName: "backend", Members: []models.TeamMember{{UserID: "u1", Username: "Alice", IsActive: true}}},
+			},
+		},
+		{name: "unknown format", format: "json", content: "{}", wantErr: "format must be csv or yaml"},
+		{name: "invalid yaml", format: OrgChartYAML, content: "teams: [", wantErr: "invalid YAML"},
+		{name: "yaml team without name", format: OrgChartYAML, content: "teams:\n  - members: []\n", wantErr: "team 1: team_name is required"},
+		{
+			name:    "yaml member without username",
+			format:  OrgChartYAML,
+			content: "teams:\n  - team_name: backend\n    members:\n      - user_id: u1\n",
+			wantErr: "team 1 member 1: user_id and username are required",
+		},
+		{name: "csv without header", format: OrgChartCSV, content: "", wantErr: "invalid CSV: missing header"},
+		{name: "csv missing column", format: OrgChartCSV, content: "team_name,user_id\nbackend,u1\n", wantErr: "invalid CSV: column username is required"},
+		{name: "csv team without name", format: OrgChartCSV, content: "team_name,user_id,username\n,u1,Alice\n", wantErr: "line 2: team_name is required"},
+		{name: "csv member without user_id", format: OrgChartCSV, content: "team_name,user_id,username\nbackend,,Alice\n", wantErr: "line 2: user_id and username are required"},
+		{
+			name:    "csv bad is_active",
+			format:  OrgChartCSV,
+			content: "team_name,user_id,username,is_active\nbackend,u1,Alice,maybe\n",
+			wantErr: "line 2: is_active must be true or false",
+		},
+		{
+			name:    "csv conflicting username",
+			format:  OrgChartCSV,
+			content: "team_name,user_id,username\nbackend,u1,Alice\nfrontend,u1,Alicia\n",
+			wantErr: "line 3: conflicting username or is_active for user u1",
+		},
+		{
+			name:    "yaml conflicting is_active",
+			format:  OrgChartYAML,
+			content: "teams:\n  - team_name: backend\n    members: [{user_id: u1, username: Alice}]\n  - team_name: frontend\n    members: [{user_id: u1, username: Alice, is_active: false}]\n",
+			wantErr: "team 2 member 1: conflicting username or is_active for user u1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOrgChart(tt.format, []byte(tt.content))
+			if tt.wantErr != "" {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("error %v, want validation error %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffOrgChart(t *testing.T) {
+	member := func(userID, username string, isActive bool) models.TeamMember {
+		return models.TeamMember{UserID: userID, Username: username, IsActive: isActive}
+	}
+	emptyPlan := func() models.ImportPlan {
+		return models.ImportPlan{
+			TeamsToCreate:     []string{},
+			UsersToAdd:        []models.ImportUser{},
+			UsersToMove:       []models.ImportMove{},
+			UsersToRename:     []models.ImportRename{},
+			UsersToActivate:   []string{},
+			UsersToDeactivate: []string{},
+			Reassignments:     []models.Reassignment{},
+		}
+	}
+	teams := []models.Team{{TeamName: "backend"}, {TeamName: "frontend"}}
+	users := []models.User{
+		{UserID: "u1", Username: "Alice", IsActive: true, Teams: []string{"backend"}},
+		{UserID: "u2", Username: "Bob", IsActive: true, Teams: []string{"backend", "frontend"}},
+		{UserID: "u3", Username: "Carol", IsActive: true, Teams: []string{"backend"}},
+		{UserID: "u4", Username: "Dan", IsActive: false, Teams: []string{"backend"}},
+		{UserID: "u5", Username: "Eve", IsActive: true, Teams: []string{"ops"}},
+		{UserID: "u6", Username: "Frank", IsActive: false, Teams: []string{"frontend"}},
+	}
+
+	tests := []struct {
+		name            string
+		chart           []models.Team
+		want            func(plan *models.ImportPlan)
+		wantDeactivated map[string]bool
+		wantLeft        map[string]map[string]bool
+		wantMembers     map[string][]string
+		wantActive      map[string]bool
+	}{
+		{
+			name: "no changes",
+			chart: []models.Team{
+				{TeamName: "backend", Members: []models.TeamMember{member("u1", "Alice", true), member("u2", "Bob", true), member("u3", "Carol", true), member("u4", "Dan", false)}},
+			},
+			want:            func(plan *models.ImportPlan) {},
+			wantDeactivated: map[string]bool{},
+			wantLeft:        map[string]map[string]bool{},
+			wantMembers:     map[string][]string{"backend": {"u1", "u2", "u3", "u4"}, "frontend": {"u2", "u6"}, "ops": {"u5"}},
+			wantActive:      map[string]bool{"u1": true, "u2": true, "u3": true, "u4": false, "u6": false},
+		},
+		{
+			name: "create, add, rename, activate, move and deactivate unlisted",
+			chart: []models.Team{
+				{TeamName: "backend", Members: []models.TeamMember{member("u1", "Alicia", true), member("u7", "Grace", true)}},
+				{TeamName: "frontend", Members: []models.TeamMember{member("u2", "Bob", true), member("u6", "Frank", true), member("u1", "Alicia", true)}},
+				{TeamName: "mobile", Members: []models.TeamMember{member("u2", "Bob", true)}},
+			},
+			want: func(plan *models.ImportPlan) {
+				plan.TeamsToCreate = []string{"mobile"}
+				plan.UsersToAdd = []models.ImportUser{{UserID: "u7", Username: "Grace", IsActive: true, Teams: []string{"backend"}}}
+				plan.UsersToRename = []models.ImportRename{{UserID: "u1", OldUsername: "Alice", Username: "Alicia"}}
+				plan.UsersToActivate = []string{"u6"}
+				plan.UsersToDeactivate = []string{"u3"}
+				plan.UsersToMove = []models.ImportMove{
+					{UserID: "u1", AddTeams: []string{"frontend"}, RemoveTeams: []string{}},
+					{UserID: "u2", AddTeams: []string{"mobile"}, RemoveTeams: []string{"backend"}},
+				}
+			},
+			wantDeactivated: map[string]bool{"u3": true},
+			wantLeft:        map[string]map[string]bool{"u2": {"backend": true}},
+			wantMembers: map[string][]string{
+				"backend": {"u1", "u7"}, "frontend": {"u2", "u6", "u1"}, "mobile": {"u2"}, "ops": {"u5"},
+			},
+			wantActive: map[string]bool{"u1": true, "u3": false, "u4": false, "u5": true, "u6": true, "u7": true},
+		},
+		{
+			name: "listed inactive user is deactivated",
+			chart: []models.Team{
+				{TeamName: "frontend", Members: []models.TeamMember{member("u2", "Bob", false), member("u6", "Frank", false)}},
+			},
+			want: func(plan *models.ImportPlan) {
+				plan.UsersToDeactivate = []string{"u2"}
+			},
+			wantDeactivated: map[string]bool{"u2": true},
+			wantLeft:        map[string]map[string]bool{},
+			wantMembers:     map[string][]string{"frontend": {"u2", "u6"}, "backend": {"u1", "u2", "u3", "u4"}, "ops": {"u5"}},
+			wantActive:      map[string]bool{"u1": true, "u2": false, "u6": false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := make([]models.User, len(users))
+			for i, user := range users {
+				current[i] = user
+				current[i].Teams = append([]string(nil), user.Teams...)
+			}
+			plan, state := diffOrgChart(tt.chart, teams, current)
+
+			want := emptyPlan()
+			tt.want(&want)
+			if !reflect.DeepEqual(*plan, want) {
+				t.Fatalf("plan %+v, want %+v", *plan, want)
+			}
+			if !reflect.DeepEqual(state.deactivated, tt.wantDeactivated) {
+				t.Fatalf("deactivated %v, want %v", state.deactivated, tt.wantDeactivated)
+			}
+			if !reflect.DeepEqual(state.leftTeams, tt.wantLeft) {
+				t.Fatalf("left teams %v, want %v", state.leftTeams, tt.wantLeft)
+			}
+			if !reflect.DeepEqual(state.members, tt.wantMembers) {
+				t.Fatalf("members %v, want %v", state.members, tt.wantMembers)
+			}
+			for userID, isActive := range tt.wantActive {
+				if user := state.users[userID]; user == nil || user.IsActive != isActive {
+					t.Fatalf("user %s after import %+v, want is_active %v", userID, user, isActive)
+				}
+			}
+		})
+	}
+}
+
+// Снятие ревьюера и кандидаты на замену считаются по пулу PR после импорта
+func TestImportStateReassignmentPool(t *testing.T) {
+	state := &importState{
+		users: map[string]*models.User{
+			"u1": {UserID: "u1", IsActive: true},
+			"u2": {UserID: "u2", IsActive: true},
+			"u3": {UserID: "u3", IsActive: false},
+			"u4": {UserID: "u4", IsActive: true},
+			"u5": {UserID: "u5", IsActive: true},
+			"u6": {UserID: "u6", IsActive: true},
+			"u7": {UserID: "u7", IsActive: true},
+			"u8": {UserID: "u8", IsActive: false},
+			"u9": {UserID: "u9", IsActive: true},
+		},
+		members: map[string][]string{
+			"backend":  {"u1", "u3", "u4"},
+			"frontend": {"u2", "u5"},
+			"ops":      {"u6", "u9"},
+		},
+		deactivated: map[string]bool{"u8": true},
+		leftTeams:   map[string]map[string]bool{"u2": {"backend": true}, "u9": {"backend": true}},
+		repositories: map[string]*models.Repository{
+			"repo-1": {RepositoryID: "repo-1", Teams: []string{"backend", "frontend"}, ExtraReviewers: []string{"u7"}, ExcludedReviewers: []string{"u4"}},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		pr             models.PullRequest
+		reviewer       string
+		wantLoses      bool
+		wantCandidates []string
+	}{
+		{
+			name:           "team PR, reviewer left the team",
+			pr:             models.PullRequest{TeamName: "backend", AuthorID: "u1", AssignedReviewers: []string{"u2"}},
+			reviewer:       "u2",
+			wantLoses:      true,
+			wantCandidates: []string{"u4"},
+		},
+		{
+			name:           "repository PR, reviewer still in the pool through another team",
+			pr:             models.PullRequest{RepositoryID: "repo-1", TeamName: "backend", AuthorID: "u5", AssignedReviewers: []string{"u2"}},
+			reviewer:       "u2",
+			wantCandidates: []string{"u1", "u7"},
+		},
+		{
+			name:           "repository PR, excluded and inactive members are not candidates",
+			pr:             models.PullRequest{RepositoryID: "repo-1", AuthorID: "u5", AssignedReviewers: []string{"u9"}},
+			reviewer:       "u9",
+			wantLoses:      true,
+			wantCandidates: []string{"u1", "u2", "u7"},
+		},
+		{
+			name:           "team PR, reviewer left a team outside the pool",
+			pr:             models.PullRequest{TeamName: "frontend", AuthorID: "u5", AssignedReviewers: []string{"u9"}},
+			reviewer:       "u9",
+			wantCandidates: []string{"u2"},
+		},
+		{
+			name:      "deactivated reviewer, no candidates",
+			pr:        models.PullRequest{TeamName: "ops", AuthorID: "u6", AssignedReviewers: []string{"u8", "u9"}},
+			reviewer:  "u8",
+			wantLoses: true,
+		},
+		{
+			name:      "repository without pool rules",
+			pr:        models.PullRequest{RepositoryID: "repo-2", TeamName: "backend", AuthorID: "u5", AssignedReviewers: []string{"u8"}},
+			reviewer:  "u8",
+			wantLoses: true,
+		},
+		{
+			name:      "PR without team or repository",
+			pr:        models.PullRequest{AuthorID: "u5", AssignedReviewers: []string{"u2"}},
+			reviewer:  "u2",
+			wantLoses: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if loses := state.losesReview(&tt.pr, tt.reviewer); loses != tt.wantLoses {
+				t.Fatalf("loses review %v, want %v", loses, tt.wantLoses)
+			}
+			var got []string
+			for _, user := range state.candidates(&tt.pr, tt.pr.AssignedReviewers) {
+				got = append(got, user.UserID)
+			}
+			if !reflect.DeepEqual(got, tt.wantCandidates) {
+				t.Fatalf("candidates %v, want %v", got, tt.wantCandidates)
+			}
+		})
+	}
+}
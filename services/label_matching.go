@@ -0,0 +1,59 @@
+package service
+
+import (
+	"Backend-trainee-assignment/models"
+	"math/rand"
+	"strings"
+)
+
+// Стратегия выбора ревьюеров по меткам PR: не меньше MinMatched ревьюеров
+// с навыками из меток PR, остальные места заполняются из общего пула
+type LabelMatchStrategy struct {
+	MinMatched int
+}
+
+// Функция выбирает до max ревьюеров, skills - навыки кандидатов по ID
+func (st LabelMatchStrategy) Select(candidates []models.User, skills map[string][]string, labels []string, max int) []models.User {
+	if len(candidates) <= max {
+		return candidates
+	}
+
+	var matched, others []models.User
+	for _, candidate := range candidates {
+		if intersects(skills[candidate.UserID], labels) {
+			matched = append(matched, candidate)
+		} else {
+			others = append(others, candidate)
+		}
+	}
+	rand.Shuffle(len(matched), func(i, j int) { matched[i], matched[j] = matched[j], matched[i] })
+
+	// Гарантированные места для кандидатов с подходящими навыками, отрицательный MinMatched - без гарантий
+	reserved := st.MinMatched
+	if reserved < 0 {
+		reserved = 0
+	}
+	if reserved > max {
+		reserved = max
+	}
+	if reserved > len(matched) {
+		reserved = len(matched)
+	}
+	selected := append([]models.User{}, matched[:reserved]...)
+
+	// Оставшиеся места заполняются случайно из остального пула
+	rest := append(matched[reserved:], others...)
+	rand.Shuffle(len(rest), func(i, j int) { rest[i], rest[j] = rest[j], rest[i] })
+	return append(selected, rest[:max-reserved]...)
+}
+
+func intersects(skills, labels []string) bool {
+	for _, skill := range skills {
+		for _, label := range labels {
+			if strings.EqualFold(skill, label) {
+				return true
+			}
+		}
+	}
+	return false
+}
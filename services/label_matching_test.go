@@ -0,0 +1,57 @@
+package service
+
+import (
+	"Backend-trainee-assignment/models"
+	"testing"
+)
+
+func TestLabelMatchStrategySelect(t *testing.T) {
+	candidates := []models.User{{UserID: "go1"}, {UserID: "go2"}, {UserID: "sql"}, {UserID: "none"}}
+	skills := map[string][]string{"go1": {"go"}, "go2": {"go", "sql"}, "sql": {"sql"}}
+
+	tests := []struct {
+		name       string
+		minMatched int
+		labels     []string
+		max        int
+		wantLen    int
+		// Не меньше minFrom выбранных из from
+		from    []string
+		minFrom int
+	}{
+		{name: "no labels", minMatched: 1, max: 2, wantLen: 2},
+		{name: "matches reserved", minMatched: 2, labels: []string{"go"}, max: 2, wantLen: 2, from: []string{"go1", "go2"}, minFrom: 2},
+		{name: "labels match case insensitively", minMatched: 1, labels: []string{"SQL"}, max: 1, wantLen: 1, from: []string{"go2", "sql"}, minFrom: 1},
+		{name: "fewer matches than MinMatched", minMatched: 3, labels: []string{"go"}, max: 3, wantLen: 3, from: []string{"go1", "go2"}, minFrom: 2},
+		{name: "max below MinMatched", minMatched: 3, labels: []string{"go"}, max: 1, wantLen: 1, from: []string{"go1", "go2"}, minFrom: 1},
+		{name: "no matching candidates", minMatched: 1, labels: []string{"rust"}, max: 2, wantLen: 2},
+		{name: "negative MinMatched", minMatched: -1, labels: []string{"go"}, max: 2, wantLen: 2},
+		{name: "pool not larger than max", minMatched: 1, labels: []string{"go"}, max: 4, wantLen: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy := LabelMatchStrategy{MinMatched: tt.minMatched}
+			// Выбор случайный, поэтому проверяется на нескольких прогонах
+			for run := 0; run < 50; run++ {
+				pool := append([]models.User{}, candidates...)
+				selected := strategy.Select(pool, skills, tt.labels, tt.max)
+				if len(selected) != tt.wantLen {
+					t.Fatalf("selected %v, want %d reviewers", selected, tt.wantLen)
+				}
+				seen, fromCount := map[string]bool{}, 0
+				for _, user := range selected {
+					if seen[user.UserID] {
+						t.Fatalf("selected %v twice", user.UserID)
+					}
+					seen[user.UserID] = true
+					if contains(tt.from, user.UserID) {
+						fromCount++
+					}
+				}
+				if fromCount < tt.minFrom {
+					t.Fatalf("selected %v, want at least %d of %v", selected, tt.minFrom, tt.from)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/models"
+	"context"
+	"fmt"
+	"net/mail"
+)
+
+// Настройки почтовых уведомлений пользователей
+type NotificationService struct {
+	notificationRepo *repository.NotificationRepository
+}
+
+func NewNotificationService(notificationRepo *repository.NotificationRepository) *NotificationService {
+	return &NotificationService{notificationRepo: notificationRepo}
+}
+
+// Функция возвращает адрес и отказы от писем пользователя
+func (s *NotificationService) GetPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+	prefs, err := s.notificationRepo.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if prefs == nil {
+		return nil, ErrUserNotFound
+	}
+	return prefs, nil
+}
+
+// Функция проверяет и сохраняет адрес и отказы от писем; пустой адрес выключает письма
+func (s *NotificationService) SetPreferences(ctx context.Context, prefs models.NotificationPreferences) (*models.NotificationPreferences, error) {
+	if prefs.Email != "" {
+		address, err := mail.ParseAddress(prefs.Email)
+		if err != nil || address.Name != "" {
+			return nil, NewValidationError("email must be a plain email address")
+		}
+	}
+	for _, kind := range prefs.EmailOptOut {
+		switch kind {
+		case EmailKindAssignment, EmailKindDigest, EmailKindEscalation:
+		default:
+			return nil, NewValidationError(fmt.Sprintf("unknown email kind %q, expected %s, %s or %s",
+				kind, EmailKindAssignment, EmailKindDigest, EmailKindEscalation))
+		}
+	}
+
+	existing, err := s.notificationRepo.GetPreferences(ctx, prefs.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrUserNotFound
+	}
+	if err := s.notificationRepo.SetPreferences(ctx, &prefs); err != nil {
+		return nil, err
+	}
+	return s.notificationRepo.GetPreferences(ctx, prefs.UserID)
+}
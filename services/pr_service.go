@@ -0,0 +1,168 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/events"
+	"Backend-trainee-assignment/models"
+	"context"
+	"time"
+)
+
+// Сервис жизненного цикла PR, общий для REST и gRPC API
+type PRService struct {
+	prRepo          *repository.PRRepository
+	userRepo        *repository.UserRepository
+	teamRepo        *repository.TeamRepository
+	repoRepo        *repository.RepositoryRepository
+	reviewerService *ReviewerService
+	bus             *events.Bus
+}
+
+func NewPRService(prRepo *repository.PRRepository, userRepo *repository.UserRepository, teamRepo *repository.TeamRepository, repoRepo *repository.RepositoryRepository, reviewerService *ReviewerService, bus *events.Bus) *PRService {
+	return &PRService{
+		prRepo:          prRepo,
+		userRepo:        userRepo,
+		teamRepo:        teamRepo,
+		repoRepo:        repoRepo,
+		reviewerService: reviewerService,
+		bus:             bus,
+	}
+}
+
+// Данные для создания PR, TeamName и RepositoryID необязательны
+type CreatePRInput struct {
+	PullRequestID   string
+	PullRequestName string
+	AuthorID        string
+	TeamName        string
+	RepositoryID    string
+	Labels          []string
+}
+
+// Функция создает PR и назначает ревьюеров. Если назначить ревьюеров не удалось,
+// PR все равно создается, а причина возвращается в warning
+func (s *PRService) CreatePR(ctx context.Context, in CreatePRInput) (*models.PullRequest, string, error) {
+	// Проверка что PR не существует
+	exists, err := s.prRepo.PRExists(ctx, in.PullRequestID)
+	if err != nil {
+		return nil, "", err
+	}
+	if exists {
+		return nil, "", ErrPRExists
+	}
+
+	// Проверка, что автор существует
+	author, err := s.userRepo.GetUserByID(ctx, in.AuthorID)
+	if err != nil {
+		return nil, "", err
+	}
+	if author == nil {
+		return nil, "", ErrAuthorNotFound
+	}
+
+	// Определение целевой команды: явно указанная или единственная команда автора
+	teamName := in.TeamName
+	if teamName != "" {
+		exists, err := s.teamRepo.TeamExists(ctx, teamName)
+		if err != nil {
+			return nil, "", err
+		}
+		if !exists {
+			return nil, "", ErrTeamNotFound
+		}
+	} else if len(author.Teams) == 1 {
+		teamName = author.Teams[0]
+	} else if len(author.Teams) > 1 && in.RepositoryID == "" {
+		return nil, "", NewValidationError("team_name is required: author belongs to several teams")
+	}
+
+	// Проверка репозитория, при наличии ревьюеры выбираются из его пула
+	if in.RepositoryID != "" {
+		exists, err := s.repoRepo.RepositoryExists(ctx, in.RepositoryID)
+		if err != nil {
+			return nil, "", err
+		}
+		if !exists {
+			return nil, "", ErrRepositoryNotFound
+		}
+	}
+
+	// Создание PR
+	pr := &models.PullRequest{
+		PullRequestID:   in.PullRequestID,
+		PullRequestName: in.PullRequestName,
+		AuthorID:        in.AuthorID,
+		TeamName:        teamName,
+		RepositoryID:    in.RepositoryID,
+		Status:          "OPEN",
+		CreatedAt:       time.Now(),
+	}
+	if len(in.Labels) > 0 {
+		pr.Labels = in.Labels
+	}
+	locked, err := s.prRepo.CreateLockedPR(ctx, pr, in.Labels)
+	if err != nil {
+		return nil, "", err
+	}
+	defer locked.Release()
+
+	// Автоматическое назначение ревьюеров в транзакции создания: фоновое дополнение
+	// не видит PR, пока ревьюеры не назначены
+	assigned, assignErr := s.reviewerService.AssignReviewers(ctx, locked)
+	if err := locked.Commit(); err != nil {
+		return nil, "", err
+	}
+	if assignErr != nil {
+		pr.AssignedReviewers = []string{}
+		return pr, "PR created but reviewers assignment failed: " + assignErr.Error(), nil
+	}
+	s.reviewerService.publishAssigned(pr, assigned)
+
+	created, err := s.GetPR(ctx, pr.PullRequestID)
+	if err != nil {
+		return nil, "", err
+	}
+	return created, "", nil
+}
+
+// Функция возвращает PR с назначенными ревьюерами
+func (s *PRService) GetPR(ctx context.Context, prID string) (*models.PullRequest, error) {
+	pr, _, err := s.prRepo.GetPRWithReviewers(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	if pr == nil {
+		return nil, ErrPRNotFound
+	}
+	return pr, nil
+}
+
+// Функция мержит PR, повторный мерж возвращает PR без изменений
+func (s *PRService) MergePR(ctx context.Context, prID string) (*models.PullRequest, error) {
+	pr, err := s.GetPR(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	// Проверка ситуации второго мерджа
+	if pr.Status == "MERGED" {
+		return pr, nil
+	}
+	mergedAt := time.Now()
+	if err := s.prRepo.UpdatePRStatus(ctx, prID, "MERGED", &mergedAt); err != nil {
+		return nil, err
+	}
+
+	mergedPR, err := s.GetPR(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	mergedPR.MergedAt = &mergedAt
+	s.bus.Publish(events.Event{
+		Type:      events.PRMerged,
+		PRID:      mergedPR.PullRequestID,
+		UserID:    mergedPR.AuthorID,
+		TeamName:  mergedPR.TeamName,
+		Reviewers: mergedPR.AssignedReviewers,
+	})
+	return mergedPR, nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/events"
+	"Backend-trainee-assignment/logging"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Фоновый процесс, дополняющий открытые PR ревьюерами
+type Reconciler struct {
+	reviewerService *ReviewerService
+	prRepo          *repository.PRRepository
+	bus             *events.Bus
+	interval        time.Duration
+	batchSize       int
+	trigger         chan struct{}
+	stopped         chan struct{}
+
+	// Проходы идут порциями по batchSize PR, cursor - последний проверенный PR
+	mu     sync.Mutex
+	cursor string
+}
+
+func NewReconciler(reviewerService *ReviewerService, prRepo *repository.PRRepository, bus *events.Bus, interval time.Duration, batchSize int) *Reconciler {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	return &Reconciler{
+		reviewerService: reviewerService,
+		prRepo:          prRepo,
+		bus:             bus,
+		interval:        interval,
+		batchSize:       batchSize,
+		trigger:         make(chan struct{}, 1),
+		stopped:         make(chan struct{}),
+	}
+}
+
+// Функция запускает периодическую проверку до отмены контекста
+func (r *Reconciler) Start(ctx context.Context) {
+	go func() {
+		defer close(r.stopped)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-r.trigger:
+			}
+			// Начатая проверка доводится до конца даже при остановке сервиса
+			runCtx := logging.WithRequestID(context.WithoutCancel(ctx), "reconcile-"+logging.NewRequestID())
+			if _, err := r.ReconcileOnce(runCtx); err != nil {
+				slog.ErrorContext(runCtx, "reviewer backfill failed", "error", err)
+			}
+		}
+	}()
+}
+
+// Функция ждет завершения текущей проверки после отмены контекста
+func (r *Reconciler) Wait() {
+	<-r.stopped
+}
+
+// Функция запрашивает внеочередную проверку, не блокируя вызывающего
+func (r *Reconciler) Trigger() {
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Функция дополняет ревьюерами очередную порцию открытых PR, возвращает количество дополненных PR.
+// Следующий проход продолжает с места остановки, после полной порции он запрашивается сразу
+func (r *Reconciler) ReconcileOnce(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prIDs, err := r.prRepo.GetUnderstaffedOpenPRs(ctx, RequiredReviewers, r.cursor, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(prIDs) < r.batchSize {
+		r.cursor = ""
+	} else {
+		r.cursor = prIDs[len(prIDs)-1]
+		r.Trigger()
+	}
+
+	filled := 0
+	for _, prID := range prIDs {
+		pr, added, err := r.reviewerService.FillReviewers(ctx, prID)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to backfill reviewers", "pull_request_id", prID, "error", err)
+			continue
+		}
+		if len(added) == 0 {
+			continue
+		}
+
+		filled++
+		slog.InfoContext(ctx, "reviewers backfilled", "pull_request_id", prID, "reviewers", added)
+		r.bus.Publish(events.Event{
+			Type:      events.ReviewersBackfilled,
+			PRID:      prID,
+			TeamName:  pr.TeamName,
+			Reviewers: added,
+		})
+	}
+	return filled, nil
+}
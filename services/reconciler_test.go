@@ -0,0 +1,181 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/events"
+	"Backend-trainee-assignment/models"
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Команда из автора и members активных участников, ID с общим префиксом
+func newReconcileTeam(t *testing.T, db *sqlx.DB, members int) (prefix, team, author string) {
+	t.Helper()
+	ctx := context.Background()
+	prefix = "rc-" + strconv.FormatInt(time.Now().UnixNano()%1e12, 36)
+	team, author = prefix, prefix+"-a"
+
+	teamRepo, userRepo := repository.NewTeamRepository(db), repository.NewUserRepository(db)
+	if err := teamRepo.CreateTeam(ctx, &models.Team{TeamName: team}); err != nil {
+		t.Fatal(err)
+	}
+	userIDs := []string{author}
+	for i := 1; i <= members; i++ {
+		userIDs = append(userIDs, fmt.Sprintf("%s-r%d", prefix, i))
+	}
+	for _, userID := range userIDs {
+		if err := userRepo.CreateUser(ctx, &models.User{UserID: userID, Username: userID, IsActive: true}); err != nil {
+			t.Fatal(err)
+		}
+		if err := userRepo.AddTeamMembership(ctx, userID, team); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return prefix, team, author
+}
+
+func newTestReviewerService(db *sqlx.DB, bus *events.Bus) (*ReviewerService, *repository.PRRepository) {
+	prRepo := repository.NewPRRepository(db)
+	return NewReviewerService(repository.NewUserRepository(db), repository.NewTeamRepository(db), prRepo,
+		repository.NewRepositoryRepository(db), bus, 0), prRepo
+}
+
+func TestReconcileOnceWalksPagesWithCursor(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	prefix, team, author := newReconcileTeam(t, db, 3)
+
+	bus := events.NewBus(0)
+	published, unsubscribe := bus.Subscribe(1000)
+	defer unsubscribe()
+	reviewerService, prRepo := newTestReviewerService(db, bus)
+
+	prIDs := []string{prefix + "-p1", prefix + "-p2", prefix + "-p3"}
+	for _, prID := range prIDs {
+		if err := prRepo.CreatePR(ctx, &models.PullRequest{PullRequestID: prID, PullRequestName: prID, AuthorID: author,
+			TeamName: team, Status: "OPEN", CreatedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := prRepo.AddPRReviewer(ctx, prIDs[1], prefix+"-r1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Порции по одному PR: курсор растет, после каждой полной порции запрашивается следующий проход.
+	// В тестовой БД могут быть и чужие недоукомплектованные PR, поэтому проход идет до сброса курсора
+	reconciler := NewReconciler(reviewerService, prRepo, bus, time.Hour, 1)
+	for passes := 0; ; passes++ {
+		if passes > 10000 {
+			t.Fatal("cursor never reset")
+		}
+		previous := reconciler.cursor
+		if _, err := reconciler.ReconcileOnce(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if reconciler.cursor == "" {
+			break
+		}
+		if reconciler.cursor <= previous {
+			t.Fatalf("cursor moved from %q to %q", previous, reconciler.cursor)
+		}
+		select {
+		case <-reconciler.trigger:
+		default:
+			t.Fatalf("full batch ending at %q did not trigger the next pass", reconciler.cursor)
+		}
+	}
+
+	backfilled := map[string]events.Event{}
+	for len(published) > 0 {
+		event := <-published
+		if event.Type == events.ReviewersBackfilled {
+			backfilled[event.PRID] = event
+		}
+	}
+	for i, prID := range prIDs {
+		reviewers, err := prRepo.GetPRReviewers(ctx, prID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reviewers) != RequiredReviewers {
+			t.Fatalf("%s has reviewers %v, want %d", prID, reviewers, RequiredReviewers)
+		}
+		event, ok := backfilled[prID]
+		if !ok {
+			t.Fatalf("no reviewers_backfilled event for %s", prID)
+		}
+		wantAdded := RequiredReviewers
+		if i == 1 {
+			wantAdded = 1
+		}
+		if event.TeamName != team || len(event.Reviewers) != wantAdded {
+			t.Fatalf("event %+v, want team %s and %d reviewers", event, team, wantAdded)
+		}
+	}
+}
+
+// PR создаются параллельно с проходами фонового дополнения: ни один PR не получает
+// больше RequiredReviewers ревьюеров
+func TestReconcileOnceRacesWithCreatePR(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	prefix, team, author := newReconcileTeam(t, db, 6)
+
+	bus := events.NewBus(0)
+	reviewerService, prRepo := newTestReviewerService(db, bus)
+	prService := NewPRService(prRepo, repository.NewUserRepository(db), repository.NewTeamRepository(db),
+		repository.NewRepositoryRepository(db), reviewerService, bus)
+	reconciler := NewReconciler(reviewerService, prRepo, bus, time.Hour, 100)
+
+	const prCount = 30
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if _, err := reconciler.ReconcileOnce(ctx); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	var creators sync.WaitGroup
+	for i := 0; i < prCount; i++ {
+		creators.Add(1)
+		go func(i int) {
+			defer creators.Done()
+			prID := fmt.Sprintf("%s-p%02d", prefix, i)
+			if _, warning, err := prService.CreatePR(ctx, CreatePRInput{PullRequestID: prID, PullRequestName: prID,
+				AuthorID: author, TeamName: team}); err != nil || warning != "" {
+				t.Errorf("create %s: %v %s", prID, err, warning)
+			}
+		}(i)
+	}
+	creators.Wait()
+	close(done)
+	wg.Wait()
+
+	for i := 0; i < prCount; i++ {
+		prID := fmt.Sprintf("%s-p%02d", prefix, i)
+		reviewers, err := prRepo.GetPRReviewers(ctx, prID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reviewers) != RequiredReviewers {
+			t.Fatalf("%s has reviewers %v, want %d", prID, reviewers, RequiredReviewers)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"Backend-trainee-assignment/logging"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Фоновый процесс, раз в interval выполняющий наступившие напоминания команд
+type ReminderScheduler struct {
+	reminderService *ReminderService
+	interval        time.Duration
+	stopped         chan struct{}
+}
+
+func NewReminderScheduler(reminderService *ReminderService, interval time.Duration) *ReminderScheduler {
+	return &ReminderScheduler{
+		reminderService: reminderService,
+		interval:        interval,
+		stopped:         make(chan struct{}),
+	}
+}
+
+// Функция запускает проверку расписаний до отмены контекста
+func (r *ReminderScheduler) Start(ctx context.Context) {
+	go func() {
+		defer close(r.stopped)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			// Начатая рассылка доводится до конца даже при остановке сервиса
+			runCtx := logging.WithRequestID(context.WithoutCancel(ctx), "reminders-"+logging.NewRequestID())
+			if _, err := r.reminderService.RunDue(runCtx, time.Now()); err != nil {
+				slog.ErrorContext(runCtx, "reminder schedule check failed", "error", err)
+			}
+		}
+	}()
+}
+
+// Функция ждет завершения текущей рассылки после отмены контекста
+func (r *ReminderScheduler) Wait() {
+	<-r.stopped
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/models"
+	"Backend-trainee-assignment/notify"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Порог эскалации по умолчанию, если он не передан
+const DefaultEscalationAfterHours = 24
+
+type ReminderService struct {
+	reminderRepo *repository.ReminderRepository
+	teamRepo     *repository.TeamRepository
+	notifier     notify.Notifier
+}
+
+func NewReminderService(reminderRepo *repository.ReminderRepository, teamRepo *repository.TeamRepository, notifier notify.Notifier) *ReminderService {
+	return &ReminderService{
+		reminderRepo: reminderRepo,
+		teamRepo:     teamRepo,
+		notifier:     notifier,
+	}
+}
+
+// Функция возвращает расписания напоминаний команды; если они не заданы, напоминания выключены
+func (s *ReminderService) GetTeamReminders(ctx context.Context, teamName string) (*models.TeamReminders, error) {
+	if err := s.requireTeam(ctx, teamName); err != nil {
+		return nil, err
+	}
+	reminders, err := s.reminderRepo.GetTeamReminders(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	if reminders == nil {
+		reminders = &models.TeamReminders{
+			TeamName:             teamName,
+			EscalationAfterHours: DefaultEscalationAfterHours,
+			Timezone:             "UTC",
+		}
+	}
+	return reminders, nil
+}
+
+// Функция проверяет и сохраняет расписания команды; пустое расписание выключает напоминание
+func (s *ReminderService) SetTeamReminders(ctx context.Context, reminders models.TeamReminders) (*models.TeamReminders, error) {
+	if reminders.Timezone == "" {
+		reminders.Timezone = "UTC"
+	}
+	if reminders.EscalationAfterHours == 0 {
+		reminders.EscalationAfterHours = DefaultEscalationAfterHours
+	}
+	if reminders.EscalationAfterHours < 0 {
+		return nil, NewValidationError("escalation_after_hours must be positive")
+	}
+	if _, err := time.LoadLocation(reminders.Timezone); err != nil {
+		return nil, NewValidationError(fmt.Sprintf("unknown timezone %q", reminders.Timezone))
+	}
+	for field, spec := range map[string]string{
+		"digest_schedule":     reminders.DigestSchedule,
+		"escalation_schedule": reminders.EscalationSchedule,
+	} {
+		if _, err := parseSchedule(spec); err != nil {
+			return nil, NewValidationError(fmt.Sprintf("%s: %v", field, err))
+		}
+	}
+	if err := s.requireTeam(ctx, reminders.TeamName); err != nil {
+		return nil, err
+	}
+
+	reminders.LastDigestAt = nil
+	reminders.LastEscalationAt = nil
+	reminders.UpdatedAt = time.Now()
+	if err := s.reminderRepo.SetTeamReminders(ctx, &reminders); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "team reminders updated", "team_name", reminders.TeamName,
+		"digest_schedule", reminders.DigestSchedule, "escalation_schedule", reminders.EscalationSchedule,
+		"escalation_after_hours", reminders.EscalationAfterHours, "timezone", reminders.Timezone)
+	return &reminders, nil
+}
+
+// Функция отправляет каждому ревьюеру команды список его ожидающих ревью, возвращает число уведомлений
+func (s *ReminderService) SendDigests(ctx context.Context, teamName string) (int, error) {
+	reviews, err := s.reminderRepo.GetPendingReviews(ctx, teamName)
+	if err != nil {
+		return 0, err
+	}
+
+	// Назначения отсортированы по ревьюеру
+	sent := 0
+	for start := 0; start < len(reviews); {
+		end := start
+		for end < len(reviews) && reviews[end].ReviewerID == reviews[start].ReviewerID {
+			end++
+		}
+		reviewerID := reviews[start].ReviewerID
+		msg := notify.Message{
+			Kind:       notify.KindDigest,
+			TeamName:   teamName,
+			Recipients: []string{reviewerID},
+			Subject:    fmt.Sprintf("[%s] %d pending review(s)", teamName, end-start),
+			Text:       formatReviews(fmt.Sprintf("Reviews waiting for %s:", reviewerID), reviews[start:end], time.Now()),
+			Reviews:    reviews[start:end],
+		}
+		if err := s.notifier.Notify(ctx, msg); err != nil {
+			slog.WarnContext(ctx, "failed to send digest", "team_name", teamName, "reviewer_id", reviewerID, "error", err)
+		} else {
+			sent++
+		}
+		start = end
+	}
+	return sent, nil
+}
+
+// Функция отправляет команде одно напоминание о ревью, ожидающих дольше afterHours часов.
+// Возвращает число ожидающих назначений в напоминании
+func (s *ReminderService) SendEscalation(ctx context.Context, teamName string, afterHours int, now time.Time) (int, error) {
+	reviews, err := s.reminderRepo.GetPendingReviews(ctx, teamName)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := now.Add(-time.Duration(afterHours) * time.Hour)
+	var waiting []models.PendingReview
+	var recipients []string
+	for _, review := range reviews {
+		if review.AssignedAt.After(cutoff) {
+			continue
+		}
+		waiting = append(waiting, review)
+		if !contains(recipients, review.ReviewerID) {
+			recipients = append(recipients, review.ReviewerID)
+		}
+	}
+	if len(waiting) == 0 {
+		return 0, nil
+	}
+
+	msg := notify.Message{
+		Kind:       notify.KindEscalation,
+		TeamName:   teamName,
+		Recipients: recipients,
+		Subject:    fmt.Sprintf("[%s] %d review(s) waiting longer than %dh", teamName, len(waiting), afterHours),
+		Text:       formatReviews(fmt.Sprintf("Reviews waiting longer than %dh:", afterHours), waiting, now),
+		Reviews:    waiting,
+	}
+	if err := s.notifier.Notify(ctx, msg); err != nil {
+		return 0, err
+	}
+	return len(waiting), nil
+}
+
+// Функция выполняет напоминания команд, время которых наступило, возвращает число запусков
+func (s *ReminderService) RunDue(ctx context.Context, now time.Time) (int, error) {
+	list, err := s.reminderRepo.ListTeamReminders(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	runs := 0
+	for _, reminders := range list {
+		loc, err := time.LoadLocation(reminders.Timezone)
+		if err != nil {
+			slog.WarnContext(ctx, "invalid reminder timezone", "team_name", reminders.TeamName, "timezone", reminders.Timezone)
+			continue
+		}
+		if s.runIfDue(ctx, reminders, repository.ReminderDigest, reminders.DigestSchedule, reminders.LastDigestAt, loc, now) {
+			runs++
+		}
+		if s.runIfDue(ctx, reminders, repository.ReminderEscalation, reminders.EscalationSchedule, reminders.LastEscalationAt, loc, now) {
+			runs++
+		}
+	}
+	return runs, nil
+}
+
+// Функция запускает напоминание, если по расписанию после прошлого запуска наступило время.
+// Пропущенные запуски (например, во время остановки сервиса) сводятся к одному
+func (s *ReminderService) runIfDue(ctx context.Context, reminders models.TeamReminders, kind, spec string, last *time.Time, loc *time.Location, now time.Time) bool {
+	schedule, err := parseSchedule(spec)
+	if err != nil || schedule == nil {
+		return false
+	}
+	from := reminders.UpdatedAt
+	if last != nil {
+		from = *last
+	}
+	if schedule.Next(from.In(loc)).After(now) {
+		return false
+	}
+
+	claimed, err := s.reminderRepo.ClaimRun(ctx, reminders.TeamName, kind, last, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to claim reminder run", "team_name", reminders.TeamName, "kind", kind, "error", err)
+		return false
+	}
+	if !claimed {
+		return false
+	}
+
+	var sent int
+	if kind == repository.ReminderEscalation {
+		sent, err = s.SendEscalation(ctx, reminders.TeamName, reminders.EscalationAfterHours, now)
+	} else {
+		sent, err = s.SendDigests(ctx, reminders.TeamName)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "reminder failed", "team_name", reminders.TeamName, "kind", kind, "error", err)
+		return true
+	}
+	slog.InfoContext(ctx, "reminder sent", "team_name", reminders.TeamName, "kind", kind, "count", sent)
+	return true
+}
+
+func (s *ReminderService) requireTeam(ctx context.Context, teamName string) error {
+	exists, err := s.teamRepo.TeamExists(ctx, teamName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrTeamNotFound
+	}
+	return nil
+}
+
+// Функция разбирает расписание в формате cron из пяти полей или дескриптор (@daily, @every 2h);
+// пустая строка означает выключенное напоминание
+func parseSchedule(spec string) (cron.Schedule, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+	return cron.ParseStandard(spec)
+}
+
+// Функция формирует текст со списком ожидающих ревью
+func formatReviews(header string, reviews []models.PendingReview, now time.Time) string {
+	var b strings.Builder
+	b.WriteString(header)
+	for _, review := range reviews {
+		fmt.Fprintf(&b, "\n- %s %q by %s, waiting %s", review.PullRequestID, review.PullRequestName,
+			review.AuthorID, now.Sub(review.AssignedAt).Truncate(time.Minute))
+	}
+	return b.String()
+}
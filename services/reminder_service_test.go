@@ -0,0 +1,230 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/migrations"
+	"Backend-trainee-assignment/models"
+	"Backend-trainee-assignment/notify"
+	"context"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Функция подключается к тестовой БД из TEST_DATABASE_URL и применяет миграции, без нее тест пропускается
+func testDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sqlx.Connect("postgres", url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := repository.Migrate(context.Background(), db, migrations.Files); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// Команда с автором и двумя ревьюерами: у r1 ревью p1 (ждет 48 часов) и p2, у r2 - ревью p1
+type reminderFixture struct {
+	team, r1, r2, p1, p2 string
+}
+
+func newReminderFixture(t *testing.T, db *sqlx.DB) reminderFixture {
+	t.Helper()
+	ctx := context.Background()
+	sfx := strconv.FormatInt(time.Now().UnixNano()%1e12, 36)
+	f := reminderFixture{team: "rm-" + sfx, r1: "rm-" + sfx + "-r1", r2: "rm-" + sfx + "-r2", p1: "rm-" + sfx + "-p1", p2: "rm-" + sfx + "-p2"}
+	author := "rm-" + sfx + "-a"
+
+	teamRepo, userRepo, prRepo := repository.NewTeamRepository(db), repository.NewUserRepository(db), repository.NewPRRepository(db)
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(teamRepo.CreateTeam(ctx, &models.Team{TeamName: f.team}))
+	for _, userID := range []string{author, f.r1, f.r2} {
+		must(userRepo.CreateUser(ctx, &models.User{UserID: userID, Username: userID, IsActive: true}))
+		must(userRepo.AddTeamMembership(ctx, userID, f.team))
+	}
+	for _, prID := range []string{f.p1, f.p2} {
+		must(prRepo.CreatePR(ctx, &models.PullRequest{PullRequestID: prID, PullRequestName: prID, AuthorID: author,
+			TeamName: f.team, Status: "OPEN", CreatedAt: time.Now()}))
+	}
+	must(prRepo.AddPRReviewer(ctx, f.p1, f.r1))
+	must(prRepo.AddPRReviewer(ctx, f.p1, f.r2))
+	must(prRepo.AddPRReviewer(ctx, f.p2, f.r1))
+	_, err := db.ExecContext(ctx, `UPDATE pr_reviewers SET assigned_at = $3 WHERE pr_id = $1 AND reviewer_user_id = $2`,
+		f.p1, f.r1, time.Now().Add(-48*time.Hour))
+	must(err)
+	return f
+}
+
+func newRecordingReminderService(db *sqlx.DB) (*ReminderService, *notify.RecordingNotifier) {
+	recorder := notify.NewRecordingNotifier()
+	return NewReminderService(repository.NewReminderRepository(db), repository.NewTeamRepository(db), recorder), recorder
+}
+
+// Функция оставляет уведомления вида kind для команды: RunDue проходит по всем командам тестовой БД
+func teamMessages(messages []notify.Message, teamName, kind string) []notify.Message {
+	var result []notify.Message
+	for _, msg := range messages {
+		if msg.TeamName == teamName && msg.Kind == kind {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+func TestSendDigestsGroupsByReviewer(t *testing.T) {
+	db := testDB(t)
+	f := newReminderFixture(t, db)
+	svc, recorder := newRecordingReminderService(db)
+
+	sent, err := svc.SendDigests(context.Background(), f.team)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := recorder.Messages()
+	if sent != 2 || len(messages) != 2 {
+		t.Fatalf("sent %d, messages %d, want one digest per reviewer", sent, len(messages))
+	}
+	want := map[string][]string{f.r1: {f.p1, f.p2}, f.r2: {f.p1}}
+	for _, msg := range messages {
+		if msg.Kind != notify.KindDigest || len(msg.Recipients) != 1 {
+			t.Fatalf("message %+v, want digest to one reviewer", msg)
+		}
+		prIDs := want[msg.Recipients[0]]
+		if len(msg.Reviews) != len(prIDs) {
+			t.Fatalf("digest for %s has %d reviews, want %v", msg.Recipients[0], len(msg.Reviews), prIDs)
+		}
+		for i, review := range msg.Reviews {
+			if review.ReviewerID != msg.Recipients[0] || review.PullRequestID != prIDs[i] {
+				t.Fatalf("digest for %s: review %+v, want %s", msg.Recipients[0], review, prIDs[i])
+			}
+		}
+	}
+}
+
+func TestSendEscalationOnlyOverdueReviews(t *testing.T) {
+	db := testDB(t)
+	f := newReminderFixture(t, db)
+	svc, recorder := newRecordingReminderService(db)
+
+	count, err := svc.SendEscalation(context.Background(), f.team, 24, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := recorder.Messages()
+	if count != 1 || len(messages) != 1 {
+		t.Fatalf("count %d, messages %d, want one escalation with one review", count, len(messages))
+	}
+	if msg := messages[0]; len(msg.Recipients) != 1 || msg.Recipients[0] != f.r1 || msg.Reviews[0].PullRequestID != f.p1 {
+		t.Fatalf("escalation %+v, want %s waiting on %s", msg, f.r1, f.p1)
+	}
+}
+
+// Напоминание не уходит раньше времени расписания в часовом поясе команды и уходит один раз после него
+func TestRunDueFollowsScheduleTimezone(t *testing.T) {
+	db := testDB(t)
+	f := newReminderFixture(t, db)
+	svc, recorder := newRecordingReminderService(db)
+	ctx := context.Background()
+
+	reminders, err := svc.SetTeamReminders(ctx, models.TeamReminders{TeamName: f.team, DigestSchedule: "0 9 * * *", Timezone: "Asia/Tokyo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loc, _ := time.LoadLocation("Asia/Tokyo")
+	schedule, _ := parseSchedule(reminders.DigestSchedule)
+	due := schedule.Next(reminders.UpdatedAt.In(loc))
+	if due.In(time.UTC).Hour() != 0 {
+		t.Fatalf("due %s, want 09:00 Asia/Tokyo", due)
+	}
+
+	for _, now := range []time.Time{due.Add(-time.Minute), due.Add(time.Minute), due.Add(2 * time.Minute)} {
+		if _, err := svc.RunDue(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if digests := teamMessages(recorder.Messages(), f.team, notify.KindDigest); len(digests) != 2 {
+		t.Fatalf("digests %d, want 2 from a single run after %s", len(digests), due)
+	}
+}
+
+// Экземпляр, который прочитал расписание до запуска на другом экземпляре, проигрывает ClaimRun
+// и не отправляет повторное напоминание
+func TestRunDueClaimLostSendsNothing(t *testing.T) {
+	db := testDB(t)
+	f := newReminderFixture(t, db)
+	first, firstRecorder := newRecordingReminderService(db)
+	second, secondRecorder := newRecordingReminderService(db)
+	ctx := context.Background()
+
+	reminders, err := first.SetTeamReminders(ctx, models.TeamReminders{TeamName: f.team, DigestSchedule: "@every 1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale := *reminders
+	now := reminders.UpdatedAt.Add(90 * time.Minute)
+
+	if _, err := first.RunDue(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	if second.runIfDue(ctx, stale, repository.ReminderDigest, stale.DigestSchedule, stale.LastDigestAt, time.UTC, now) {
+		t.Fatal("stale run claimed the reminder")
+	}
+	if digests := teamMessages(firstRecorder.Messages(), f.team, notify.KindDigest); len(digests) != 2 {
+		t.Fatalf("first instance digests %d, want 2", len(digests))
+	}
+	if messages := secondRecorder.Messages(); len(messages) != 0 {
+		t.Fatalf("second instance sent %d messages, want none", len(messages))
+	}
+}
+
+func TestRunDueConcurrentInstancesSendOnce(t *testing.T) {
+	db := testDB(t)
+	f := newReminderFixture(t, db)
+	ctx := context.Background()
+
+	services := make([]*ReminderService, 4)
+	recorders := make([]*notify.RecordingNotifier, len(services))
+	for i := range services {
+		services[i], recorders[i] = newRecordingReminderService(db)
+	}
+	reminders, err := services[0].SetTeamReminders(ctx, models.TeamReminders{TeamName: f.team, DigestSchedule: "@every 1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := reminders.UpdatedAt.Add(90 * time.Minute)
+
+	var wg sync.WaitGroup
+	for _, svc := range services {
+		wg.Add(1)
+		go func(svc *ReminderService) {
+			defer wg.Done()
+			if _, err := svc.RunDue(ctx, now); err != nil {
+				t.Error(err)
+			}
+		}(svc)
+	}
+	wg.Wait()
+
+	total := 0
+	for _, recorder := range recorders {
+		total += len(teamMessages(recorder.Messages(), f.team, notify.KindDigest))
+	}
+	if total != 2 {
+		t.Fatalf("digests %d across instances, want 2 from one instance", total)
+	}
+}
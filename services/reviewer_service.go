@@ -2,225 +2,329 @@ package service
 
 import (
 	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/events"
+	"Backend-trainee-assignment/metrics"
 	"Backend-trainee-assignment/models"
+	"context"
 	"fmt"
+	"log/slog"
 	"math/rand"
 	"time"
 )
 
+// Необходимое количество ревьюеров на PR
+const RequiredReviewers = 2
+
 type ReviewerService struct {
 	userRepo *repository.UserRepository
 	teamRepo *repository.TeamRepository
 	prRepo   *repository.PRRepository
+	repoRepo *repository.RepositoryRepository
+	bus      *events.Bus
+
+	labelMatch LabelMatchStrategy
 }
 
-func NewReviewerService(userRepo *repository.UserRepository, teamRepo *repository.TeamRepository, prRepo *repository.PRRepository) *ReviewerService {
+func NewReviewerService(userRepo *repository.UserRepository, teamRepo *repository.TeamRepository, prRepo *repository.PRRepository, repoRepo *repository.RepositoryRepository, bus *events.Bus, minMatchedReviewers int) *ReviewerService {
 	return &ReviewerService{
-		userRepo: userRepo,
-		teamRepo: teamRepo,
-		prRepo:   prRepo,
+		userRepo:   userRepo,
+		teamRepo:   teamRepo,
+		prRepo:     prRepo,
+		repoRepo:   repoRepo,
+		bus:        bus,
+		labelMatch: LabelMatchStrategy{MinMatched: minMatchedReviewers},
 	}
 }
 
-// Функция  назначает ревьюеров на PR
-func (s *ReviewerService) AssignReviewers(pr *models.PullRequest) error {
+// Функция назначает ревьюеров созданному, но еще не зафиксированному PR и возвращает их.
+// Транзакцию фиксирует вызывающий, после этого назначение публикуется через publishAssigned
+func (s *ReviewerService) AssignReviewers(ctx context.Context, locked *repository.LockedPR) ([]string, error) {
+	pr := locked.PR
 	// Поиск автора и проверка ошибок
-	author, err := s.userRepo.GetUserByID(pr.AuthorID)
+	author, err := s.userRepo.GetUserByID(ctx, pr.AuthorID)
 	if err != nil {
-		return fmt.Errorf("ошибка при поиске автора: %w", err)
+		return nil, fmt.Errorf("ошибка при поиске автора: %w", err)
 	}
 	if author == nil {
-		return fmt.Errorf("автор не найден")
+		return nil, ErrAuthorNotFound
 	}
-	authorTeam := author.TeamName
-	if authorTeam == "" {
-		return fmt.Errorf("автор не относится к комнаде")
+	if pr.TeamName == "" && pr.RepositoryID == "" {
+		return nil, ErrNoTargetTeam
 	}
-	availableReviewers, err := s.getAvailableReviewers(authorTeam, pr.AuthorID)
+	availableReviewers, err := s.getAvailableReviewers(ctx, pr)
 	if err != nil {
-		return fmt.Errorf("не удалось найти ревьюеров: %w", err)
+		return nil, fmt.Errorf("не удалось найти ревьюеров: %w", err)
 	}
 	if len(availableReviewers) == 0 {
-		fmt.Printf("доступных ревьюеров нет")
-
-		return nil
+		slog.WarnContext(ctx, "no available reviewers", "pull_request_id", pr.PullRequestID)
+		return nil, nil
 	}
 
-	// Выбор радомных ревьюеров
-	selectedReviewers := s.selectRandomReviewers(availableReviewers, 2)
+	// Выбор ревьюеров с учетом меток PR
+	selectedReviewers, err := s.selectReviewers(ctx, pr, availableReviewers, RequiredReviewers)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось выбрать ревьюеров: %w", err)
+	}
 
+	assigned := make([]string, 0, len(selectedReviewers))
 	for _, reviewer := range selectedReviewers {
-		if err := s.prRepo.AddPRReviewer(pr.PullRequestID, reviewer.UserID); err != nil {
-			return fmt.Errorf("failed to assign reviewer: %w", err)
-		}
+		assigned = append(assigned, reviewer.UserID)
+	}
+	if err := locked.AddReviewers(ctx, assigned); err != nil {
+		return nil, fmt.Errorf("failed to assign reviewer: %w", err)
 	}
+	return assigned, nil
+}
 
-	return nil
+// Функция учитывает в метриках и публикует назначение ревьюеров новому PR
+func (s *ReviewerService) publishAssigned(pr *models.PullRequest, assigned []string) {
+	if len(assigned) == 0 {
+		return
+	}
+	metrics.ReviewersAssigned.Add(float64(len(assigned)))
+	s.bus.Publish(events.Event{
+		Type:      events.ReviewersAssigned,
+		PRID:      pr.PullRequestID,
+		UserID:    pr.AuthorID,
+		TeamName:  pr.TeamName,
+		Reviewers: assigned,
+		Reason:    "created",
+	})
 }
 
-// Функция заменяет ревьюера
-func (s *ReviewerService) ReassignReviewer(prID, oldReviewerID string) (string, error) {
-	// PR с ревьюерами и проверка ошибок
-	pr, reviewers, err := s.prRepo.GetPRWithReviewers(prID)
+// Функция заменяет ревьюера по запросу пользователя
+func (s *ReviewerService) ReassignReviewer(ctx context.Context, prID, oldReviewerID string) (string, error) {
+	return s.ReassignReviewerWithReason(ctx, prID, oldReviewerID, metrics.ReasonManual)
+}
+
+// Функция заменяет ревьюера, причина попадает в метрики и событие переназначения.
+// PR блокируется на время замены, фоновое дополнение и другие замены его ждут или пропускают
+func (s *ReviewerService) ReassignReviewerWithReason(ctx context.Context, prID, oldReviewerID, reason string) (string, error) {
+	// Блокировка PR с ревьюерами и проверка ошибок
+	locked, err := s.prRepo.LockOpenPR(ctx, prID)
 	if err != nil {
 		return "", fmt.Errorf("ошибка в получении PR: %w", err)
 	}
-	if pr == nil {
-		return "", fmt.Errorf("PR не найден")
-	}
-	if pr.Status == "MERGED" {
-		return "", fmt.Errorf("нельзя добавить ревьюера в MERGED PR")
+	if locked == nil {
+		exists, err := s.prRepo.PRExists(ctx, prID)
+		if err != nil {
+			return "", fmt.Errorf("ошибка в получении PR: %w", err)
+		}
+		if !exists {
+			return "", ErrPRNotFound
+		}
+		return "", ErrPRMerged
 	}
+	defer locked.Release()
+	pr, reviewers := locked.PR, locked.PR.AssignedReviewers
 
 	// Поиск заменяемого ревьюера в PR
-	isOldReviewerAssigned := false
-	for _, reviewer := range reviewers {
-		if reviewer == oldReviewerID {
-			isOldReviewerAssigned = true
-			break
-		}
-	}
-	if !isOldReviewerAssigned {
-		return "", fmt.Errorf("заменяемый ревьюер не назначен на PR")
+	if !contains(reviewers, oldReviewerID) {
+		return "", ErrReviewerNotAssigned
 	}
 
-	// Поиск команды заменяемого ревьюера
-	oldReviewer, err := s.userRepo.GetUserByID(oldReviewerID)
+	// Проверка заменяемого ревьюера и пула кандидатов PR
+	oldReviewer, err := s.userRepo.GetUserByID(ctx, oldReviewerID)
 	if err != nil {
 		return "", fmt.Errorf("ошибка в поиске ревьюера: %w", err)
 	}
 	if oldReviewer == nil {
-		return "", fmt.Errorf("ревьюер не найден")
+		return "", ErrReviewerNotFound
 	}
-	reviewerTeam := oldReviewer.TeamName
-	if reviewerTeam == "" {
-		return "", fmt.Errorf("ревьюер не относится к команде")
+	if pr.TeamName == "" && pr.RepositoryID == "" {
+		return "", ErrNoTargetTeam
 	}
 
-	// Поиск доступных ревьюеров
-	availableReviewers, err := s.getAvailableReviewersForReassignment(reviewerTeam, prID, oldReviewerID)
+	// Поиск доступных ревьюеров в пуле PR: заменяемый и остальные назначенные не подходят
+	availableReviewers, err := s.getAvailableReviewersForReassignment(ctx, pr, reviewers)
 	if err != nil {
 		return "", fmt.Errorf("ошибка при поиске доступных ревьюеров: %w", err)
 	}
 	if len(availableReviewers) == 0 {
-		return "", fmt.Errorf("нет доступных ревьюеров")
+		return "", ErrNoCandidate
 	}
 
-	// Замена на случайного ревьюера
-	newReviewer := s.selectRandomReviewers(availableReviewers, 1)[0]
-	if err := s.prRepo.RemovePRReviewer(prID, oldReviewerID); err != nil {
+	// Замена на случайного ревьюера с учетом меток PR
+	selected, err := s.selectReviewers(ctx, pr, availableReviewers, 1)
+	if err != nil {
+		return "", fmt.Errorf("ошибка при выборе ревьюера: %w", err)
+	}
+	newReviewer := selected[0]
+	if err := locked.RemoveReviewer(ctx, oldReviewerID); err != nil {
 		return "", fmt.Errorf("ошибка при замене ревьюера: %w", err)
 	}
-	if err := s.prRepo.AddPRReviewer(prID, newReviewer.UserID); err != nil {
-		s.prRepo.AddPRReviewer(prID, oldReviewerID)
+	if err := locked.AddReviewers(ctx, []string{newReviewer.UserID}); err != nil {
 		return "", fmt.Errorf("ошибка при добавлении нового ревьюера: %w", err)
 	}
 
-	currentReviewers, err := s.prRepo.GetPRReviewers(prID)
-	if err != nil {
-		return "", fmt.Errorf("ошибка при поиске доступных ревьюеров: %w", err)
+	currentReviewers := []string{newReviewer.UserID}
+	for _, reviewer := range reviewers {
+		if reviewer != oldReviewerID {
+			currentReviewers = append(currentReviewers, reviewer)
+		}
 	}
+	additionalReviewerID := ""
 	if len(currentReviewers) == 1 {
-		fmt.Printf("только один ревьюер\n")
-		pr, err := s.prRepo.GetPRByID(prID)
-		if err != nil {
-			return newReviewer.UserID, nil
-		}
-		additionalReviewer, err := s.findAdditionalReviewer(pr, currentReviewers[0])
+		// Заменяемый ревьюер не возвращается дополнительным: иначе новый assigned_at сбросил бы его SLA
+		slog.DebugContext(ctx, "single reviewer left, looking for additional one", "pull_request_id", prID)
+		additionalReviewer, err := s.findAdditionalReviewer(ctx, pr, append(currentReviewers, oldReviewerID))
 		if err == nil && additionalReviewer != nil {
-			if err := s.prRepo.AddPRReviewer(prID, additionalReviewer.UserID); err != nil {
-				fmt.Printf("ошибка в добавлении ревьюера: %v\n", err)
-			} else {
-				fmt.Printf("добавлен ревьюер: %s\n", additionalReviewer.UserID)
+			if err := locked.AddReviewers(ctx, []string{additionalReviewer.UserID}); err != nil {
+				return "", fmt.Errorf("ошибка при добавлении дополнительного ревьюера: %w", err)
 			}
+			additionalReviewerID = additionalReviewer.UserID
 		} else {
-			fmt.Printf("нет доступных ревьюеров: %v\n", err)
+			slog.InfoContext(ctx, "no additional reviewer available", "pull_request_id", prID, "reason", err)
 		}
 	}
+	if err := locked.Commit(); err != nil {
+		return "", fmt.Errorf("ошибка при замене ревьюера: %w", err)
+	}
+
+	metrics.ReviewersAssigned.Inc()
+	metrics.Reassignments.WithLabelValues(reason).Inc()
+	s.publishReassigned(pr, oldReviewerID, newReviewer.UserID, reason)
+	if additionalReviewerID != "" {
+		metrics.ReviewersAssigned.Inc()
+		slog.InfoContext(ctx, "additional reviewer added",
+			"pull_request_id", prID, "reviewer_id", additionalReviewerID)
+	}
+	slog.InfoContext(ctx, "reviewer reassigned",
+		"pull_request_id", prID, "old_reviewer_id", oldReviewerID, "new_reviewer_id", newReviewer.UserID, "reason", reason)
 	return newReviewer.UserID, nil
 }
 
-// Функция находит дополнительного ревьюера
-func (s *ReviewerService) findAdditionalReviewer(pr *models.PullRequest, existingReviewer string) (*models.User, error) {
-	author, err := s.userRepo.GetUserByID(pr.AuthorID)
+// Функция дополняет открытый PR ревьюерами до RequiredReviewers, возвращает PR и добавленных.
+// PR блокируется на время подбора, занятый другим запросом PR пропускается до следующего прохода
+func (s *ReviewerService) FillReviewers(ctx context.Context, prID string) (*models.PullRequest, []string, error) {
+	locked, err := s.prRepo.TryLockOpenPR(ctx, prID)
 	if err != nil {
-		return nil, err
+		return nil, nil, fmt.Errorf("ошибка в получении PR: %w", err)
 	}
-	if author == nil {
-		return nil, fmt.Errorf("author not found")
+	if locked == nil {
+		return nil, nil, nil
 	}
-	authorTeam := author.TeamName
-	if authorTeam == "" {
-		return nil, fmt.Errorf("author not in any team")
+	defer locked.Release()
+
+	pr, reviewers := locked.PR, locked.PR.AssignedReviewers
+	if len(reviewers) >= RequiredReviewers {
+		return pr, nil, nil
 	}
 
-	// Проверка критериев (активный, не автор, не исключаемый ревьюер)
-	teamMembers, err := s.userRepo.GetUsersByTeam(authorTeam)
+	candidates, err := s.getAvailableReviewers(ctx, pr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("не удалось найти ревьюеров: %w", err)
+	}
+
+	// Исключение уже назначенных ревьюеров
+	var available []models.User
+	for _, member := range candidates {
+		if !contains(reviewers, member.UserID) {
+			available = append(available, member)
+		}
+	}
+
+	selected, err := s.selectReviewers(ctx, pr, available, RequiredReviewers-len(reviewers))
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка при выборе ревьюеров: %w", err)
+	}
+	if len(selected) == 0 {
+		return pr, nil, nil
+	}
+
+	added := make([]string, 0, len(selected))
+	for _, reviewer := range selected {
+		added = append(added, reviewer.UserID)
+	}
+	if err := locked.AddReviewers(ctx, added); err != nil {
+		return nil, nil, fmt.Errorf("ошибка в добавлении ревьюера: %w", err)
+	}
+	if err := locked.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("ошибка в добавлении ревьюера: %w", err)
+	}
+	metrics.ReviewersAssigned.Add(float64(len(added)))
+	return pr, added, nil
+}
+
+// Функция публикует событие переназначения, newReviewerID пустой, если ревьюер снят без замены
+func (s *ReviewerService) publishReassigned(pr *models.PullRequest, oldReviewerID, newReviewerID, reason string) {
+	event := events.Event{
+		Type:     events.ReviewerReassigned,
+		PRID:     pr.PullRequestID,
+		UserID:   oldReviewerID,
+		TeamName: pr.TeamName,
+		Reason:   reason,
+	}
+	if newReviewerID != "" {
+		event.Reviewers = []string{newReviewerID}
+	}
+	s.bus.Publish(event)
+}
+
+// Функция находит дополнительного ревьюера не из excluded
+func (s *ReviewerService) findAdditionalReviewer(ctx context.Context, pr *models.PullRequest, excluded []string) (*models.User, error) {
+	// Проверка критериев (активный, не автор, не исключенный ревьюер)
+	candidates, err := s.candidatePool(ctx, pr)
 	if err != nil {
 		return nil, err
 	}
 	var available []models.User
-	for _, member := range teamMembers {
+	for _, member := range candidates {
 		if member.IsActive &&
 			member.UserID != pr.AuthorID &&
-			member.UserID != existingReviewer {
+			!contains(excluded, member.UserID) {
 			available = append(available, member)
 		}
 	}
 	if len(available) == 0 {
-		return nil, fmt.Errorf("no available reviewers")
+		return nil, ErrNoCandidate
 	}
 
-	return &s.selectRandomReviewers(available, 1)[0], nil
-}
-
-// Функция возвращает доступных ревьюеров для переназначения
-func (s *ReviewerService) getAvailableReviewersForReassignment(teamName, prID, excludeReviewerID string) ([]models.User, error) {
-	// Поиск участников команды и проверка ошибок
-	teamMembers, err := s.userRepo.GetUsersByTeam(teamName)
+	selected, err := s.selectReviewers(ctx, pr, available, 1)
 	if err != nil {
 		return nil, err
 	}
-	pr, err := s.prRepo.GetPRByID(prID)
+	return &selected[0], nil
+}
+
+// Функция возвращает доступных ревьюеров для переназначения, excluded - назначенные на PR ревьюеры
+// вместе с заменяемым
+func (s *ReviewerService) getAvailableReviewersForReassignment(ctx context.Context, pr *models.PullRequest, excluded []string) ([]models.User, error) {
+	// Поиск кандидатов и проверка ошибок
+	candidates, err := s.candidatePool(ctx, pr)
 	if err != nil {
 		return nil, err
 	}
-	if pr == nil {
-		return nil, fmt.Errorf("PR не найден")
-	}
 
-	// Проверка критериев (активный, не автор, не исключаемый ревьюер)
+	// Проверка критериев (активный, не автор, не назначен на PR)
 	var available []models.User
-	for _, member := range teamMembers {
+	for _, member := range candidates {
 		switch {
 		case !member.IsActive:
-			fmt.Printf("✗ Excluded: %s (%s) - Reason: inactive\n", member.UserID, member.Username)
+			slog.DebugContext(ctx, "candidate excluded", "user_id", member.UserID, "reason", "inactive")
 		case member.UserID == pr.AuthorID:
-			fmt.Printf("✗ Excluded: %s (%s) - Reason: author\n", member.UserID, member.Username)
-		case member.UserID == excludeReviewerID:
-			fmt.Printf("✗ Excluded: %s (%s) - Reason: excluded\n", member.UserID, member.Username)
+			slog.DebugContext(ctx, "candidate excluded", "user_id", member.UserID, "reason", "author")
+		case contains(excluded, member.UserID):
+			slog.DebugContext(ctx, "candidate excluded", "user_id", member.UserID, "reason", "assigned")
 		default:
 			available = append(available, member)
-			fmt.Printf("✓ Available candidate: %s (%s)\n", member.UserID, member.Username)
+			slog.DebugContext(ctx, "candidate available", "user_id", member.UserID)
 		}
 	}
 	return available, nil
 }
 
 // Функция возвращает доступных ревьюеров при первом назначении
-func (s *ReviewerService) getAvailableReviewers(teamName, authorID string) ([]models.User, error) {
-	if teamName == "" {
-		return []models.User{}, nil
-	}
-	teamMembers, err := s.userRepo.GetUsersByTeam(teamName)
+func (s *ReviewerService) getAvailableReviewers(ctx context.Context, pr *models.PullRequest) ([]models.User, error) {
+	candidates, err := s.candidatePool(ctx, pr)
 	if err != nil {
 		return nil, err
 	}
 
 	// Проверка критериев(активный, не автор)
 	var available []models.User
-	for _, member := range teamMembers {
-		if member.IsActive && member.UserID != authorID {
+	for _, member := range candidates {
+		if member.IsActive && member.UserID != pr.AuthorID {
 			available = append(available, member)
 		}
 	}
@@ -228,6 +332,34 @@ func (s *ReviewerService) getAvailableReviewers(teamName, authorID string) ([]mo
 	return available, nil
 }
 
+// Функция возвращает пул кандидатов PR: пул репозитория, если он задан, иначе целевую команду
+func (s *ReviewerService) candidatePool(ctx context.Context, pr *models.PullRequest) ([]models.User, error) {
+	if pr.RepositoryID != "" {
+		return s.repoRepo.GetReviewerPool(ctx, pr.RepositoryID)
+	}
+	if pr.TeamName == "" {
+		return []models.User{}, nil
+	}
+	return s.userRepo.GetUsersByTeam(ctx, pr.TeamName)
+}
+
+// Функция выбирает ревьюеров: по меткам PR, если они заданы, иначе случайно
+func (s *ReviewerService) selectReviewers(ctx context.Context, pr *models.PullRequest, candidates []models.User, max int) ([]models.User, error) {
+	if len(pr.Labels) == 0 || len(candidates) <= max {
+		return s.selectRandomReviewers(candidates, max), nil
+	}
+
+	userIDs := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		userIDs[i] = candidate.UserID
+	}
+	skills, err := s.userRepo.GetSkillsByUsers(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	return s.labelMatch.Select(candidates, skills, pr.Labels, max), nil
+}
+
 // Функция выбирает случайных ревьюеров
 func (s *ReviewerService) selectRandomReviewers(reviewers []models.User, max int) []models.User {
 	if len(reviewers) == 0 {
@@ -247,3 +379,12 @@ func (s *ReviewerService) selectRandomReviewers(reviewers []models.User, max int
 
 	return reviewers[:max]
 }
+
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
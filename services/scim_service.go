@@ -0,0 +1,359 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/metrics"
+	"Backend-trainee-assignment/models"
+	"context"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Максимальный размер страницы в списках SCIM
+const ScimMaxPageSize = 200
+
+// Ограничения длины по схеме БД: user_id - VARCHAR(36), username и team_name - VARCHAR(255)
+const (
+	scimMaxUserNameLength = 36
+	scimMaxNameLength     = 255
+)
+
+// Сервис синхронизации пользователей и команд с каталогом по SCIM 2.0.
+// Пользователь SCIM соответствует пользователю сервиса, группа - команде
+type ScimService struct {
+	userRepo            *repository.UserRepository
+	teamRepo            *repository.TeamRepository
+	teamService         *TeamService
+	deactivationService *DeactivationService
+	reconciler          *Reconciler
+}
+
+func NewScimService(userRepo *repository.UserRepository, teamRepo *repository.TeamRepository, teamService *TeamService, deactivationService *DeactivationService, reconciler *Reconciler) *ScimService {
+	return &ScimService{
+		userRepo:            userRepo,
+		teamRepo:            teamRepo,
+		teamService:         teamService,
+		deactivationService: deactivationService,
+		reconciler:          reconciler,
+	}
+}
+
+// Изменение пользователя из PUT/PATCH, nil-поля не меняются
+type ScimUserChange struct {
+	Username *string
+	IsActive *bool
+}
+
+// Действия над группой из PUT/PATCH
+const (
+	ScimGroupRename = "rename"
+	ScimGroupAdd    = "add"
+	ScimGroupRemove = "remove"
+	ScimGroupSet    = "set"
+)
+
+// Изменение состава или имени группы, полученное из PATCH
+type ScimGroupPatch struct {
+	Action  string
+	Name    string
+	Members []string
+}
+
+// Функция возвращает страницу пользователей. startIndex считается с 1, как в SCIM
+func (s *ScimService) ListUsers(ctx context.Context, userID string, startIndex, count int) ([]models.User, int, error) {
+	offset, limit := scimPage(startIndex, count)
+	return s.userRepo.ListUsers(ctx, userID, offset, limit)
+}
+
+// Функция возвращает пользователя вместе с его командами
+func (s *ScimService) GetUser(ctx context.Context, userID string) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// Функция заводит пользователя, пришедшего из каталога
+func (s *ScimService) CreateUser(ctx context.Context, user models.User) (*models.User, error) {
+	if user.UserID == "" {
+		return nil, NewValidationError("userName is required")
+	}
+	if err := validateScimLength("userName", user.UserID, scimMaxUserNameLength); err != nil {
+		return nil, err
+	}
+	if err := validateScimLength("displayName", user.Username, scimMaxNameLength); err != nil {
+		return nil, err
+	}
+	existing, err := s.userRepo.GetUserByID(ctx, user.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrUserExists
+	}
+	if user.Username == "" {
+		user.Username = user.UserID
+	}
+	if err := s.userRepo.CreateUser(ctx, &user); err != nil {
+		return nil, fmt.Errorf("ошибка при создании пользователя: %w", err)
+	}
+	if user.IsActive {
+		s.reconciler.Trigger()
+	}
+	return s.GetUser(ctx, user.UserID)
+}
+
+// Функция применяет изменения пользователя одной транзакцией. Деактивация идет тем же путем,
+// что и массовая деактивация: открытые ревью пользователя переназначаются
+func (s *ScimService) UpdateUser(ctx context.Context, userID string, change ScimUserChange) (*models.User, error) {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	username, isActive := user.Username, user.IsActive
+	if change.Username != nil && *change.Username != "" {
+		if err := validateScimLength("displayName", *change.Username, scimMaxNameLength); err != nil {
+			return nil, err
+		}
+		username = *change.Username
+	}
+	if change.IsActive != nil {
+		isActive = *change.IsActive
+	}
+
+	switch {
+	case user.IsActive && !isActive:
+		if _, err := s.deactivationService.DeactivateRenamedUser(ctx, userID, username, metrics.ReasonSCIM); err != nil {
+			return nil, err
+		}
+	case username != user.Username || isActive != user.IsActive:
+		if err := s.userRepo.UpdateUser(ctx, userID, username, isActive); err != nil {
+			return nil, fmt.Errorf("ошибка при изменении пользователя: %w", err)
+		}
+		if isActive && !user.IsActive {
+			s.reconciler.Trigger()
+		}
+	}
+	return s.GetUser(ctx, userID)
+}
+
+// Функция деактивирует пользователя. Пользователи не удаляются, чтобы сохранить историю ревью
+func (s *ScimService) DeactivateUser(ctx context.Context, userID string) error {
+	inactive := false
+	_, err := s.UpdateUser(ctx, userID, ScimUserChange{IsActive: &inactive})
+	return err
+}
+
+// Функция возвращает страницу команд с участниками
+func (s *ScimService) ListGroups(ctx context.Context, teamName string, startIndex, count int) ([]models.Team, int, error) {
+	offset, limit := scimPage(startIndex, count)
+	teams, total, err := s.teamRepo.ListTeams(ctx, teamName, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range teams {
+		if teams[i].Members, err = s.teamMembers(ctx, teams[i].TeamName); err != nil {
+			return nil, 0, err
+		}
+	}
+	return teams, total, nil
+}
+
+// Функция возвращает команду с участниками
+func (s *ScimService) GetGroup(ctx context.Context, teamName string) (*models.Team, error) {
+	team, err := s.teamRepo.GetTeamByName(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil {
+		return nil, ErrTeamNotFound
+	}
+	if team.Members, err = s.teamMembers(ctx, teamName); err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// Функция создает команду из уже заведенных пользователей одной транзакцией
+func (s *ScimService) CreateGroup(ctx context.Context, teamName string, memberIDs []string) (*models.Team, error) {
+	if teamName == "" {
+		return nil, NewValidationError("displayName is required")
+	}
+	if err := validateScimLength("displayName", teamName, scimMaxNameLength); err != nil {
+		return nil, err
+	}
+	exists, err := s.teamRepo.TeamExists(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrTeamExists
+	}
+	if err := s.requireUsers(ctx, memberIDs); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.teamRepo.BeginMembershipChange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if err := tx.CreateTeam(ctx, teamName); err != nil {
+		return nil, fmt.Errorf("ошибка при создании команды: %w", err)
+	}
+	if err := tx.AddMemberships(ctx, teamName, memberIDs); err != nil {
+		return nil, fmt.Errorf("ошибка при добавлении участника: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	if len(memberIDs) > 0 {
+		// В команде могли появиться кандидаты для PR без ревьюеров
+		s.reconciler.Trigger()
+	}
+	return s.GetGroup(ctx, teamName)
+}
+
+// Функция применяет изменения группы по порядку и записывает итоговые состав и имя одной
+// транзакцией: при ошибке не меняется ничего. Ревью уходящих переназначаются внутри команды,
+// в том числе на новых участников
+func (s *ScimService) PatchGroup(ctx context.Context, teamName string, patches []ScimGroupPatch) (*models.Team, error) {
+	team, err := s.GetGroup(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	current := make([]string, 0, len(team.Members))
+	for _, member := range team.Members {
+		current = append(current, member.UserID)
+	}
+	members, newTeamName := applyGroupPatches(current, patches)
+	if newTeamName == "" {
+		newTeamName = teamName
+	}
+
+	if newTeamName != teamName {
+		if err := validateScimLength("displayName", newTeamName, scimMaxNameLength); err != nil {
+			return nil, err
+		}
+		exists, err := s.teamRepo.TeamExists(ctx, newTeamName)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrTeamExists
+		}
+	}
+	var added []string
+	for _, userID := range members {
+		if !contains(current, userID) {
+			added = append(added, userID)
+		}
+	}
+	if err := s.requireUsers(ctx, added); err != nil {
+		return nil, err
+	}
+
+	report, err := s.teamService.ReplaceTeam(ctx, teamName, members, newTeamName)
+	if err != nil {
+		return nil, err
+	}
+	if len(report.AffectedUsers) > 0 {
+		// В команде могли появиться кандидаты для PR без ревьюеров
+		s.reconciler.Trigger()
+	}
+	return s.GetGroup(ctx, newTeamName)
+}
+
+// Функция применяет изменения к составу current и возвращает итоговые состав и имя команды.
+// Добавление уже состоящих и удаление не состоящих в команде пропускается, пустое имя не меняет команду
+func applyGroupPatches(current []string, patches []ScimGroupPatch) ([]string, string) {
+	members := append([]string{}, current...)
+	name := ""
+	for _, patch := range patches {
+		switch patch.Action {
+		case ScimGroupRename:
+			if patch.Name != "" {
+				name = patch.Name
+			}
+		case ScimGroupAdd:
+			for _, userID := range patch.Members {
+				if !contains(members, userID) {
+					members = append(members, userID)
+				}
+			}
+		case ScimGroupRemove:
+			for _, userID := range patch.Members {
+				members = removeString(members, userID)
+			}
+		case ScimGroupSet:
+			members = uniqueStrings(patch.Members)
+		}
+	}
+	return members, name
+}
+
+// Функция удаляет команду, ревью ее участников на PR команды снимаются
+func (s *ScimService) DeleteGroup(ctx context.Context, teamName string) error {
+	exists, err := s.teamRepo.TeamExists(ctx, teamName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrTeamNotFound
+	}
+	_, err = s.teamService.DeleteTeam(ctx, teamName)
+	return err
+}
+
+func (s *ScimService) teamMembers(ctx context.Context, teamName string) ([]models.TeamMember, error) {
+	users, err := s.userRepo.GetUsersByTeam(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]models.TeamMember, 0, len(users))
+	for _, user := range users {
+		members = append(members, models.TeamMember{UserID: user.UserID, Username: user.Username, IsActive: user.IsActive})
+	}
+	return members, nil
+}
+
+// Функция проверяет, что все пользователи заведены. Каталог присылает пользователей раньше групп
+func (s *ScimService) requireUsers(ctx context.Context, userIDs []string) error {
+	for _, userID := range userIDs {
+		user, err := s.userRepo.GetUserByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return NewValidationError(fmt.Sprintf("member %q is not provisioned", userID))
+		}
+	}
+	return nil
+}
+
+// Функция проверяет, что значение помещается в колонку БД
+func validateScimLength(attr, value string, max int) error {
+	if utf8.RuneCountInString(value) > max {
+		return NewValidationError(fmt.Sprintf("%s must be at most %d characters", attr, max))
+	}
+	return nil
+}
+
+// Функция переводит startIndex/count из SCIM в offset/limit
+func scimPage(startIndex, count int) (int, int) {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count < 0 {
+		count = 0
+	}
+	if count > ScimMaxPageSize {
+		count = ScimMaxPageSize
+	}
+	return startIndex - 1, count
+}
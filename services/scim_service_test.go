@@ -0,0 +1,127 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/events"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func newTestScimService(db *sqlx.DB) *ScimService {
+	bus := events.NewBus(0)
+	reviewerService, prRepo := newTestReviewerService(db, bus)
+	teamRepo := repository.NewTeamRepository(db)
+	return NewScimService(repository.NewUserRepository(db), teamRepo, NewTeamService(teamRepo, reviewerService),
+		NewDeactivationService(repository.NewDeactivationRepository(db), teamRepo, bus),
+		NewReconciler(reviewerService, prRepo, bus, time.Hour, 0))
+}
+
+func TestScimUpdateUserRenamesAndDeactivatesTogether(t *testing.T) {
+	db := testDB(t)
+	f := newMembershipFixture(t, db)
+	s := newTestScimService(db)
+
+	name, inactive := "Renamed", false
+	user, err := s.UpdateUser(context.Background(), f.r1, ScimUserChange{Username: &name, IsActive: &inactive})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != name || user.IsActive {
+		t.Fatalf("user %+v, want renamed and inactive", user)
+	}
+	assertReviewers(t, db, f.p1, f.r2, f.r3)
+}
+
+func TestScimPatchGroupAppliesAllOrNothing(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	f := newMembershipFixture(t, db)
+	s := newTestScimService(db)
+
+	// Второй участник не заведен: удаление r1 из первой операции тоже не применяется
+	_, err := s.PatchGroup(ctx, f.team, []ScimGroupPatch{
+		{Action: ScimGroupRemove, Members: []string{f.r1}},
+		{Action: ScimGroupAdd, Members: []string{f.team + "-missing"}},
+	})
+	if _, ok := err.(*ValidationError); !ok {
+		t.Fatalf("error %v, want validation error", err)
+	}
+	assertTeams(t, db, f.r1, f.team)
+	assertReviewers(t, db, f.p1, f.r1, f.r2)
+
+	newName := f.team + "-renamed"
+	team, err := s.PatchGroup(ctx, f.team, []ScimGroupPatch{
+		{Action: ScimGroupRemove, Members: []string{f.r1}},
+		{Action: ScimGroupRename, Name: newName},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if team.TeamName != newName || len(team.Members) != 3 {
+		t.Fatalf("team %+v, want %s without r1", team, newName)
+	}
+	assertTeams(t, db, f.r1)
+	assertTeams(t, db, f.r2, newName)
+	assertReviewers(t, db, f.p1, f.r2, f.r3)
+}
+
+func TestApplyGroupPatches(t *testing.T) {
+	current := []string{"u1", "u2"}
+	tests := []struct {
+		name        string
+		patches     []ScimGroupPatch
+		wantMembers []string
+		wantName    string
+	}{
+		{name: "no patches", wantMembers: []string{"u1", "u2"}},
+		{
+			name:        "add skips members",
+			patches:     []ScimGroupPatch{{Action: ScimGroupAdd, Members: []string{"u2", "u3", "u3"}}},
+			wantMembers: []string{"u1", "u2", "u3"},
+		},
+		{
+			name:        "remove skips non-members",
+			patches:     []ScimGroupPatch{{Action: ScimGroupRemove, Members: []string{"u1", "u9"}}},
+			wantMembers: []string{"u2"},
+		},
+		{
+			// Операции применяются по порядку: добавленный и затем удаленный в команду не попадает
+			name: "ops in order",
+			patches: []ScimGroupPatch{
+				{Action: ScimGroupAdd, Members: []string{"u3"}},
+				{Action: ScimGroupRemove, Members: []string{"u3", "u1"}},
+				{Action: ScimGroupAdd, Members: []string{"u4"}},
+			},
+			wantMembers: []string{"u2", "u4"},
+		},
+		{
+			name: "set then rename",
+			patches: []ScimGroupPatch{
+				{Action: ScimGroupSet, Members: []string{"u3", "u3"}},
+				{Action: ScimGroupRename, Name: "platform"},
+			},
+			wantMembers: []string{"u3"},
+			wantName:    "platform",
+		},
+		{
+			name:        "empty name keeps team",
+			patches:     []ScimGroupPatch{{Action: ScimGroupRename, Name: ""}, {Action: ScimGroupSet, Members: []string{}}},
+			wantMembers: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members, name := applyGroupPatches(current, tt.patches)
+			if fmt.Sprint(members) != fmt.Sprint(tt.wantMembers) || name != tt.wantName {
+				t.Fatalf("members %v, name %q, want %v, %q", members, name, tt.wantMembers, tt.wantName)
+			}
+			if fmt.Sprint(current) != "[u1 u2]" {
+				t.Fatalf("current members changed to %v", current)
+			}
+		})
+	}
+}
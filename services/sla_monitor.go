@@ -0,0 +1,81 @@
+package service
+
+import (
+	"Backend-trainee-assignment/logging"
+	"Backend-trainee-assignment/metrics"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Фоновый процесс, проверяющий SLA и переназначающий просрочивших ревьюеров
+// в командах с включенным auto_reassign
+type SLAMonitor struct {
+	slaService      *SLAService
+	reviewerService *ReviewerService
+	interval        time.Duration
+	stopped         chan struct{}
+}
+
+func NewSLAMonitor(slaService *SLAService, reviewerService *ReviewerService, interval time.Duration) *SLAMonitor {
+	return &SLAMonitor{
+		slaService:      slaService,
+		reviewerService: reviewerService,
+		interval:        interval,
+		stopped:         make(chan struct{}),
+	}
+}
+
+// Функция запускает периодическую проверку до отмены контекста
+func (m *SLAMonitor) Start(ctx context.Context) {
+	go func() {
+		defer close(m.stopped)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			// Начатая проверка доводится до конца даже при остановке сервиса
+			runCtx := logging.WithRequestID(context.WithoutCancel(ctx), "sla-"+logging.NewRequestID())
+			if _, err := m.CheckOnce(runCtx, time.Now()); err != nil {
+				slog.ErrorContext(runCtx, "sla check failed", "error", err)
+			}
+		}
+	}()
+}
+
+// Функция ждет завершения текущей проверки после отмены контекста
+func (m *SLAMonitor) Wait() {
+	<-m.stopped
+}
+
+// Функция обновляет метрику просроченных назначений и переназначает ревьюеров
+// с включенным auto_reassign, возвращает количество переназначений
+func (m *SLAMonitor) CheckOnce(ctx context.Context, now time.Time) (int, error) {
+	overdue, err := m.slaService.Overdue(ctx, "", now)
+	if err != nil {
+		return 0, err
+	}
+	metrics.OverdueAssignments.Set(float64(len(overdue)))
+
+	reassigned := 0
+	for _, a := range overdue {
+		if !a.AutoReassign {
+			continue
+		}
+		newReviewerID, err := m.reviewerService.ReassignReviewerWithReason(ctx, a.PullRequestID, a.ReviewerID, metrics.ReasonSLA)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to reassign overdue reviewer",
+				"pull_request_id", a.PullRequestID, "reviewer_id", a.ReviewerID, "error", err)
+			continue
+		}
+		reassigned++
+		slog.InfoContext(ctx, "overdue reviewer reassigned",
+			"pull_request_id", a.PullRequestID, "old_reviewer_id", a.ReviewerID, "new_reviewer_id", newReviewerID,
+			"deadline", a.Deadline, "overdue_seconds", a.OverdueSeconds)
+	}
+	return reassigned, nil
+}
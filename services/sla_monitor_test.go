@@ -0,0 +1,71 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/events"
+	"Backend-trainee-assignment/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Функция создает PR команды с ревьюерами, первый из них назначен 48 часов назад
+func newOverduePR(t *testing.T, db *sqlx.DB, prID, team, author string, reviewers ...string) {
+	t.Helper()
+	ctx := context.Background()
+	prRepo := repository.NewPRRepository(db)
+	if err := prRepo.CreatePR(ctx, &models.PullRequest{PullRequestID: prID, PullRequestName: prID, AuthorID: author,
+		TeamName: team, Status: "OPEN", CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	for _, reviewer := range reviewers {
+		if err := prRepo.AddPRReviewer(ctx, prID, reviewer); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE pr_reviewers SET assigned_at = $3 WHERE pr_id = $1 AND reviewer_user_id = $2`,
+		prID, reviewers[0], time.Now().Add(-48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckOnceReplacesOverdueReviewers(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	slaRepo := repository.NewSLARepository(db)
+
+	// В команде с тремя участниками замена - не второй назначенный ревьюер, а свободный r3.
+	// В команде из двух участников замена одна, просрочивший ревьюер не возвращается дополнительным
+	prefix, team, author := newReconcileTeam(t, db, 3)
+	smallPrefix, smallTeam, smallAuthor := newReconcileTeam(t, db, 2)
+	for _, teamName := range []string{team, smallTeam} {
+		if err := slaRepo.SetTeamSLA(ctx, &models.TeamSLA{TeamName: teamName, ThresholdHours: 1, AutoReassign: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	newOverduePR(t, db, prefix+"-p1", team, author, prefix+"-r1", prefix+"-r2")
+	newOverduePR(t, db, smallPrefix+"-p1", smallTeam, smallAuthor, smallPrefix+"-r1")
+
+	reviewerService, prRepo := newTestReviewerService(db, events.NewBus(0))
+	slaService := NewSLAService(slaRepo, prRepo, repository.NewTeamRepository(db), SLAConfig{DefaultThresholdHours: 24})
+	monitor := NewSLAMonitor(slaService, reviewerService, time.Hour)
+
+	reassigned, err := monitor.CheckOnce(ctx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reassigned < 2 {
+		t.Fatalf("reassigned %d, want both overdue reviewers replaced", reassigned)
+	}
+	assertReviewers(t, db, prefix+"-p1", prefix+"-r2", prefix+"-r3")
+	assertReviewers(t, db, smallPrefix+"-p1", smallPrefix+"-r2")
+
+	// Новые ревьюеры еще в пределах SLA, повторная проверка их не трогает
+	if _, err := monitor.CheckOnce(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	assertReviewers(t, db, prefix+"-p1", prefix+"-r2", prefix+"-r3")
+	assertReviewers(t, db, smallPrefix+"-p1", smallPrefix+"-r2")
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/metrics"
+	"Backend-trainee-assignment/models"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Решения ревьюера по PR
+const (
+	DecisionApproved         = "APPROVED"
+	DecisionChangesRequested = "CHANGES_REQUESTED"
+	DecisionCommented        = "COMMENTED"
+)
+
+// Настройки SLA по умолчанию для команд без собственного порога
+type SLAConfig struct {
+	DefaultThresholdHours int
+	DefaultAutoReassign   bool
+	// Не учитывать время суббот и воскресений при расчете срока
+	BusinessDays bool
+	Location     *time.Location
+}
+
+type SLAService struct {
+	slaRepo  *repository.SLARepository
+	prRepo   *repository.PRRepository
+	teamRepo *repository.TeamRepository
+	cfg      SLAConfig
+}
+
+func NewSLAService(slaRepo *repository.SLARepository, prRepo *repository.PRRepository, teamRepo *repository.TeamRepository, cfg SLAConfig) *SLAService {
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+	return &SLAService{
+		slaRepo:  slaRepo,
+		prRepo:   prRepo,
+		teamRepo: teamRepo,
+		cfg:      cfg,
+	}
+}
+
+// Функция возвращает порог SLA команды или порог по умолчанию
+func (s *SLAService) GetTeamSLA(ctx context.Context, teamName string) (*models.TeamSLA, error) {
+	exists, err := s.teamRepo.TeamExists(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrTeamNotFound
+	}
+
+	sla, err := s.slaRepo.GetTeamSLA(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	if sla == nil {
+		sla = &models.TeamSLA{
+			TeamName:       teamName,
+			ThresholdHours: s.cfg.DefaultThresholdHours,
+			AutoReassign:   s.cfg.DefaultAutoReassign,
+			IsDefault:      true,
+		}
+	}
+	return sla, nil
+}
+
+// Функция задает порог SLA команды
+func (s *SLAService) SetTeamSLA(ctx context.Context, sla models.TeamSLA) (*models.TeamSLA, error) {
+	if sla.ThresholdHours <= 0 {
+		return nil, NewValidationError("threshold_hours must be positive")
+	}
+	exists, err := s.teamRepo.TeamExists(ctx, sla.TeamName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrTeamNotFound
+	}
+
+	sla.IsDefault = false
+	if err := s.slaRepo.SetTeamSLA(ctx, &sla); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "team sla updated",
+		"team_name", sla.TeamName, "threshold_hours", sla.ThresholdHours, "auto_reassign", sla.AutoReassign)
+	return &sla, nil
+}
+
+// Функция фиксирует решение ревьюера по PR; время реакции считается только по первому решению
+func (s *SLAService) RecordDecision(ctx context.Context, prID, reviewerID, decision string) (*models.ReviewDecision, error) {
+	switch decision {
+	case DecisionApproved, DecisionChangesRequested, DecisionCommented:
+	default:
+		return nil, NewValidationError(fmt.Sprintf("decision must be one of %s, %s, %s",
+			DecisionApproved, DecisionChangesRequested, DecisionCommented))
+	}
+
+	pr, err := s.prRepo.GetPRByID(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	if pr == nil {
+		return nil, ErrPRNotFound
+	}
+	if pr.Status == "MERGED" {
+		return nil, ErrPRMerged
+	}
+
+	result, first, err := s.slaRepo.RecordDecision(ctx, prID, reviewerID, decision, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, ErrReviewerNotAssigned
+	}
+	response := result.FirstDecisionAt.Sub(result.AssignedAt)
+	result.ResponseSeconds = int64(response / time.Second)
+	if first {
+		metrics.FirstDecisionLatency.Observe(response.Seconds())
+		slog.InfoContext(ctx, "first review decision recorded",
+			"pull_request_id", prID, "reviewer_id", reviewerID, "decision", decision, "response_seconds", result.ResponseSeconds)
+	}
+	return result, nil
+}
+
+// Функция возвращает назначения без решения, превысившие SLA; teamName - необязательный фильтр
+func (s *SLAService) Overdue(ctx context.Context, teamName string, now time.Time) ([]models.OverdueAssignment, error) {
+	pending, err := s.slaRepo.GetPendingPastThreshold(ctx, teamName, s.cfg.DefaultThresholdHours, s.cfg.DefaultAutoReassign, now)
+	if err != nil {
+		return nil, err
+	}
+
+	overdue := make([]models.OverdueAssignment, 0, len(pending))
+	for _, a := range pending {
+		a.Deadline = s.Deadline(a.AssignedAt, a.ThresholdHours)
+		if !now.After(a.Deadline) {
+			continue
+		}
+		a.OverdueSeconds = int64(now.Sub(a.Deadline) / time.Second)
+		overdue = append(overdue, a)
+	}
+	return overdue, nil
+}
+
+// Функция возвращает срок первого решения для назначения
+func (s *SLAService) Deadline(assignedAt time.Time, thresholdHours int) time.Time {
+	threshold := time.Duration(thresholdHours) * time.Hour
+	if !s.cfg.BusinessDays {
+		return assignedAt.Add(threshold)
+	}
+	return addBusinessTime(assignedAt.In(s.cfg.Location), threshold)
+}
+
+// Функция прибавляет длительность, не считая суббот и воскресений в часовом поясе t
+func addBusinessTime(t time.Time, d time.Duration) time.Time {
+	for {
+		nextDay := startOfNextDay(t)
+		if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+			t = nextDay
+			continue
+		}
+		left := nextDay.Sub(t)
+		if d <= left {
+			return t.Add(d)
+		}
+		d -= left
+		t = nextDay
+	}
+}
+
+func startOfNextDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, t.Location())
+}
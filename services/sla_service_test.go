@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSLADeadline(t *testing.T) {
+	moscow := time.FixedZone("UTC+3", 3*60*60)
+	at := func(day, hour int, loc *time.Location) time.Time {
+		// 29 февраля 2024 - четверг, 1 марта - пятница, 4 марта - понедельник
+		return time.Date(2024, time.February, 29+day, hour, 0, 0, 0, loc)
+	}
+	tests := []struct {
+		name         string
+		businessDays bool
+		location     *time.Location
+		assignedAt   time.Time
+		hours        int
+		want         time.Time
+	}{
+		{name: "calendar time counts weekend", assignedAt: at(1, 10, time.UTC), hours: 24, want: at(2, 10, time.UTC)},
+		{name: "within a weekday", businessDays: true, assignedAt: at(4, 10, time.UTC), hours: 24, want: at(5, 10, time.UTC)},
+		{name: "friday evening to monday", businessDays: true, assignedAt: at(1, 20, time.UTC), hours: 8, want: at(4, 4, time.UTC)},
+		{name: "several days over weekend", businessDays: true, assignedAt: at(0, 12, time.UTC), hours: 48, want: at(4, 12, time.UTC)},
+		{name: "whole friday ends at midnight", businessDays: true, assignedAt: at(1, 0, time.UTC), hours: 24, want: at(2, 0, time.UTC)},
+		{name: "assigned on saturday", businessDays: true, assignedAt: at(2, 12, time.UTC), hours: 1, want: at(4, 1, time.UTC)},
+		{name: "zero threshold on sunday", businessDays: true, assignedAt: at(3, 23, time.UTC), hours: 0, want: at(4, 0, time.UTC)},
+		{
+			// 22:00 пятницы по UTC - уже суббота в UTC+3
+			name: "weekend in SLA time zone", businessDays: true, location: moscow,
+			assignedAt: at(1, 22, time.UTC), hours: 1, want: at(4, 1, moscow),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSLAService(nil, nil, nil, SLAConfig{BusinessDays: tt.businessDays, Location: tt.location})
+			if got := s.Deadline(tt.assignedAt, tt.hours); !got.Equal(tt.want) {
+				t.Fatalf("Deadline(%s, %dh) = %s, want %s", tt.assignedAt, tt.hours, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/models"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Размер топа ревьюеров по умолчанию и максимальный
+const (
+	DefaultStatsTop = 10
+	MaxStatsTop     = 100
+)
+
+// Параметры отчета о справедливости по умолчанию и ограничение числа периодов в разбивке
+const (
+	DefaultFairnessWindow    = 30 * 24 * time.Hour
+	DefaultFairnessTolerance = 0.25
+	MaxFairnessPeriods       = 366
+)
+
+// Статусы участника в отчете о справедливости
+const (
+	FairnessOver     = "over"
+	FairnessUnder    = "under"
+	FairnessFair     = "fair"
+	FairnessInactive = "inactive"
+)
+
+type StatsService struct {
+	prRepo   *repository.PRRepository
+	teamRepo *repository.TeamRepository
+}
+
+func NewStatsService(prRepo *repository.PRRepository, teamRepo *repository.TeamRepository) *StatsService {
+	return &StatsService{prRepo: prRepo, teamRepo: teamRepo}
+}
+
+// Функция собирает статистику за период: итоги, разбивку по командам и периодам,
+// перцентили времени до мержа и самых загруженных ревьюеров
+func (s *StatsService) GetStats(ctx context.Context, q models.StatsQuery) (*models.StatsReport, error) {
+	q, err := normalizeStatsQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	if q.TeamName != "" {
+		exists, err := s.teamRepo.TeamExists(ctx, q.TeamName)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrTeamNotFound
+		}
+	}
+
+	report := &models.StatsReport{From: q.From, To: q.To, TeamName: q.TeamName, GroupBy: q.GroupBy}
+	teams, err := s.prRepo.GetTeamStats(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	report.Teams = teams
+	for _, team := range teams {
+		report.Totals.Assignments += team.Assignments
+		report.Totals.CreatedPRs += team.CreatedPRs
+		report.Totals.MergedPRs += team.MergedPRs
+		report.Totals.OpenPRs += team.OpenPRs
+	}
+
+	if q.GroupBy != "" {
+		if report.Periods, err = s.prRepo.GetPeriodStats(ctx, q, q.GroupBy); err != nil {
+			return nil, err
+		}
+	}
+	latency, err := s.prRepo.GetReviewLatency(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	report.Latency = *latency
+	if report.TopReviewers, err = s.prRepo.GetTopReviewers(ctx, q, q.Top); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Функция проверяет параметры статистики, подставляет размер топа по умолчанию
+// и приводит границы периода к UTC
+func normalizeStatsQuery(q models.StatsQuery) (models.StatsQuery, error) {
+	if q.From != nil && q.To != nil && !q.From.Before(*q.To) {
+		return q, NewValidationError("from must be before to")
+	}
+	switch q.GroupBy {
+	case "", repository.StatsGroupDay, repository.StatsGroupWeek, repository.StatsGroupMonth:
+	default:
+		return q, NewValidationError("group_by must be day, week or month")
+	}
+	if q.Top == 0 {
+		q.Top = DefaultStatsTop
+	}
+	if q.Top < 0 || q.Top > MaxStatsTop {
+		return q, NewValidationError(fmt.Sprintf("top must be between 1 and %d", MaxStatsTop))
+	}
+	// Время в БД хранится без зоны в UTC
+	if q.From != nil {
+		from := q.From.UTC()
+		q.From = &from
+	}
+	if q.To != nil {
+		to := q.To.UTC()
+		q.To = &to
+	}
+	return q, nil
+}
+
+// Функция строит отчет о справедливости: доля назначений каждого участника сравнивается с долей
+// его активных дней в периоде, так что отпуск и деактивация не делают участника недогруженным
+func (s *StatsService) GetFairness(ctx context.Context, q models.FairnessQuery, now time.Time) (*models.FairnessReport, error) {
+	if q.To.IsZero() {
+		q.To = now
+	}
+	if q.From.IsZero() {
+		q.From = q.To.Add(-DefaultFairnessWindow)
+	}
+	q.From, q.To = q.From.UTC(), q.To.UTC()
+	if !q.From.Before(q.To) {
+		return nil, NewValidationError("from must be before to")
+	}
+	if q.Tolerance == 0 {
+		q.Tolerance = DefaultFairnessTolerance
+	}
+	if q.Tolerance < 0 || q.Tolerance >= 1 {
+		return nil, NewValidationError("tolerance must be between 0 and 1")
+	}
+	var periods int
+	switch q.GroupBy {
+	case "":
+	case repository.StatsGroupDay:
+		periods = int(q.To.Sub(q.From) / (24 * time.Hour))
+	case repository.StatsGroupWeek:
+		periods = int(q.To.Sub(q.From) / (7 * 24 * time.Hour))
+	case repository.StatsGroupMonth:
+		periods = calendarMonths(q.From, q.To)
+	default:
+		return nil, NewValidationError("group_by must be day, week or month")
+	}
+	if periods > MaxFairnessPeriods {
+		return nil, NewValidationError(fmt.Sprintf("window is too long for %s grouping, at most %d periods", q.GroupBy, MaxFairnessPeriods))
+	}
+	if q.TeamName != "" {
+		exists, err := s.teamRepo.TeamExists(ctx, q.TeamName)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrTeamNotFound
+		}
+	}
+
+	teams, err := s.prRepo.GetMemberLoad(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	for i := range teams {
+		evaluateFairness(&teams[i], q.Tolerance)
+	}
+	return &models.FairnessReport{
+		From:      q.From,
+		To:        q.To,
+		TeamName:  q.TeamName,
+		GroupBy:   q.GroupBy,
+		Tolerance: q.Tolerance,
+		Teams:     teams,
+	}, nil
+}
+
+// Функция возвращает число полных календарных месяцев между from и to
+func calendarMonths(from, to time.Time) int {
+	months := (to.Year()-from.Year())*12 + int(to.Month()-from.Month())
+	if months > 0 && from.AddDate(0, months, 0).After(to) {
+		months--
+	}
+	return months
+}
+
+// Функция считает ожидаемые доли, статусы участников, коэффициент Джини и стандартное отклонение
+func evaluateFairness(team *models.TeamFairness, tolerance float64) {
+	team.Overloaded, team.Underloaded = []string{}, []string{}
+	var totalDays float64
+	for _, m := range team.Members {
+		totalDays += m.ActiveDays
+	}
+
+	var rates []float64
+	for i := range team.Members {
+		m := &team.Members[i]
+		if team.TotalAssignments > 0 {
+			m.Share = float64(m.Assignments) / float64(team.TotalAssignments)
+		}
+		if m.ActiveDays <= 0 || totalDays <= 0 {
+			m.Status = FairnessInactive
+			continue
+		}
+		m.ExpectedShare = m.ActiveDays / totalDays
+		m.ExpectedAssignments = m.ExpectedShare * float64(team.TotalAssignments)
+		rates = append(rates, float64(m.Assignments)/m.ActiveDays)
+
+		switch {
+		case float64(m.Assignments) > m.ExpectedAssignments*(1+tolerance):
+			m.Status = FairnessOver
+			team.Overloaded = append(team.Overloaded, m.UserID)
+		case float64(m.Assignments) < m.ExpectedAssignments*(1-tolerance):
+			m.Status = FairnessUnder
+			team.Underloaded = append(team.Underloaded, m.UserID)
+		default:
+			m.Status = FairnessFair
+		}
+	}
+	team.Gini = gini(rates)
+	team.StdDev = stdDev(rates)
+}
+
+// Функция возвращает коэффициент Джини: 0 - нагрузка одинакова, ближе к 1 - все назначения у одного
+func gini(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	var sum, weighted float64
+	for i, v := range sorted {
+		sum += v
+		weighted += float64(2*(i+1)-len(sorted)-1) * v
+	}
+	if sum == 0 {
+		return 0
+	}
+	return weighted / (float64(len(sorted)) * sum)
+}
+
+func stdDev(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(variance / float64(len(values)))
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"Backend-trainee-assignment/models"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNormalizeStatsQuery(t *testing.T) {
+	moscow := time.FixedZone("UTC+3", 3*60*60)
+	from := time.Date(2024, time.March, 1, 3, 0, 0, 0, moscow)
+	to := time.Date(2024, time.March, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		q       models.StatsQuery
+		want    models.StatsQuery
+		wantErr string
+	}{
+		{name: "defaults", q: models.StatsQuery{}, want: models.StatsQuery{Top: DefaultStatsTop}},
+		{
+			name: "bounds normalised to UTC",
+			q:    models.StatsQuery{From: &from, To: &to, GroupBy: "week", Top: MaxStatsTop},
+			want: models.StatsQuery{From: ptrTime(from.UTC()), To: &to, GroupBy: "week", Top: MaxStatsTop},
+		},
+		{name: "open window", q: models.StatsQuery{To: &to, Top: 1}, want: models.StatsQuery{To: &to, Top: 1}},
+		{name: "from equals to", q: models.StatsQuery{From: &to, To: &to}, wantErr: "from must be before to"},
+		{name: "from after to", q: models.StatsQuery{From: &to, To: &from}, wantErr: "from must be before to"},
+		// Одно и то же время в разных зонах - пустой период
+		{name: "same instant in other zone", q: models.StatsQuery{From: ptrTime(to.In(moscow)), To: &to}, wantErr: "from must be before to"},
+		{name: "unknown group_by", q: models.StatsQuery{GroupBy: "year"}, wantErr: "group_by must be day, week or month"},
+		{name: "negative top", q: models.StatsQuery{Top: -1}, wantErr: "top must be between 1 and 100"},
+		{name: "top above maximum", q: models.StatsQuery{Top: MaxStatsTop + 1}, wantErr: "top must be between 1 and 100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeStatsQuery(tt.q)
+			if tt.wantErr != "" {
+				if _, ok := err.(*ValidationError); !ok || err.Error() != tt.wantErr {
+					t.Fatalf("error %v, want validation error %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Top != tt.want.Top || got.GroupBy != tt.want.GroupBy || !sameTime(got.From, tt.want.From) || !sameTime(got.To, tt.want.To) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for _, bound := range []*time.Time{got.From, got.To} {
+				if bound != nil && bound.Location() != time.UTC {
+					t.Fatalf("bound %s is not in UTC", bound)
+				}
+			}
+		})
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func TestEvaluateFairness(t *testing.T) {
+	member := func(id string, days float64, assignments int) models.MemberFairness {
+		return models.MemberFairness{UserID: id, ActiveDays: days, Assignments: assignments}
+	}
+	tests := []struct {
+		name       string
+		tolerance  float64
+		members    []models.MemberFairness
+		wantStatus []string
+		wantGini   float64
+		wantStdDev float64
+	}{
+		{
+			name:       "equal load",
+			tolerance:  0.25,
+			members:    []models.MemberFairness{member("a", 10, 5), member("b", 10, 5), member("c", 10, 5)},
+			wantStatus: []string{FairnessFair, FairnessFair, FairnessFair},
+		},
+		{
+			// Назначения на день: 1.2, 0, 0, 0 - Джини (n-1)/n
+			name:       "all load on one member",
+			tolerance:  0.25,
+			members:    []models.MemberFairness{member("a", 10, 12), member("b", 10, 0), member("c", 10, 0), member("d", 10, 0)},
+			wantStatus: []string{FairnessOver, FairnessUnder, FairnessUnder, FairnessUnder},
+			wantGini:   0.75,
+			wantStdDev: math.Sqrt(3) * 0.3,
+		},
+		{
+			// Не работавший в периоде участник не входит в ожидаемые доли и Джини
+			name:       "zero active days",
+			tolerance:  0.25,
+			members:    []models.MemberFairness{member("a", 10, 4), member("b", 10, 4), member("c", 0, 0)},
+			wantStatus: []string{FairnessFair, FairnessFair, FairnessInactive},
+		},
+		{
+			// Ожидается по 4 назначения, отклонение ровно на tolerance еще справедливо
+			name:       "deviation equal to tolerance",
+			tolerance:  0.25,
+			members:    []models.MemberFairness{member("a", 10, 5), member("b", 10, 3)},
+			wantStatus: []string{FairnessFair, FairnessFair},
+			wantGini:   0.125,
+			wantStdDev: 0.1,
+		},
+		{
+			name:       "deviation above tolerance",
+			tolerance:  0.2,
+			members:    []models.MemberFairness{member("a", 10, 5), member("b", 10, 3)},
+			wantStatus: []string{FairnessOver, FairnessUnder},
+			wantGini:   0.125,
+			wantStdDev: 0.1,
+		},
+		{
+			// Половина активных дней - половина ожидаемых назначений
+			name:       "expected share follows active days",
+			tolerance:  0.25,
+			members:    []models.MemberFairness{member("a", 20, 8), member("b", 10, 4)},
+			wantStatus: []string{FairnessFair, FairnessFair},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			team := models.TeamFairness{Members: tt.members}
+			for _, m := range tt.members {
+				team.TotalAssignments += m.Assignments
+			}
+			evaluateFairness(&team, tt.tolerance)
+
+			var wantOver, wantUnder []string
+			for i, m := range team.Members {
+				if m.Status != tt.wantStatus[i] {
+					t.Errorf("%s: status %q, want %q (%+v)", m.UserID, m.Status, tt.wantStatus[i], m)
+				}
+				switch tt.wantStatus[i] {
+				case FairnessOver:
+					wantOver = append(wantOver, m.UserID)
+				case FairnessUnder:
+					wantUnder = append(wantUnder, m.UserID)
+				}
+			}
+			if fmt.Sprint(team.Overloaded) != fmt.Sprint(wantOver) || fmt.Sprint(team.Underloaded) != fmt.Sprint(wantUnder) {
+				t.Errorf("overloaded %v, underloaded %v, want %v, %v", team.Overloaded, team.Underloaded, wantOver, wantUnder)
+			}
+			if !closeTo(team.Gini, tt.wantGini) || !closeTo(team.StdDev, tt.wantStdDev) {
+				t.Errorf("gini %v, stddev %v, want %v, %v", team.Gini, team.StdDev, tt.wantGini, tt.wantStdDev)
+			}
+		})
+	}
+}
+
+func TestGiniAndStdDev(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     []float64
+		wantGini   float64
+		wantStdDev float64
+	}{
+		{name: "empty"},
+		{name: "single value", values: []float64{3}},
+		{name: "all zero", values: []float64{0, 0, 0}},
+		{name: "equal", values: []float64{2, 2, 2, 2}},
+		{name: "one of two", values: []float64{0, 1}, wantGini: 0.5, wantStdDev: 0.5},
+		{name: "one of five", values: []float64{0, 0, 5, 0, 0}, wantGini: 0.8, wantStdDev: 2},
+		{name: "unsorted", values: []float64{9, 2, 5, 4, 4, 7, 4, 5}, wantGini: 0.2125, wantStdDev: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gini(tt.values); !closeTo(got, tt.wantGini) {
+				t.Errorf("gini(%v) = %v, want %v", tt.values, got, tt.wantGini)
+			}
+			if got := stdDev(tt.values); !closeTo(got, tt.wantStdDev) {
+				t.Errorf("stdDev(%v) = %v, want %v", tt.values, got, tt.wantStdDev)
+			}
+		})
+	}
+}
+
+func TestCalendarMonths(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		from, to time.Time
+		want     int
+	}{
+		{from: date(2024, time.January, 1), to: date(2024, time.January, 31), want: 0},
+		{from: date(2024, time.January, 1), to: date(2024, time.February, 1), want: 1},
+		{from: date(2024, time.January, 15), to: date(2024, time.March, 14), want: 1},
+		{from: date(2024, time.January, 15), to: date(2024, time.March, 15), want: 2},
+		// 366 дней - 12 месяцев, а не 13 по 28 дней
+		{from: date(2024, time.January, 1), to: date(2025, time.January, 1), want: 12},
+		{from: date(2000, time.January, 1), to: date(2030, time.July, 1), want: 366},
+	}
+	for _, tt := range tests {
+		if got := calendarMonths(tt.from, tt.to); got != tt.want {
+			t.Errorf("calendarMonths(%s, %s) = %d, want %d", tt.from.Format(time.DateOnly), tt.to.Format(time.DateOnly), got, tt.want)
+		}
+	}
+}
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
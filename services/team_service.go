@@ -0,0 +1,369 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/metrics"
+	"Backend-trainee-assignment/models"
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Сервис управления составом команд. Каждое изменение состава вместе с переназначением
+// ревью выполняется одной транзакцией: при ошибке не меняется ничего
+type TeamService struct {
+	teamRepo        *repository.TeamRepository
+	reviewerService *ReviewerService
+}
+
+func NewTeamService(teamRepo *repository.TeamRepository, reviewerService *ReviewerService) *TeamService {
+	return &TeamService{
+		teamRepo:        teamRepo,
+		reviewerService: reviewerService,
+	}
+}
+
+// Функция создает команду с участниками, участие в других командах сохраняется.
+// Существующие пользователи добавляются без изменения имени и активности
+func (s *TeamService) CreateTeam(ctx context.Context, team models.Team) error {
+	exists, err := s.teamRepo.TeamExists(ctx, team.TeamName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrTeamExists
+	}
+
+	tx, err := s.teamRepo.BeginMembershipChange(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := tx.CreateTeam(ctx, team.TeamName); err != nil {
+		return fmt.Errorf("ошибка при создании команды: %w", err)
+	}
+	users := make([]models.User, 0, len(team.Members))
+	userIDs := make([]string, 0, len(team.Members))
+	for _, member := range team.Members {
+		users = append(users, models.User{UserID: member.UserID, Username: member.Username, IsActive: member.IsActive})
+		userIDs = append(userIDs, member.UserID)
+	}
+	if err := tx.CreateMissingUsers(ctx, users); err != nil {
+		return fmt.Errorf("ошибка при добавлении участника: %w", err)
+	}
+	if err := tx.AddMemberships(ctx, team.TeamName, userIDs); err != nil {
+		return fmt.Errorf("ошибка при добавлении участника: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Функция добавляет пользователя в команду, создавая его при необходимости.
+// Имя и активность существующего пользователя не меняются
+func (s *TeamService) AddMember(ctx context.Context, teamName string, member models.TeamMember) (*models.MembershipReport, error) {
+	tx, err := s.teamRepo.BeginMembershipChange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	user := models.User{UserID: member.UserID, Username: member.Username, IsActive: member.IsActive}
+	if err := tx.CreateMissingUsers(ctx, []models.User{user}); err != nil {
+		return nil, fmt.Errorf("ошибка при добавлении участника: %w", err)
+	}
+	if err := tx.AddMemberships(ctx, teamName, []string{member.UserID}); err != nil {
+		return nil, fmt.Errorf("ошибка при добавлении участника: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &models.MembershipReport{
+		Action:        "add_member",
+		TeamName:      teamName,
+		AffectedUsers: []string{member.UserID},
+		Reassignments: []models.Reassignment{},
+	}, nil
+}
+
+// Функция убирает пользователя из команды и переназначает его ревью внутри команды
+func (s *TeamService) RemoveMember(ctx context.Context, teamName, userID string) (*models.MembershipReport, error) {
+	tx, err := s.teamRepo.BeginMembershipChange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	release, err := s.releaseReviews(ctx, tx, teamName, []string{userID})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.RemoveMemberships(ctx, teamName, []string{userID}); err != nil {
+		return nil, fmt.Errorf("ошибка при удалении участника: %w", err)
+	}
+	if err := s.commitRelease(ctx, tx, release); err != nil {
+		return nil, err
+	}
+
+	return &models.MembershipReport{
+		Action:        "remove_member",
+		TeamName:      teamName,
+		AffectedUsers: []string{userID},
+		Reassignments: release.reassignments,
+	}, nil
+}
+
+// Функция переводит пользователя в другую команду, ревью старой команды переназначаются в ней же
+func (s *TeamService) MoveMember(ctx context.Context, userID, fromTeam, toTeam string) (*models.MembershipReport, error) {
+	tx, err := s.teamRepo.BeginMembershipChange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	release, err := s.releaseReviews(ctx, tx, fromTeam, []string{userID})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.AddMemberships(ctx, toTeam, []string{userID}); err != nil {
+		return nil, fmt.Errorf("ошибка при переводе участника: %w", err)
+	}
+	if err := tx.RemoveMemberships(ctx, fromTeam, []string{userID}); err != nil {
+		return nil, fmt.Errorf("ошибка при переводе участника: %w", err)
+	}
+	if err := s.commitRelease(ctx, tx, release); err != nil {
+		return nil, err
+	}
+
+	return &models.MembershipReport{
+		Action:        "move_member",
+		TeamName:      fromTeam,
+		NewTeamName:   toTeam,
+		AffectedUsers: []string{userID},
+		Reassignments: release.reassignments,
+	}, nil
+}
+
+// Функция приводит состав команды к списку userIDs. Новые участники добавляются раньше, чем подбираются
+// замены ревью уходящих, поэтому тоже могут их получить
+func (s *TeamService) SetMembers(ctx context.Context, teamName string, userIDs []string) (*models.MembershipReport, error) {
+	return s.ReplaceTeam(ctx, teamName, userIDs, "")
+}
+
+// Функция приводит состав команды к списку userIDs и переименовывает ее в newTeamName
+// одной транзакцией. Пустое newTeamName - без переименования
+func (s *TeamService) ReplaceTeam(ctx context.Context, teamName string, userIDs []string, newTeamName string) (*models.MembershipReport, error) {
+	tx, err := s.teamRepo.BeginMembershipChange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	members, err := tx.GetMembers(ctx, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске участников команды: %w", err)
+	}
+	current := memberIDs(members)
+	toAdd, toRemove := []string{}, []string{}
+	for _, userID := range userIDs {
+		if !contains(current, userID) && !contains(toAdd, userID) {
+			toAdd = append(toAdd, userID)
+		}
+	}
+	for _, userID := range current {
+		if !contains(userIDs, userID) {
+			toRemove = append(toRemove, userID)
+		}
+	}
+
+	if err := tx.AddMemberships(ctx, teamName, toAdd); err != nil {
+		return nil, fmt.Errorf("ошибка при добавлении участника: %w", err)
+	}
+	release, err := s.releaseReviews(ctx, tx, teamName, toRemove)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.RemoveMemberships(ctx, teamName, toRemove); err != nil {
+		return nil, fmt.Errorf("ошибка при удалении участника: %w", err)
+	}
+	if newTeamName != "" && newTeamName != teamName {
+		if err := tx.RenameTeam(ctx, teamName, newTeamName); err != nil {
+			return nil, fmt.Errorf("ошибка при переименовании команды: %w", err)
+		}
+		// События о переназначениях уходят уже с новым именем команды
+		release.teamName = newTeamName
+		for _, pr := range release.prs {
+			pr.TeamName = newTeamName
+		}
+	} else {
+		newTeamName = ""
+	}
+	if err := s.commitRelease(ctx, tx, release); err != nil {
+		return nil, err
+	}
+
+	return &models.MembershipReport{
+		Action:        "set_members",
+		TeamName:      teamName,
+		NewTeamName:   newTeamName,
+		AffectedUsers: append(toAdd, toRemove...),
+		Reassignments: release.reassignments,
+	}, nil
+}
+
+// Функция переименовывает команду, назначенные ревью не меняются
+func (s *TeamService) RenameTeam(ctx context.Context, teamName, newTeamName string) (*models.MembershipReport, error) {
+	tx, err := s.teamRepo.BeginMembershipChange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	members, err := tx.GetMembers(ctx, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске участников команды: %w", err)
+	}
+	if err := tx.RenameTeam(ctx, teamName, newTeamName); err != nil {
+		return nil, fmt.Errorf("ошибка при переименовании команды: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &models.MembershipReport{
+		Action:        "rename_team",
+		TeamName:      teamName,
+		NewTeamName:   newTeamName,
+		AffectedUsers: memberIDs(members),
+		Reassignments: []models.Reassignment{},
+	}, nil
+}
+
+// Функция удаляет команду, ревью ее участников на PR команды снимаются, у PR пропадает целевая команда
+func (s *TeamService) DeleteTeam(ctx context.Context, teamName string) (*models.MembershipReport, error) {
+	tx, err := s.teamRepo.BeginMembershipChange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	members, err := tx.GetMembers(ctx, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске участников команды: %w", err)
+	}
+	userIDs := memberIDs(members)
+
+	release, err := s.releaseReviews(ctx, tx, teamName, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.DeleteTeam(ctx, teamName); err != nil {
+		return nil, fmt.Errorf("ошибка при удалении команды: %w", err)
+	}
+	if err := s.commitRelease(ctx, tx, release); err != nil {
+		return nil, err
+	}
+
+	return &models.MembershipReport{
+		Action:        "delete_team",
+		TeamName:      teamName,
+		AffectedUsers: userIDs,
+		Reassignments: release.reassignments,
+	}, nil
+}
+
+// Переназначения ревью уходящих участников, применяемые вместе с изменением состава
+type reviewRelease struct {
+	teamName      string
+	prs           map[string]*models.PullRequest
+	reassignments []models.Reassignment
+}
+
+// Функция подбирает замену открытым ревью уходящих пользователей на PR команды внутри этой же команды.
+// PR блокируются в транзакции tx, замены записываются в нее же
+func (s *TeamService) releaseReviews(ctx context.Context, tx *repository.MembershipTx, teamName string, leavingUserIDs []string) (*reviewRelease, error) {
+	release := &reviewRelease{
+		teamName:      teamName,
+		prs:           make(map[string]*models.PullRequest),
+		reassignments: []models.Reassignment{},
+	}
+	prs, err := tx.LockTeamReviews(ctx, teamName, leavingUserIDs)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске PR ревьюера: %w", err)
+	}
+	if len(prs) == 0 {
+		return release, nil
+	}
+	members, err := tx.GetMembers(ctx, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске участников команды: %w", err)
+	}
+
+	for i := range prs {
+		pr := &prs[i]
+		release.prs[pr.PullRequestID] = pr
+		reviewers := append([]string(nil), pr.AssignedReviewers...)
+		for _, oldReviewerID := range pr.AssignedReviewers {
+			if !contains(leavingUserIDs, oldReviewerID) {
+				continue
+			}
+			reviewers = removeString(reviewers, oldReviewerID)
+
+			// Проверка критериев (активный, не автор, не назначен, не уходит)
+			var available []models.User
+			for _, member := range members {
+				if member.IsActive &&
+					member.UserID != pr.AuthorID &&
+					!contains(reviewers, member.UserID) &&
+					!contains(leavingUserIDs, member.UserID) {
+					available = append(available, member)
+				}
+			}
+
+			reassignment := models.Reassignment{PullRequestID: pr.PullRequestID, OldReviewerID: oldReviewerID}
+			if len(available) > 0 {
+				selected, err := s.reviewerService.selectReviewers(ctx, pr, available, 1)
+				if err != nil {
+					return nil, fmt.Errorf("ошибка при выборе ревьюера: %w", err)
+				}
+				reassignment.NewReviewerID = selected[0].UserID
+				reviewers = append(reviewers, selected[0].UserID)
+			}
+			release.reassignments = append(release.reassignments, reassignment)
+		}
+	}
+
+	if err := tx.Reassign(ctx, release.reassignments); err != nil {
+		return nil, fmt.Errorf("ошибка при переназначении ревьюеров: %w", err)
+	}
+	return release, nil
+}
+
+// Функция фиксирует транзакцию и только после этого сообщает о переназначениях
+func (s *TeamService) commitRelease(ctx context.Context, tx *repository.MembershipTx, release *reviewRelease) error {
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, reassignment := range release.reassignments {
+		if reassignment.NewReviewerID == "" {
+			slog.InfoContext(ctx, "reviewer removed without replacement",
+				"pull_request_id", reassignment.PullRequestID, "old_reviewer_id", reassignment.OldReviewerID,
+				"team_name", release.teamName, "reason", metrics.ReasonMembership)
+		} else {
+			metrics.ReviewersAssigned.Inc()
+			metrics.Reassignments.WithLabelValues(metrics.ReasonMembership).Inc()
+			slog.InfoContext(ctx, "reviewer replaced",
+				"pull_request_id", reassignment.PullRequestID, "old_reviewer_id", reassignment.OldReviewerID,
+				"new_reviewer_id", reassignment.NewReviewerID, "reason", metrics.ReasonMembership)
+		}
+		s.reviewerService.publishReassigned(release.prs[reassignment.PullRequestID],
+			reassignment.OldReviewerID, reassignment.NewReviewerID, metrics.ReasonMembership)
+	}
+	return nil
+}
+
+func memberIDs(members []models.User) []string {
+	ids := make([]string, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.UserID)
+	}
+	return ids
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/events"
+	"Backend-trainee-assignment/models"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Команда с автором a и участниками r1-r3, вторая команда other. На открытом p1 ревьюеры r1 и r2,
+// на смерженном p2 - r1
+type membershipFixture struct {
+	team, other, author, r1, r2, r3, p1, p2 string
+}
+
+func newMembershipFixture(t *testing.T, db *sqlx.DB) membershipFixture {
+	t.Helper()
+	ctx := context.Background()
+	prefix, team, author := newReconcileTeam(t, db, 3)
+	f := membershipFixture{team: team, other: prefix + "-other", author: author,
+		r1: prefix + "-r1", r2: prefix + "-r2", r3: prefix + "-r3", p1: prefix + "-p1", p2: prefix + "-p2"}
+
+	prRepo := repository.NewPRRepository(db)
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(repository.NewTeamRepository(db).CreateTeam(ctx, &models.Team{TeamName: f.other}))
+	for _, prID := range []string{f.p1, f.p2} {
+		must(prRepo.CreatePR(ctx, &models.PullRequest{PullRequestID: prID, PullRequestName: prID, AuthorID: author,
+			TeamName: team, Status: "OPEN", CreatedAt: time.Now()}))
+	}
+	must(prRepo.AddPRReviewer(ctx, f.p1, f.r1))
+	must(prRepo.AddPRReviewer(ctx, f.p1, f.r2))
+	must(prRepo.AddPRReviewer(ctx, f.p2, f.r1))
+	mergedAt := time.Now()
+	must(prRepo.UpdatePRStatus(ctx, f.p2, "MERGED", &mergedAt))
+	return f
+}
+
+func newTestTeamService(db *sqlx.DB) *TeamService {
+	reviewerService, _ := newTestReviewerService(db, events.NewBus(0))
+	return NewTeamService(repository.NewTeamRepository(db), reviewerService)
+}
+
+func assertReviewers(t *testing.T, db *sqlx.DB, prID string, want ...string) {
+	t.Helper()
+	reviewers, err := repository.NewPRRepository(db).GetPRReviewers(context.Background(), prID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reviewers, want) {
+		t.Fatalf("%s reviewers %v, want %v", prID, reviewers, want)
+	}
+}
+
+func assertTeams(t *testing.T, db *sqlx.DB, userID string, want ...string) *models.User {
+	t.Helper()
+	user, err := repository.NewUserRepository(db).GetUserByID(context.Background(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user == nil || len(user.Teams) != len(want) || (len(want) > 0 && !reflect.DeepEqual(user.Teams, want)) {
+		t.Fatalf("user %s: %+v, want teams %v", userID, user, want)
+	}
+	return user
+}
+
+func TestAddMemberKeepsExistingUser(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	f := newMembershipFixture(t, db)
+	userRepo := repository.NewUserRepository(db)
+	if err := userRepo.UpdateUserActiveStatus(ctx, f.r3, false); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := newTestTeamService(db).AddMember(ctx, f.other, models.TeamMember{UserID: f.r3, Username: "renamed", IsActive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Action != "add_member" || !reflect.DeepEqual(report.AffectedUsers, []string{f.r3}) {
+		t.Fatalf("report %+v", report)
+	}
+	user := assertTeams(t, db, f.r3, f.team, f.other)
+	if user.Username != f.r3 || user.IsActive {
+		t.Fatalf("existing user changed: %+v", user)
+	}
+}
+
+func TestRemoveMemberReassignsTeamReviews(t *testing.T) {
+	db := testDB(t)
+	f := newMembershipFixture(t, db)
+
+	report, err := newTestTeamService(db).RemoveMember(context.Background(), f.team, f.r1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Автор и оставшийся ревьюер не подходят, замена - r3; ревью на смерженном PR не трогается
+	want := []models.Reassignment{{PullRequestID: f.p1, OldReviewerID: f.r1, NewReviewerID: f.r3}}
+	if !reflect.DeepEqual(report.Reassignments, want) {
+		t.Fatalf("reassignments %+v, want %+v", report.Reassignments, want)
+	}
+	assertTeams(t, db, f.r1)
+	assertReviewers(t, db, f.p1, f.r2, f.r3)
+	assertReviewers(t, db, f.p2, f.r1)
+}
+
+func TestMoveMemberReassignsInOldTeam(t *testing.T) {
+	db := testDB(t)
+	f := newMembershipFixture(t, db)
+
+	report, err := newTestTeamService(db).MoveMember(context.Background(), f.r2, f.team, f.other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.Reassignment{{PullRequestID: f.p1, OldReviewerID: f.r2, NewReviewerID: f.r3}}
+	if report.NewTeamName != f.other || !reflect.DeepEqual(report.Reassignments, want) {
+		t.Fatalf("report %+v, want move to %s with %+v", report, f.other, want)
+	}
+	assertTeams(t, db, f.r2, f.other)
+	assertReviewers(t, db, f.p1, f.r1, f.r3)
+}
+
+func TestLockTeamReviewsLocksOpenPRs(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	f := newMembershipFixture(t, db)
+	prRepo := repository.NewPRRepository(db)
+
+	tx, err := repository.NewTeamRepository(db).BeginMembershipChange(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	prs, err := tx.LockTeamReviews(ctx, f.team, []string{f.r1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 1 || prs[0].PullRequestID != f.p1 || !reflect.DeepEqual(prs[0].AssignedReviewers, []string{f.r1, f.r2}) {
+		t.Fatalf("locked %+v, want only open %s with its reviewers", prs, f.p1)
+	}
+
+	// Пока транзакция открыта, фоновое дополнение пропускает PR
+	locked, err := prRepo.TryLockOpenPR(ctx, f.p1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if locked != nil {
+		locked.Release()
+		t.Fatalf("%s is not locked by the membership change", f.p1)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	locked, err = prRepo.TryLockOpenPR(ctx, f.p1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if locked == nil {
+		t.Fatalf("%s is still locked after rollback", f.p1)
+	}
+	locked.Release()
+}
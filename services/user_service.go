@@ -0,0 +1,56 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/events"
+	"Backend-trainee-assignment/models"
+	"context"
+)
+
+// Сервис управления пользователями, общий для REST и gRPC API
+type UserService struct {
+	userRepo   *repository.UserRepository
+	reconciler *Reconciler
+	bus        *events.Bus
+}
+
+func NewUserService(userRepo *repository.UserRepository, reconciler *Reconciler, bus *events.Bus) *UserService {
+	return &UserService{
+		userRepo:   userRepo,
+		reconciler: reconciler,
+		bus:        bus,
+	}
+}
+
+// Функция устанавливает флаг активности пользователя и возвращает обновленного пользователя
+func (s *UserService) SetIsActive(ctx context.Context, userID string, isActive bool) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if err := s.userRepo.UpdateUserActiveStatus(ctx, userID, isActive); err != nil {
+		return nil, err
+	}
+
+	if isActive {
+		// Появился активный пользователь, можно дополнить PR без ревьюеров
+		s.reconciler.Trigger()
+	} else if user.IsActive {
+		publishDeactivated(s.bus, user, "")
+	}
+	return s.userRepo.GetUserByID(ctx, userID)
+}
+
+// Функция публикует деактивацию пользователя, по событию на каждую его команду для фильтрации по команде
+func publishDeactivated(bus *events.Bus, user *models.User, reason string) {
+	if len(user.Teams) == 0 {
+		bus.Publish(events.Event{Type: events.UserDeactivated, UserID: user.UserID, Reason: reason})
+		return
+	}
+	for _, team := range user.Teams {
+		bus.Publish(events.Event{Type: events.UserDeactivated, UserID: user.UserID, TeamName: team, Reason: reason})
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	repository "Backend-trainee-assignment/database"
+	"Backend-trainee-assignment/models"
+	"context"
+	"log/slog"
+	"net/url"
+)
+
+// Настройка вебхуков команд для уведомлений в чат
+type WebhookService struct {
+	webhookRepo *repository.WebhookRepository
+	teamRepo    *repository.TeamRepository
+}
+
+func NewWebhookService(webhookRepo *repository.WebhookRepository, teamRepo *repository.TeamRepository) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		teamRepo:    teamRepo,
+	}
+}
+
+// Функция возвращает вебхук команды; секретная часть адреса скрыта
+func (s *WebhookService) GetTeamWebhook(ctx context.Context, teamName string) (*models.TeamWebhook, error) {
+	if err := s.requireTeam(ctx, teamName); err != nil {
+		return nil, err
+	}
+	webhook, err := s.webhookRepo.GetTeamWebhook(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	if webhook == nil {
+		return nil, ErrWebhookNotFound
+	}
+	webhook.URL = maskWebhookURL(webhook.URL)
+	return webhook, nil
+}
+
+// Функция проверяет адрес и шаблоны и сохраняет вебхук команды
+func (s *WebhookService) SetTeamWebhook(ctx context.Context, webhook models.TeamWebhook) (*models.TeamWebhook, error) {
+	parsed, err := url.Parse(webhook.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, NewValidationError("url must be an absolute http(s) URL")
+	}
+	if err := validateChatTemplates(webhook.Templates); err != nil {
+		return nil, err
+	}
+	if err := s.requireTeam(ctx, webhook.TeamName); err != nil {
+		return nil, err
+	}
+
+	if err := s.webhookRepo.SetTeamWebhook(ctx, &webhook); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "team webhook updated", "team_name", webhook.TeamName, "enabled", webhook.Enabled)
+	webhook.URL = maskWebhookURL(webhook.URL)
+	return &webhook, nil
+}
+
+// Функция удаляет вебхук команды
+func (s *WebhookService) DeleteTeamWebhook(ctx context.Context, teamName string) error {
+	deleted, err := s.webhookRepo.DeleteTeamWebhook(ctx, teamName)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrWebhookNotFound
+	}
+	slog.InfoContext(ctx, "team webhook deleted", "team_name", teamName)
+	return nil
+}
+
+func (s *WebhookService) requireTeam(ctx context.Context, teamName string) error {
+	exists, err := s.teamRepo.TeamExists(ctx, teamName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrTeamNotFound
+	}
+	return nil
+}
+
+// Функция скрывает путь и параметры адреса вебхука: в них содержится секрет
+func maskWebhookURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return "***"
+	}
+	return parsed.Scheme + "://" + parsed.Host + "/***"
+}